@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go-transaction/config"
+	"go-transaction/consumer"
+	"go-transaction/tracing"
+
+	"github.com/rs/zerolog/log"
+)
+
+// main runs the RabbitMQ command consumer on its own, without the HTTP server.
+// It accepts the same flags as the server (see config.Load) and, without -config,
+// must be started from the repository root so the ./config files are found.
+func main() {
+	if _, err := config.Load(os.Args[1:]); err != nil {
+		log.Error().Err(err).Msg("Error loading configuration")
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Error setting up tracing")
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.WithoutCancel(ctx))
+
+	if err := consumer.Run(ctx); err != nil {
+		log.Error().Err(err).Msg("RabbitMQ consumer failed")
+		shutdownTracing(context.WithoutCancel(ctx))
+		os.Exit(1)
+	}
+}
@@ -2,32 +2,29 @@ package config
 
 import (
 	"context"
-	"fmt"
 	"go-transaction/entity"
+	"go-transaction/metrics"
 
 	"github.com/rs/zerolog/log"
 
 	"cloud.google.com/go/firestore"
 	firebase "firebase.google.com/go/v4"
 	"google.golang.org/api/option"
-
-	"github.com/knadh/koanf"
-	"github.com/knadh/koanf/parsers/yaml"
-	"github.com/knadh/koanf/providers/env"
-	"github.com/knadh/koanf/providers/file"
-	"github.com/subosito/gotenv"
 )
 
-// InitFirebase initializes the Firebase application with the given credentials and project ID.
+// InitFirebase initializes the Firebase application with the credentials file and project ID
+// from the firebase section of the configuration. Firestore clients created from it report call latencies to metrics.
 // It returns the Firebase app instance or an error if the initialization fails.
 func InitFirebase() (*firebase.App, error) {
-	filepath := "/etc/secrets/firebase.json"
-	projectID := "crud-b5a48"
-	opt := option.WithCredentialsFile(filepath)
+	cfg, err := Get()
+	if err != nil {
+		return nil, err
+	}
+	opts := append(metrics.FirestoreOptions(), option.WithCredentialsFile(cfg.Firebase.CredentialsFile))
 
 	app, err := firebase.NewApp(context.Background(), &firebase.Config{
-		ProjectID: projectID,
-	}, opt)
+		ProjectID: cfg.Firebase.ProjectID,
+	}, opts...)
 	if err != nil {
 		log.Error().Err(err).Msg("Error initializing Firebase app")
 		return nil, err
@@ -46,7 +43,9 @@ func GetFirestoreClient(app *firebase.App) (*firestore.Client, error) {
 	return client, nil
 }
 
-func FirebaseInitialization() (*firestore.Client,error){
+// FirebaseInitialization initializes the Firebase app and returns a Firestore client for it.
+// The caller owns the returned client and is responsible for closing it.
+func FirebaseInitialization() (*firestore.Client, error) {
 	app, err := InitFirebase()
 	if err != nil {
 		return nil, err
@@ -57,141 +56,279 @@ func FirebaseInitialization() (*firestore.Client,error){
 	if err != nil {
 		return nil, err
 	}
-	defer client.Close()
 
 	return client, nil
 }
 
-// GetServerYamlConfig loads and returns the server configuration from the YAML file.
-// It uses the project environment loaded from .env file and reads the config file accordingly.
-// It returns a ServerConfig struct or an error if the file cannot be read or unmarshaled.
+// GetServerYamlConfig returns the server settings (port and shutdown timeout) from the loaded configuration.
+// The configuration is loaded once (see Load); the returned struct is a copy the caller may modify.
 func GetServerYamlConfig() (*entity.ServerConfig, error) {
-	var path = fmt.Sprintf("./config/config.%s.yaml", ReadEnvConfig())
+	cfg, err := Get()
+	if err != nil {
+		return nil, err
+	}
 
-	var serverConfig entity.ServerConfig
+	serverConfig := cfg.Server
+	return &serverConfig, nil
+}
 
-	y := koanf.New(".")
-	err := y.Load(file.Provider(path), yaml.Parser())
+// GetPaymentAmountYamlConfig returns the payment limits from the loaded configuration.
+func GetPaymentAmountYamlConfig() (*entity.PaymentConfig, error) {
+	cfg, err := Get()
 	if err != nil {
-		log.Error().Err(err).Msg("Error reading server config YAML")
-		return nil, fmt.Errorf("unable to read config: %v", err)
+		return nil, err
 	}
 
-	err = y.Unmarshal("", &serverConfig)
+	paymentConfig := cfg.Payment
+	return &paymentConfig, nil
+}
+
+// GetSwaggerYamlConfig returns the Swagger settings from the loaded configuration.
+func GetSwaggerYamlConfig() (*entity.Swagger, error) {
+	cfg, err := Get()
 	if err != nil {
-		log.Error().Err(err).Msg("Error unmarshaling server config")
-		return nil, fmt.Errorf("error loading config file: %v", err)
+		return nil, err
 	}
 
-	return &serverConfig, nil
+	swagger := cfg.Swagger
+	return &swagger, nil
 }
 
-// GetPaymentAmountYamlConfig loads and returns the payment configuration from the YAML file.
-// It reads the configuration based on the project environment and unmarshals it into a PaymentConfig struct.
-func GetPaymentAmountYamlConfig() (*entity.PaymentConfig, error) {
-	var path = fmt.Sprintf("./config/config.%s.yaml", ReadEnvConfig())
+// GetApiYamlConfig returns the API version prefix from the loaded configuration.
+func GetApiYamlConfig() (*entity.Api, error) {
+	cfg, err := Get()
+	if err != nil {
+		return nil, err
+	}
 
-	var paymentConfig entity.PaymentConfig
+	api := cfg.Api
+	return &api, nil
+}
 
-	y := koanf.New(".")
-	err := y.Load(file.Provider(path), yaml.Parser())
+// GetReviewYamlConfig returns the manual review queue configuration from the loaded configuration.
+func GetReviewYamlConfig() (*entity.ReviewConfig, error) {
+	cfg, err := Get()
 	if err != nil {
-		log.Error().Err(err).Msg("Error reading payment config YAML")
-		return nil, fmt.Errorf("unable to read config: %v", err)
+		return nil, err
 	}
 
-	err = y.Unmarshal("paymentconfig", &paymentConfig)
+	reviewConfig := cfg.Review
+	return &reviewConfig, nil
+}
+
+// GetAuthorizationYamlConfig returns the authorization (hold) configuration from the loaded configuration.
+func GetAuthorizationYamlConfig() (*entity.AuthorizationConfig, error) {
+	cfg, err := Get()
 	if err != nil {
-		log.Error().Err(err).Msg("Error unmarshaling payment config")
-		return nil, fmt.Errorf("error loading config file: %v", err)
+		return nil, err
 	}
 
-	return &paymentConfig, nil
+	authorizationConfig := cfg.Authorization
+	return &authorizationConfig, nil
 }
 
-// GetSwaggerYamlConfig loads and returns the Swagger configuration from the YAML file.
-// It reads the Swagger section of the configuration and unmarshals it into a Swagger struct.
-func GetSwaggerYamlConfig() (*entity.Swagger, error) {
-	var path = fmt.Sprintf("./config/config.%s.yaml", ReadEnvConfig())
+// GetSchedulerYamlConfig returns the scheduler configuration from the loaded configuration.
+func GetSchedulerYamlConfig() (*entity.SchedulerConfig, error) {
+	cfg, err := Get()
+	if err != nil {
+		return nil, err
+	}
 
-	var swagger entity.Swagger
+	schedulerConfig := cfg.Scheduler
+	return &schedulerConfig, nil
+}
 
-	k := koanf.New(".")
-	err := k.Load(file.Provider(path), yaml.Parser())
+// GetPaymentRequestYamlConfig returns the payment request lifecycle configuration from the loaded configuration.
+func GetPaymentRequestYamlConfig() (*entity.PaymentRequestConfig, error) {
+	cfg, err := Get()
 	if err != nil {
-		log.Error().Err(err).Msg("Error reading Swagger config YAML")
-		return nil, fmt.Errorf("unable to read config: %v", err)
+		return nil, err
 	}
 
-	err = k.Unmarshal("swagger", &swagger)
+	paymentRequestConfig := cfg.PaymentRequest
+	return &paymentRequestConfig, nil
+}
+
+// GetRabbitMQYamlConfig returns the RabbitMQ consumer configuration from the loaded configuration.
+func GetRabbitMQYamlConfig() (*entity.RabbitMQConfig, error) {
+	cfg, err := Get()
 	if err != nil {
-		log.Error().Err(err).Msg("Error unmarshaling Swagger config")
-		return nil, fmt.Errorf("error loading config file: %v", err)
+		return nil, err
 	}
 
-	return &swagger, nil
+	rabbitMQConfig := cfg.RabbitMQ
+	return &rabbitMQConfig, nil
 }
 
-// GetApiYamlConfig loads and returns the API configuration from the YAML file.
-// It reads the API section of the configuration and unmarshals it into an Api struct.
-func GetApiYamlConfig() (*entity.Api, error) {
-	var path = fmt.Sprintf("./config/config.%s.yaml", ReadEnvConfig())
+// GetOutboxYamlConfig returns the outbox relay configuration from the loaded configuration.
+func GetOutboxYamlConfig() (*entity.OutboxConfig, error) {
+	cfg, err := Get()
+	if err != nil {
+		return nil, err
+	}
 
-	var api entity.Api
+	outboxConfig := cfg.Outbox
+	return &outboxConfig, nil
+}
 
-	k := koanf.New(".")
-	err := k.Load(file.Provider(path), yaml.Parser())
+// GetWebhookYamlConfig returns the webhook delivery configuration from the loaded configuration.
+func GetWebhookYamlConfig() (*entity.WebhookConfig, error) {
+	cfg, err := Get()
 	if err != nil {
-		log.Error().Err(err).Msg("Error reading API config YAML")
-		return nil, fmt.Errorf("unable to read config: %v", err)
+		return nil, err
 	}
 
-	err = k.Unmarshal("", &api)
+	webhookConfig := cfg.Webhook
+	return &webhookConfig, nil
+}
+
+// GetTracingYamlConfig returns the tracing configuration from the loaded configuration.
+func GetTracingYamlConfig() (*entity.TracingConfig, error) {
+	cfg, err := Get()
 	if err != nil {
-		log.Error().Err(err).Msg("Error unmarshaling API config")
-		return nil, fmt.Errorf("error loading config file: %v", err)
+		return nil, err
 	}
 
-	return &api, nil
+	tracingConfig := cfg.Tracing
+	return &tracingConfig, nil
 }
 
-// ReadEnvConfig loads the environment configuration from the .env file.
-// It reads the "PROJECT" value from the environment variables and returns it.
-func ReadEnvConfig() string {
-	if err := gotenv.Load("/etc/secrets/.env"); err != nil {
-		log.Fatal().Err(err).Msg("Error loading .env file")
+// GetRateLimitYamlConfig returns the rate limit and login lockout configuration from the loaded configuration.
+func GetRateLimitYamlConfig() (*entity.RateLimitConfig, error) {
+	cfg, err := Get()
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimitConfig := cfg.RateLimit
+	return &rateLimitConfig, nil
+}
+
+// GetCORSYamlConfig returns the CORS policy from the loaded configuration.
+func GetCORSYamlConfig() (*entity.CORSConfig, error) {
+	cfg, err := Get()
+	if err != nil {
+		return nil, err
 	}
 
-	var k = koanf.New(".")
-	err := k.Load(env.Provider("", ".", func(s string) string {
-		return s
-	}), nil)
+	corsConfig := cfg.CORS
+	return &corsConfig, nil
+}
 
+// GetSecurityYamlConfig returns the security headers configuration from the loaded configuration.
+func GetSecurityYamlConfig() (*entity.SecurityConfig, error) {
+	cfg, err := Get()
 	if err != nil {
-		log.Fatal().Err(err).Msg("Error loading environment variables")
+		return nil, err
 	}
 
-	project := k.String("PROJECT")
-	return project
+	securityConfig := cfg.Security
+	return &securityConfig, nil
 }
 
-// GetKey loads the secret key and secret string from the environment configuration.
-// It reads the values of "SECRET_KEY" and "SECRET_STRING" and returns them.
-func GetKey() (string, string) {
-	if err := gotenv.Load("/etc/secrets/.env"); err != nil {
-		log.Fatal().Err(err).Msg("Error loading .env file")
+// GetStepUpYamlConfig returns the step-up authentication configuration from the loaded configuration.
+func GetStepUpYamlConfig() (*entity.StepUpConfig, error) {
+	cfg, err := Get()
+	if err != nil {
+		return nil, err
+	}
+
+	stepUpConfig := cfg.StepUp
+	return &stepUpConfig, nil
+}
+
+// GetUPIPinYamlConfig returns the UPI PIN configuration from the loaded configuration.
+func GetUPIPinYamlConfig() (*entity.UPIPinConfig, error) {
+	cfg, err := Get()
+	if err != nil {
+		return nil, err
+	}
+
+	upiPinConfig := cfg.UPIPin
+	return &upiPinConfig, nil
+}
+
+// GetBeneficiaryYamlConfig returns the beneficiary configuration from the loaded configuration.
+func GetBeneficiaryYamlConfig() (*entity.BeneficiaryConfig, error) {
+	cfg, err := Get()
+	if err != nil {
+		return nil, err
 	}
 
-	var k = koanf.New(".")
-	err := k.Load(env.Provider("", ".", func(s string) string {
-		return s
-	}), nil)
+	beneficiaryConfig := cfg.Beneficiary
+	return &beneficiaryConfig, nil
+}
 
+// GetAccountYamlConfig returns the account lifecycle configuration from the loaded configuration.
+func GetAccountYamlConfig() (*entity.AccountConfig, error) {
+	cfg, err := Get()
 	if err != nil {
-		log.Fatal().Err(err).Msg("Error loading environment variables")
+		return nil, err
 	}
 
-	key := k.String("SECRET_KEY")
-	s := k.String("SECRET_STRING")
-	return key, s
+	accountConfig := cfg.Account
+	return &accountConfig, nil
+}
+
+// GetCreditYamlConfig returns the credit line configuration from the loaded configuration.
+func GetCreditYamlConfig() (*entity.CreditConfig, error) {
+	cfg, err := Get()
+	if err != nil {
+		return nil, err
+	}
+
+	creditConfig := cfg.Credit
+	return &creditConfig, nil
+}
+
+// GetFeeYamlConfig returns the transfer pricing configuration from the loaded configuration.
+func GetFeeYamlConfig() (*entity.FeeConfig, error) {
+	cfg, err := Get()
+	if err != nil {
+		return nil, err
+	}
+
+	feeConfig := cfg.Fees
+	return &feeConfig, nil
+}
+
+// GetQuoteYamlConfig returns the transfer quote configuration from the loaded configuration.
+func GetQuoteYamlConfig() (*entity.QuoteConfig, error) {
+	cfg, err := Get()
+	if err != nil {
+		return nil, err
+	}
+
+	quoteConfig := cfg.Quote
+	return &quoteConfig, nil
+}
+
+// ReadEnvConfig returns the project environment (e.g. 'stag', 'prod') of the loaded configuration,
+// or an empty string if the configuration could not be loaded.
+func ReadEnvConfig() string {
+	cfg, err := Get()
+	if err != nil {
+		return ""
+	}
+	return cfg.Project
+}
+
+// GetKey returns the JWT signing key and issuer from the secrets section of the loaded configuration.
+// Both are empty if the configuration could not be loaded.
+func GetKey() (string, string) {
+	cfg, err := Get()
+	if err != nil {
+		return "", ""
+	}
+	return cfg.Secrets.JWTKey, cfg.Secrets.JWTIssuer
+}
+
+// GetQuoteKey returns the key transfer quote IDs are signed with, or an empty string if the
+// configuration could not be loaded.
+func GetQuoteKey() string {
+	cfg, err := Get()
+	if err != nil {
+		return ""
+	}
+	return cfg.Secrets.QuoteKey
 }
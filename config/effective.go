@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"go-transaction/entity"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+// secretKeys are redacted wherever the configuration is shown or logged.
+var secretKeys = map[string]bool{
+	"secrets.jwtkey":    true,
+	"secrets.jwtissuer": true,
+	"secrets.quotekey":  true,
+}
+
+// change is a single key whose value differs between two configurations.
+type change struct {
+	Key string
+	Old string
+	New string
+}
+
+// Effective returns the active configuration as flat "section.key" values, with secrets redacted.
+func Effective() (map[string]string, error) {
+	cfg, err := Get()
+	if err != nil {
+		return nil, err
+	}
+	return flatten(cfg, true), nil
+}
+
+// diff lists the keys that differ between two configurations, sorted by key.
+// Values are compared in full but reported redacted.
+func diff(old, new *entity.Config) []change {
+	if old == nil {
+		old = &entity.Config{}
+	}
+
+	oldValues, newValues := flatten(old, false), flatten(new, false)
+	oldShown, newShown := flatten(old, true), flatten(new, true)
+
+	var changes []change
+	for key, value := range newValues {
+		if oldValues[key] != value {
+			changes = append(changes, change{Key: key, Old: oldShown[key], New: newShown[key]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// flatten turns a configuration into "section.key" → value using the koanf tags,
+// optionally redacting secrets and the password of connection URLs.
+func flatten(cfg *entity.Config, redact bool) map[string]string {
+	values := map[string]string{}
+	flattenStruct(reflect.ValueOf(*cfg), "", values)
+
+	if redact {
+		for key, value := range values {
+			if secretKeys[key] && value != "" {
+				values[key] = redacted
+			} else if strings.HasSuffix(key, ".url") {
+				values[key] = redactURL(value)
+			}
+		}
+	}
+	return values
+}
+
+func flattenStruct(v reflect.Value, prefix string, values map[string]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("koanf")
+		field := v.Field(i)
+
+		if tag == ",squash" {
+			flattenStruct(field, prefix, values)
+			continue
+		}
+
+		key := prefix + tag
+		if _, ok := field.Interface().(time.Duration); !ok && field.Kind() == reflect.Struct {
+			flattenStruct(field, key+".", values)
+			continue
+		}
+		values[key] = fmt.Sprint(field.Interface())
+	}
+}
+
+// redactURL hides the password in the user info of a URL, leaving anything else untouched.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	if _, ok := u.User.Password(); !ok {
+		return raw
+	}
+	u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	return u.String()
+}
@@ -0,0 +1,352 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go-transaction/entity"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/providers/env"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/rs/zerolog/log"
+	"github.com/subosito/gotenv"
+)
+
+// EnvPrefix is the prefix of environment variables that override configuration keys.
+// The rest of the name is the key path with '_' as the separator, e.g. TX_RABBITMQ_URL sets rabbitmq.url.
+const EnvPrefix = "TX_"
+
+const defaultEnvFile = "/etc/secrets/.env"
+
+// defaults are the lowest configuration layer.
+var defaults = map[string]interface{}{
+	"port":                     8080,
+	"shutdowntimeout":          "30s",
+	"firebase.projectid":       "crud-b5a48",
+	"firebase.credentialsfile": "/etc/secrets/firebase.json",
+}
+
+// legacyEnv maps the environment variables used before EnvPrefix was introduced to their keys.
+var legacyEnv = map[string]string{
+	"PROJECT":       "project",
+	"SECRET_KEY":    "secrets.jwtkey",
+	"SECRET_STRING": "secrets.jwtissuer",
+}
+
+var (
+	current atomic.Pointer[entity.Config]
+	loadMu  sync.Mutex
+
+	// loadArgs and loadedFile remember how the active configuration was built so Reload can repeat it.
+	loadArgs   []string
+	loadedFile string
+)
+
+// Load builds the configuration from, in increasing order of precedence:
+//
+//   - built-in defaults,
+//   - the YAML file (./config/config.<project>.yaml unless -config or TX_CONFIG_FILE is given),
+//   - the .env file (/etc/secrets/.env unless -env-file or TX_ENV_FILE is given) and the environment:
+//     PROJECT, SECRET_KEY and SECRET_STRING, then any TX_* variable,
+//   - command-line flags: -project and repeatable -set key=value.
+//
+// The result is validated and becomes the configuration returned by Get. Load is meant to be
+// called once at startup; args are the command-line arguments without the program name.
+func Load(args []string) (*entity.Config, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	cfg, path, err := load(args)
+	if err != nil {
+		return nil, err
+	}
+
+	loadArgs, loadedFile = args, path
+	current.Store(cfg)
+	return cfg, nil
+}
+
+// Get returns the loaded configuration. If Load has not been called, the configuration is
+// loaded from the defaults, files and environment without flags.
+func Get() (*entity.Config, error) {
+	if cfg := current.Load(); cfg != nil {
+		return cfg, nil
+	}
+
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	if cfg := current.Load(); cfg != nil {
+		return cfg, nil
+	}
+
+	cfg, path, err := load(nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Error loading configuration")
+		return nil, err
+	}
+
+	loadedFile = path
+	current.Store(cfg)
+	return cfg, nil
+}
+
+// setFlags collects repeated -set key=value flags.
+type setFlags map[string]interface{}
+
+func (s setFlags) String() string { return "" }
+
+func (s setFlags) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	s[strings.ToLower(key)] = val
+	return nil
+}
+
+// options holds the command-line flags understood by Load.
+type options struct {
+	configFile string
+	envFile    string
+	project    string
+	set        setFlags
+}
+
+func parseFlags(args []string) (*options, error) {
+	opts := &options{set: setFlags{}}
+
+	fs := flag.NewFlagSet("go-transaction", flag.ContinueOnError)
+	fs.StringVar(&opts.configFile, "config", "", "path of the YAML config file (default ./config/config.<project>.yaml)")
+	fs.StringVar(&opts.envFile, "env-file", "", "path of the .env file (default "+defaultEnvFile+")")
+	fs.StringVar(&opts.project, "project", "", "deployment environment, e.g. stag or prod (overrides PROJECT)")
+	fs.Var(opts.set, "set", "override a config key, e.g. -set rabbitmq.enabled=true (repeatable)")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// load builds and validates a configuration and returns it with the path of the YAML file it read.
+func load(args []string) (*entity.Config, string, error) {
+	opts, err := parseFlags(args)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid command-line flags: %v", err)
+	}
+
+	// The .env file only fills variables that are not already set in the environment
+	envFile := firstNonEmpty(opts.envFile, os.Getenv(EnvPrefix+"ENV_FILE"), defaultEnvFile)
+	if err := gotenv.Load(envFile); err != nil {
+		if opts.envFile != "" || os.Getenv(EnvPrefix+"ENV_FILE") != "" {
+			return nil, "", fmt.Errorf("unable to read env file %s: %v", envFile, err)
+		}
+		log.Warn().Str("path", envFile).Msg("No .env file found, using the environment only")
+	}
+
+	project := firstNonEmpty(opts.project, os.Getenv(EnvPrefix+"PROJECT"), os.Getenv("PROJECT"))
+	configFile := firstNonEmpty(opts.configFile, os.Getenv(EnvPrefix+"CONFIG_FILE"))
+	if configFile == "" {
+		if project == "" {
+			return nil, "", errors.New("project is not set: set PROJECT, TX_PROJECT or pass -project")
+		}
+		configFile = fmt.Sprintf("./config/config.%s.yaml", project)
+	}
+
+	k := koanf.New(".")
+
+	if err := k.Load(confmap.Provider(defaults, "."), nil); err != nil {
+		return nil, "", fmt.Errorf("error loading config defaults: %v", err)
+	}
+
+	if err := k.Load(file.Provider(configFile), yaml.Parser()); err != nil {
+		return nil, "", fmt.Errorf("unable to read config file %s: %v", configFile, err)
+	}
+
+	err = k.Load(env.ProviderWithValue("", ".", func(key, value string) (string, interface{}) {
+		return legacyEnv[key], value
+	}), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("error loading environment variables: %v", err)
+	}
+
+	err = k.Load(env.Provider(EnvPrefix, ".", func(key string) string {
+		return strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(key, EnvPrefix)), "_", ".")
+	}), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("error loading environment variables: %v", err)
+	}
+
+	if err := k.Load(confmap.Provider(opts.set, "."), nil); err != nil {
+		return nil, "", fmt.Errorf("error loading -set flags: %v", err)
+	}
+
+	if project != "" {
+		k.Set("project", project)
+	}
+
+	var cfg entity.Config
+	if err := k.Unmarshal("", &cfg); err != nil {
+		return nil, "", fmt.Errorf("error loading config file: %v", err)
+	}
+
+	if err := Validate(&cfg); err != nil {
+		return nil, "", err
+	}
+
+	return &cfg, configFile, nil
+}
+
+// Validate checks a configuration and returns every problem found, one per line.
+func Validate(cfg *entity.Config) error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(cfg.Project != "", "project must be set")
+	check(cfg.Server.Port > 0 && cfg.Server.Port <= 65535, "port must be between 1 and 65535, got %d", cfg.Server.Port)
+	check(cfg.Server.ShutdownTimeout >= 0, "shutdowntimeout must not be negative")
+	check(cfg.Api.Api != "", "api must be set")
+
+	check(cfg.Firebase.ProjectID != "", "firebase.projectid must be set")
+	check(cfg.Firebase.CredentialsFile != "", "firebase.credentialsfile must be set")
+	check(cfg.Secrets.JWTKey != "", "secrets.jwtkey must be set (SECRET_KEY or TX_SECRETS_JWTKEY)")
+	check(cfg.Secrets.QuoteKey != "", "secrets.quotekey must be set (TX_SECRETS_QUOTEKEY)")
+	check(cfg.Secrets.QuoteKey != cfg.Secrets.JWTKey, "secrets.quotekey must differ from secrets.jwtkey")
+
+	check(cfg.Payment.MaxUpiAmount > 0, "paymentconfig.upi must be greater than 0")
+	check(cfg.Payment.MaxCreditAmount > 0, "paymentconfig.credit must be greater than 0")
+
+	check(cfg.Review.UpiThreshold >= 0, "review.upi must not be negative")
+	check(cfg.Review.CreditThreshold >= 0, "review.credit must not be negative")
+	check(cfg.Review.Timeout > 0, "review.timeout must be greater than 0")
+	check(cfg.Authorization.TTL > 0, "authorization.ttl must be greater than 0")
+	check(cfg.Scheduler.RunTimeout >= 0, "scheduler.runtimeout must not be negative")
+	check(cfg.PaymentRequest.Expiry > 0, "paymentrequest.expiry must be greater than 0")
+	check(cfg.PaymentRequest.MaxExpiry == 0 || cfg.PaymentRequest.MaxExpiry >= cfg.PaymentRequest.Expiry,
+		"paymentrequest.maxexpiry must not be shorter than paymentrequest.expiry")
+	check(cfg.PaymentRequest.ReminderBefore >= 0, "paymentrequest.reminderbefore must not be negative")
+
+	if cfg.RabbitMQ.Enabled || cfg.Outbox.Enabled {
+		check(strings.HasPrefix(cfg.RabbitMQ.URL, "amqp://") || strings.HasPrefix(cfg.RabbitMQ.URL, "amqps://"),
+			"rabbitmq.url must be an amqp:// or amqps:// URL")
+	}
+	if cfg.RabbitMQ.Enabled {
+		check(cfg.RabbitMQ.Queue != "", "rabbitmq.queue must be set")
+		check(cfg.RabbitMQ.DeadLetterExchange != "", "rabbitmq.deadletterexchange must be set")
+		check(cfg.RabbitMQ.DeadLetterQueue != "", "rabbitmq.deadletterqueue must be set")
+		check(cfg.RabbitMQ.Prefetch >= 0, "rabbitmq.prefetch must not be negative")
+	}
+	if cfg.Outbox.Enabled {
+		check(cfg.Outbox.Exchange != "", "outbox.exchange must be set")
+	}
+
+	if cfg.Tracing.Enabled {
+		switch cfg.Tracing.Exporter {
+		case "stdout", "otlp", "none":
+		case "file":
+			check(cfg.Tracing.File != "", "tracing.file must be set for the file exporter")
+		default:
+			check(false, "tracing.exporter must be one of stdout, file, otlp or none, got %q", cfg.Tracing.Exporter)
+		}
+		check(cfg.Tracing.SampleRatio >= 0 && cfg.Tracing.SampleRatio <= 1, "tracing.sampleratio must be between 0 and 1")
+	}
+
+	switch cfg.RateLimit.Store {
+	case "", "memory":
+	default:
+		check(false, "ratelimit.store must be memory, got %q", cfg.RateLimit.Store)
+	}
+	for _, group := range []string{"login", "money", "verify", "default"} {
+		rule := cfg.RateLimit.Rule(group)
+		for name, limit := range map[string]entity.RateLimit{"perip": rule.PerIP, "peruser": rule.PerUser} {
+			check(limit.Requests >= 0, "ratelimit.%s.%s.requests must not be negative", group, name)
+			check(limit.Requests == 0 || limit.Period > 0, "ratelimit.%s.%s.period must be greater than 0", group, name)
+		}
+	}
+	check(cfg.RateLimit.Lockout.Threshold >= 0, "ratelimit.lockout.threshold must not be negative")
+	check(cfg.RateLimit.Lockout.Threshold == 0 || cfg.RateLimit.Lockout.BaseDelay > 0,
+		"ratelimit.lockout.basedelay must be greater than 0")
+
+	for _, origin := range cfg.CORS.AllowOrigins {
+		if origin == "*" {
+			check(!cfg.CORS.AllowCredentials, "cors.alloworigins must not contain '*' when cors.allowcredentials is true")
+			continue
+		}
+		check(strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://"),
+			"cors origin %q must start with http:// or https://", origin)
+		check(strings.Count(origin, "*") <= 1, "cors origin %q may contain at most one '*'", origin)
+	}
+	check(cfg.CORS.MaxAge >= 0, "cors.maxage must not be negative")
+	switch strings.ToUpper(cfg.Security.FrameOptions) {
+	case "", "DENY", "SAMEORIGIN":
+	default:
+		check(false, "security.frameoptions must be DENY or SAMEORIGIN, got %q", cfg.Security.FrameOptions)
+	}
+	check(cfg.Security.HSTSMaxAge >= 0, "security.hstsmaxage must not be negative")
+
+	if cfg.StepUp.Enabled {
+		check(cfg.StepUp.Amount >= 0, "stepup.amount must not be negative")
+		check(cfg.StepUp.ChallengeTTL > 0, "stepup.challengettl must be greater than 0")
+		check(cfg.StepUp.MaxAttempts > 0, "stepup.maxattempts must be greater than 0")
+		check(cfg.StepUp.Issuer != "", "stepup.issuer must be set")
+	}
+
+	if cfg.UPIPin.Enabled {
+		check(cfg.UPIPin.Length >= 4 && cfg.UPIPin.Length <= 6, "upipin.length must be between 4 and 6")
+		check(cfg.UPIPin.MaxAttempts > 0, "upipin.maxattempts must be greater than 0")
+		check(cfg.UPIPin.Lockout > 0, "upipin.lockout must be greater than 0")
+	}
+
+	check(cfg.Beneficiary.CoolingOff >= 0, "beneficiary.coolingoff must not be negative")
+	check(cfg.Beneficiary.CoolingOffLimit >= 0, "beneficiary.coolingofflimit must not be negative")
+
+	check(cfg.Account.DormantAfter >= 0, "account.dormantafter must not be negative")
+
+	check(cfg.Credit.InterestRate >= 0, "credit.interestrate must not be negative")
+
+	for i, rule := range cfg.Fees.Rules {
+		check(rule.Name != "", "fees.rules[%d].name must be set", i)
+		check(rule.MaxAmount == 0 || rule.MaxAmount >= rule.MinAmount, "fee rule %s: maxamount must not be below minamount", rule.Name)
+		check(rule.Fixed >= 0 && rule.MinFee >= 0, "fee rule %s: fixed and minfee must not be negative", rule.Name)
+		check(rule.Percent >= 0 && rule.Percent <= 100, "fee rule %s: percent must be between 0 and 100", rule.Name)
+		check(rule.MaxFee == 0 || rule.MaxFee >= rule.MinFee, "fee rule %s: maxfee must not be below minfee", rule.Name)
+		for j, tier := range rule.Tiers {
+			check(tier.Percent >= 0 && tier.Percent <= 100, "fee rule %s: tier percent must be between 0 and 100", rule.Name)
+			if j < len(rule.Tiers)-1 {
+				next := rule.Tiers[j+1].UpTo
+				check(tier.UpTo > 0 && (next == 0 || next > tier.UpTo), "fee rule %s: tiers must be in increasing order, with only the last one open-ended", rule.Name)
+			}
+		}
+	}
+	check(len(cfg.Fees.Rules) == 0 || cfg.Fees.RevenueAccount != "", "fees.revenueaccount must be set when fee rules are configured")
+
+	check(cfg.Quote.TTL > 0, "quote.ttl must be greater than 0")
+
+	check(cfg.Webhook.MaxAttempts > 0, "webhook.maxattempts must be greater than 0")
+	check(cfg.Webhook.BaseBackoff >= 0 && cfg.Webhook.MaxBackoff >= 0, "webhook backoffs must not be negative")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
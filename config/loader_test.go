@@ -0,0 +1,114 @@
+package config
+
+import (
+	"go-transaction/entity"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig loads the production config file with test secrets; every test case breaks one setting of it.
+func validConfig(t *testing.T) *entity.Config {
+	t.Helper()
+	cfg, _, err := load([]string{
+		"-project", "prod",
+		"-config", "config.prod.yaml",
+		"-set", "secrets.jwtkey=test-jwt-key",
+		"-set", "secrets.quotekey=test-quote-key",
+	})
+	if err != nil {
+		t.Fatalf("unable to load test configuration: %v", err)
+	}
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(cfg *entity.Config)
+		wantErr string
+	}{
+		{"valid", func(cfg *entity.Config) {}, ""},
+		{"no project", func(cfg *entity.Config) { cfg.Project = "" }, "project must be set"},
+		{"port out of range", func(cfg *entity.Config) { cfg.Server.Port = 70000 }, "port must be between 1 and 65535"},
+		{"no jwt key", func(cfg *entity.Config) { cfg.Secrets.JWTKey = "" }, "secrets.jwtkey must be set"},
+		{"quote key reusing the jwt key", func(cfg *entity.Config) { cfg.Secrets.QuoteKey = cfg.Secrets.JWTKey }, "secrets.quotekey must differ"},
+		{"zero upi limit", func(cfg *entity.Config) { cfg.Payment.MaxUpiAmount = 0 }, "paymentconfig.upi must be greater than 0"},
+		{"negative schedule run timeout", func(cfg *entity.Config) { cfg.Scheduler.RunTimeout = -time.Minute }, "scheduler.runtimeout must not be negative"},
+		{"max expiry below expiry", func(cfg *entity.Config) {
+			cfg.PaymentRequest.Expiry = 72 * time.Hour
+			cfg.PaymentRequest.MaxExpiry = time.Hour
+		}, "paymentrequest.maxexpiry must not be shorter"},
+		{"rabbitmq url without scheme", func(cfg *entity.Config) {
+			cfg.RabbitMQ.Enabled = true
+			cfg.RabbitMQ.URL = "localhost:5672"
+		}, "rabbitmq.url must be an amqp:// or amqps:// URL"},
+		{"unknown tracing exporter", func(cfg *entity.Config) {
+			cfg.Tracing.Enabled = true
+			cfg.Tracing.Exporter = "jaeger"
+		}, "tracing.exporter must be one of"},
+		{"file exporter without a file", func(cfg *entity.Config) {
+			cfg.Tracing.Enabled = true
+			cfg.Tracing.Exporter = "file"
+			cfg.Tracing.File = ""
+		}, "tracing.file must be set"},
+		{"unknown rate limit store", func(cfg *entity.Config) { cfg.RateLimit.Store = "redis" }, "ratelimit.store must be memory"},
+		{"wildcard origin with credentials", func(cfg *entity.Config) {
+			cfg.CORS.AllowOrigins = []string{"*"}
+			cfg.CORS.AllowCredentials = true
+		}, "cors.alloworigins must not contain '*'"},
+		{"origin without scheme", func(cfg *entity.Config) { cfg.CORS.AllowOrigins = []string{"example.com"} }, "must start with http:// or https://"},
+		{"unknown frame option", func(cfg *entity.Config) { cfg.Security.FrameOptions = "ALLOW" }, "security.frameoptions must be DENY or SAMEORIGIN"},
+		{"upi pin too long", func(cfg *entity.Config) {
+			cfg.UPIPin.Enabled = true
+			cfg.UPIPin.Length = 8
+		}, "upipin.length must be between 4 and 6"},
+		{"fee percent above 100", func(cfg *entity.Config) {
+			cfg.Fees.RevenueAccount = "9999999999"
+			cfg.Fees.Rules = []entity.FeeRule{{Name: "upi", Percent: 150}}
+		}, "fee rule upi: percent must be between 0 and 100"},
+		{"fee tiers out of order", func(cfg *entity.Config) {
+			cfg.Fees.RevenueAccount = "9999999999"
+			cfg.Fees.Rules = []entity.FeeRule{{Name: "card", Tiers: []entity.FeeTier{{UpTo: 1000, Percent: 1}, {UpTo: 500, Percent: 2}}}}
+		}, "fee rule card: tiers must be in increasing order"},
+		{"fee rules without a revenue account", func(cfg *entity.Config) {
+			cfg.Fees.RevenueAccount = ""
+			cfg.Fees.Rules = []entity.FeeRule{{Name: "upi", Fixed: 1}}
+		}, "fees.revenueaccount must be set"},
+		{"zero quote ttl", func(cfg *entity.Config) { cfg.Quote.TTL = 0 }, "quote.ttl must be greater than 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			tt.change(cfg)
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate rejected a valid configuration: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate returned %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Project = ""
+	cfg.Quote.TTL = 0
+
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("Validate accepted an invalid configuration")
+	}
+	for _, want := range []string{"project must be set", "quote.ttl must be greater than 0"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate returned %q, missing %q", err, want)
+		}
+	}
+}
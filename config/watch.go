@@ -0,0 +1,123 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/entity"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// reloadDebounce groups the bursts of events editors and deploy tools produce for a single save.
+const reloadDebounce = 250 * time.Millisecond
+
+// restartKeys are the keys (or key prefixes) that are only read at startup. A reload still
+// updates them in the snapshot, but they take effect on the next restart.
+var restartKeys = []string{"port", "shutdowntimeout", "api", "swagger.", "firebase.", "rabbitmq.", "outbox.", "tracing.", "ratelimit.store", "cors."}
+
+// Reload rebuilds the configuration with the flags given to Load and, if it is valid, swaps it
+// in atomically. An invalid configuration is rejected and the active one is kept.
+// The changed keys are logged, with secrets redacted.
+func Reload() (*entity.Config, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	cfg, _, err := load(loadArgs)
+	if err != nil {
+		log.Error().Err(err).Msg("Configuration reload rejected, keeping the active configuration")
+		return nil, err
+	}
+
+	previous := current.Swap(cfg)
+	changes := diff(previous, cfg)
+	if len(changes) == 0 {
+		log.Info().Msg("Configuration reloaded, no changes")
+		return cfg, nil
+	}
+
+	for _, change := range changes {
+		event := log.Info()
+		if needsRestart(change.Key) {
+			event = log.Warn().Bool("restart_required", true)
+		}
+		event.Str("key", change.Key).Str("old", change.Old).Str("new", change.New).Msg("Configuration changed")
+	}
+	log.Info().Int("changes", len(changes)).Msg("Configuration reloaded")
+
+	return cfg, nil
+}
+
+// Watch reloads the configuration whenever the YAML file it was loaded from changes, until ctx is cancelled.
+// The file's directory is watched rather than the file itself, so saves that replace the file
+// (editors writing via rename, Kubernetes ConfigMap symlink swaps) are picked up too.
+func Watch(ctx context.Context) error {
+	if _, err := Get(); err != nil {
+		return err
+	}
+
+	loadMu.Lock()
+	path := loadedFile
+	loadMu.Unlock()
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve config file path: %v", err)
+	}
+	dir, name := filepath.Split(path)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config watcher: %v", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %v", dir, err)
+	}
+	log.Info().Str("path", path).Msg("Watching configuration file for changes")
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return fmt.Errorf("config watcher closed")
+			}
+			base := filepath.Base(event.Name)
+			if base != name && base != "..data" {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			debounce = time.After(reloadDebounce)
+
+		case <-debounce:
+			debounce = nil
+			Reload()
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return fmt.Errorf("config watcher closed")
+			}
+			log.Error().Err(err).Msg("Config watcher error")
+		}
+	}
+}
+
+// needsRestart reports whether a changed key is only read at startup.
+func needsRestart(key string) bool {
+	for _, k := range restartKeys {
+		if key == k || (strings.HasSuffix(k, ".") && strings.HasPrefix(key, k)) {
+			return true
+		}
+	}
+	return strings.HasSuffix(key, "interval")
+}
@@ -0,0 +1,66 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-transaction/entity"
+	"go-transaction/service"
+	"go-transaction/utils"
+)
+
+// execute decodes the payload of a command, validates it the same way the HTTP handlers do
+// and runs it through the service layer.
+func execute(ctx context.Context, command entity.Command) error {
+	switch command.Type {
+	case "transfer":
+		var requestBody entity.RequestBody
+		if err := json.Unmarshal(command.Payload, &requestBody); err != nil {
+			return fmt.Errorf("invalid transfer payload: %v", err)
+		}
+		if err := utils.ValidateRequestBody(&requestBody); err != nil {
+			return err
+		}
+		// Queued transfers need the sender's UPI PIN like any other, and fail if they need a TOTP code
+		result, err := service.InitiateQueuedTransaction(ctx, requestBody)
+		if err != nil {
+			return err
+		}
+		if result.Status != "success" && result.Status != "held" {
+			return fmt.Errorf("transfer %s ended in status %s", result.TransactionID, result.Status)
+		}
+		return nil
+
+	case "payment_request":
+		var requestBody entity.MakePaymentRequest
+		if err := json.Unmarshal(command.Payload, &requestBody); err != nil {
+			return fmt.Errorf("invalid payment request payload: %v", err)
+		}
+		if err := utils.ValidateMakePaymentRequest(&requestBody); err != nil {
+			return err
+		}
+		return service.MakeRequest(ctx, requestBody)
+
+	case "payment_request_action":
+		if command.UserID == "" {
+			return fmt.Errorf("user_id is required for a payment request action")
+		}
+		var requestBody entity.PaymentRequestAction
+		if err := json.Unmarshal(command.Payload, &requestBody); err != nil {
+			return fmt.Errorf("invalid payment request action payload: %v", err)
+		}
+		if err := utils.ValidatePaymentRequestAction(&requestBody); err != nil {
+			return err
+		}
+		// An acceptance that needs a step-up code is done here: the payer confirms the challenge over HTTP
+		var stepUp *service.StepUpRequiredError
+		if err := service.PaymentRequestAction(ctx, requestBody, command.UserID); err != nil && !errors.As(err, &stepUp) {
+			return err
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown command type: %s", command.Type)
+	}
+}
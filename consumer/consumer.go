@@ -0,0 +1,221 @@
+// Package consumer runs the RabbitMQ consumer that executes transaction commands
+// published by other services through the service layer.
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"go-transaction/metrics"
+	"go-transaction/service"
+	"go-transaction/tracing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// queueStatsInterval is how often the queue depths are sampled for the consumer lag metrics.
+const queueStatsInterval = 15 * time.Second
+
+// Run consumes commands until the context is cancelled, reconnecting to the broker
+// whenever the connection is lost.
+func Run(ctx context.Context) error {
+	rabbitMQConfig, err := config.GetRabbitMQYamlConfig()
+	if err != nil {
+		return err
+	}
+
+	delay := rabbitMQConfig.ReconnectDelay
+	if delay <= 0 {
+		delay = 5 * time.Second
+	}
+
+	for {
+		err := consume(ctx, rabbitMQConfig)
+		if ctx.Err() != nil {
+			return nil
+		}
+		log.Error().Err(err).Dur("retry_in", delay).Msg("RabbitMQ consumer stopped, reconnecting")
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+// consume opens a connection and channel, declares the topology and processes deliveries
+// until the context is cancelled or the broker closes the channel.
+func consume(ctx context.Context, rabbitMQConfig *entity.RabbitMQConfig) error {
+	conn, err := amqp.Dial(rabbitMQConfig.URL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to RabbitMQ: %v", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open a channel: %v", err)
+	}
+	defer ch.Close()
+
+	if err := declareTopology(ch, rabbitMQConfig); err != nil {
+		return err
+	}
+
+	prefetch := rabbitMQConfig.Prefetch
+	if prefetch <= 0 {
+		prefetch = 1
+	}
+	if err := ch.Qos(prefetch, 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch: %v", err)
+	}
+
+	msgs, err := ch.Consume(rabbitMQConfig.Queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to register consumer: %v", err)
+	}
+
+	log.Info().Str("queue", rabbitMQConfig.Queue).Int("prefetch", prefetch).Msg("Waiting for transaction commands")
+
+	statsTicker := time.NewTicker(queueStatsInterval)
+	defer statsTicker.Stop()
+	recordQueueStats(conn, rabbitMQConfig)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-statsTicker.C:
+			recordQueueStats(conn, rabbitMQConfig)
+		case msg, ok := <-msgs:
+			if !ok {
+				return errors.New("delivery channel closed")
+			}
+			// Let a command that has started finish even if shutdown begins meanwhile
+			handleDelivery(context.WithoutCancel(ctx), msg)
+		}
+	}
+}
+
+// recordQueueStats reports how many commands are waiting in the command and dead-letter queues.
+// It uses its own channel because a failed passive declare closes the channel it runs on.
+func recordQueueStats(conn *amqp.Connection, rabbitMQConfig *entity.RabbitMQConfig) {
+	ch, err := conn.Channel()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to open a channel for queue stats")
+		return
+	}
+	defer ch.Close()
+
+	for _, name := range []string{rabbitMQConfig.Queue, rabbitMQConfig.DeadLetterQueue} {
+		queue, err := ch.QueueDeclarePassive(name, true, false, false, false, nil)
+		if err != nil {
+			log.Warn().Err(err).Str("queue", name).Msg("Failed to read queue stats")
+			return
+		}
+		metrics.SetQueueStats(queue.Name, queue.Messages, queue.Consumers)
+	}
+}
+
+// declareTopology declares the durable command queue and its dead-letter exchange and queue.
+// Rejected messages are routed to the dead-letter queue instead of being dropped or redelivered forever.
+func declareTopology(ch *amqp.Channel, rabbitMQConfig *entity.RabbitMQConfig) error {
+	if err := ch.ExchangeDeclare(rabbitMQConfig.DeadLetterExchange, amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter exchange: %v", err)
+	}
+
+	if _, err := ch.QueueDeclare(rabbitMQConfig.DeadLetterQueue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter queue: %v", err)
+	}
+
+	if err := ch.QueueBind(rabbitMQConfig.DeadLetterQueue, rabbitMQConfig.Queue, rabbitMQConfig.DeadLetterExchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind dead-letter queue: %v", err)
+	}
+
+	_, err := ch.QueueDeclare(rabbitMQConfig.Queue, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange":    rabbitMQConfig.DeadLetterExchange,
+		"x-dead-letter-routing-key": rabbitMQConfig.Queue,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare queue: %v", err)
+	}
+
+	return nil
+}
+
+// handleDelivery executes a single command and settles the delivery.
+// Successful commands are acked; malformed commands and commands that fail are rejected
+// without requeueing so they land on the dead-letter queue. Commands move money, so they
+// are never retried automatically, and a command whose ID was already processed (a redelivery
+// after a crash before the ack) is acked without running it again.
+//
+// The command runs in a span continuing the publisher's trace when the message carries a traceparent header.
+func handleDelivery(ctx context.Context, msg amqp.Delivery) {
+	var err error
+	ctx, span := tracing.Start(tracing.ExtractAMQP(ctx, msg.Headers), "consumer.process",
+		attribute.String("messaging.system", "rabbitmq"),
+		attribute.String("messaging.message.id", msg.MessageId),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	var command entity.Command
+	if err = json.Unmarshal(msg.Body, &command); err != nil {
+		log.Error().Err(err).Str("message_id", msg.MessageId).Msg("Malformed command, dead-lettering")
+		reject(msg)
+		return
+	}
+	span.SetAttributes(attribute.String("command.type", command.Type), attribute.String("command.id", command.ID))
+
+	if command.ID == "" {
+		err = errors.New("command has no id")
+		log.Error().Err(err).Str("message_id", msg.MessageId).Msg("Malformed command, dead-lettering")
+		reject(msg)
+		return
+	}
+
+	logger := log.With().Str("command_id", command.ID).Str("type", command.Type).Logger()
+
+	claimed, err := service.ClaimCommand(ctx, command.ID, command.Type)
+	if err != nil {
+		// Nothing ran yet, so the command can safely be delivered again
+		logger.Error().Err(err).Msg("Failed to record command, requeueing")
+		if err := msg.Nack(false, true); err != nil {
+			logger.Error().Err(err).Msg("Failed to requeue command")
+		}
+		return
+	}
+	if !claimed {
+		logger.Warn().Msg("Command already processed, acking duplicate")
+		if err := msg.Ack(false); err != nil {
+			logger.Error().Err(err).Msg("Failed to ack command")
+		}
+		return
+	}
+
+	err = execute(ctx, command)
+	service.FinishCommand(ctx, command.ID, err)
+	if err != nil {
+		logger.Error().Err(err).Msg("Command failed, dead-lettering")
+		reject(msg)
+		return
+	}
+
+	if err := msg.Ack(false); err != nil {
+		logger.Error().Err(err).Msg("Failed to ack command")
+		return
+	}
+	logger.Info().Msg("Command processed")
+}
+
+func reject(msg amqp.Delivery) {
+	if err := msg.Nack(false, false); err != nil {
+		log.Error().Err(err).Msg("Failed to reject command")
+	}
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"go-transaction/entity"
+	"go-transaction/service"
+	"go-transaction/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// VerifyAccount returns the masked holder name and status of the account behind a UPI ID or bank account,
+// so the user can confirm the payee before sending money.
+//   - If no account matches the details, it returns `404 Not Found`.
+func VerifyAccount(c *gin.Context) {
+	var responseBody entity.CommonResponse
+	var requestBody entity.AccountVerifyRequest
+
+	err := utils.ReadAccountVerifyRequest(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	verification, err := service.VerifyAccount(ctx, requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error verifying account")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusNotFound, responseBody)
+		return
+	}
+
+	respondData(c, verification)
+}
+
+// SetAccountStatus freezes, closes, reactivates or marks dormant an account on behalf of an admin.
+func SetAccountStatus(c *gin.Context) {
+	var responseBody entity.CommonResponse
+	var requestBody entity.AccountStatusChange
+
+	err := utils.ReadAccountStatusChange(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	state, err := service.SetAccountStatus(ctx, c.Param("accNo"), requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error changing account status")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	respondData(c, state)
+}
+
+// SetCreditLimit sets the credit limit of an account on behalf of an admin, enabling credit card payments
+// that draw on it.
+func SetCreditLimit(c *gin.Context) {
+	var responseBody entity.CommonResponse
+	var requestBody entity.CreditLimitChange
+
+	err := utils.ReadCreditLimitChange(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	account, err := service.SetCreditLimit(ctx, c.Param("accNo"), requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error setting credit limit")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	respondData(c, account)
+}
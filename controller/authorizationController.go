@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"errors"
+	"go-transaction/entity"
+	"go-transaction/service"
+	"go-transaction/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Authorize reserves funds on the sender's account for a later capture or void.
+// The request body has the same shape as a transaction initiation.
+func Authorize(c *gin.Context) {
+	var requestBody entity.RequestBody
+	var responseBody entity.CommonResponse
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadRequestBody(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	// Funds can only be held on the authenticated user's own accounts
+	if !strings.EqualFold(requestBody.SenderID, uid) {
+		log.Error().
+			Str("sender_id", requestBody.SenderID).
+			Msg("Sender does not match the authenticated user")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusForbidden, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	authorization, err := service.Authorize(ctx, requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error authorizing payment")
+
+		// Holds that need a TOTP code are accepted but not yet placed
+		var stepUp *service.StepUpRequiredError
+		if errors.As(err, &stepUp) {
+			responseBody.ApplyResponseBody(entity.SUCCESS)
+			c.JSON(http.StatusAccepted, gin.H{
+				"data": entity.TransactionResult{
+					Status:      "challenge_required",
+					ChallengeID: stepUp.Challenge.ID,
+				},
+				"metadata": gin.H{
+					"status": responseBody,
+				},
+			})
+			return
+		}
+		if respondPaymentError(c, err) {
+			return
+		}
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, authorization)
+}
+
+// GetAuthorization returns an authorization to one of its parties or an admin.
+func GetAuthorization(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, role, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	authorization, err := service.GetAuthorization(ctx, c.Param("id"), role, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error fetching authorization")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, authorization)
+}
+
+// CaptureAuthorization captures all or part of an authorization.
+func CaptureAuthorization(c *gin.Context) {
+	var responseBody entity.CommonResponse
+	var requestBody entity.CaptureRequest
+
+	uid, role, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadCaptureRequest(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	authorization, err := service.CaptureAuthorization(ctx, c.Param("id"), requestBody.Amount, role, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error capturing authorization")
+		if respondPaymentError(c, err) {
+			return
+		}
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, authorization)
+}
+
+// VoidAuthorization releases the remaining reserved funds of an authorization.
+func VoidAuthorization(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, role, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	authorization, err := service.VoidAuthorization(ctx, c.Param("id"), role, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error voiding authorization")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, authorization)
+}
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"go-transaction/entity"
+	"go-transaction/service"
+	"go-transaction/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// AddBeneficiary saves a payee for the authenticated user and returns it with the account holder's name.
+func AddBeneficiary(c *gin.Context) {
+	var responseBody entity.CommonResponse
+	var requestBody entity.BeneficiaryRequest
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadBeneficiaryRequest(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	beneficiary, err := service.AddBeneficiary(ctx, requestBody, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error adding beneficiary")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	respondData(c, beneficiary)
+}
+
+// ListBeneficiaries returns the authenticated user's beneficiaries.
+func ListBeneficiaries(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	beneficiaries, err := service.ListBeneficiaries(ctx, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error fetching beneficiaries")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, beneficiaries)
+}
+
+// RenameBeneficiary changes the nickname of one of the authenticated user's beneficiaries.
+func RenameBeneficiary(c *gin.Context) {
+	var responseBody entity.CommonResponse
+	var requestBody entity.BeneficiaryRename
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadBeneficiaryRename(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	beneficiary, err := service.RenameBeneficiary(ctx, c.Param("id"), requestBody.Nickname, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error renaming beneficiary")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, beneficiary)
+}
+
+// DeleteBeneficiary removes one of the authenticated user's beneficiaries.
+func DeleteBeneficiary(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if err := service.DeleteBeneficiary(ctx, c.Param("id"), uid); err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error deleting beneficiary")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	responseBody.ApplyResponseBody(entity.SUCCESS)
+	c.JSON(http.StatusOK, responseBody)
+}
@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"go-transaction/entity"
+	"go-transaction/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// GetEffectiveConfig returns the active configuration, after any hot reloads, with secrets redacted.
+func GetEffectiveConfig(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	effective, err := service.GetEffectiveConfig()
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error reading effective configuration")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, effective)
+}
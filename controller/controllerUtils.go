@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"errors"
+	"go-transaction/entity"
+	"go-transaction/service"
+	"go-transaction/utils"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readClaims extracts the user ID and role from the bearer token of an authenticated request.
+// Routes using it must be guarded by middleware.AuthCheck.
+func readClaims(c *gin.Context) (string, string, error) {
+	authHeader := c.GetHeader("Authorization")
+	token := authHeader[len("Bearer "):]
+	payload, err := utils.GetPayloadFromJWT(token)
+	if err != nil {
+		return "", "", err
+	}
+
+	uid, ok := payload["uid"].(string)
+	if !ok {
+		return "", "", errors.New("uid claim is missing from token")
+	}
+
+	role, ok := payload["role"].(string)
+	if !ok {
+		return "", "", errors.New("role claim is missing from token")
+	}
+
+	return uid, role, nil
+}
+
+// respondData writes a successful response wrapping the given data
+// in the standard "data" / "metadata" envelope.
+func respondData(c *gin.Context, data interface{}) {
+	var responseBody entity.CommonResponse
+
+	responseBody.ApplyResponseBody(entity.SUCCESS)
+	c.JSON(http.StatusOK, gin.H{
+		"data": data,
+		"metadata": gin.H{
+			"status": responseBody,
+		},
+	})
+}
+
+// respondPaymentError writes the response for the payment errors that have their own status code,
+// and reports whether err was one of them:
+//   - A UPI PIN lockout gets `429 Too Many Requests` with a `Retry-After` header.
+//   - A transfer blocked by an account's status, or sent from an account the user does not hold, gets `403 Forbidden`.
+//   - A transfer that cannot be executed against its quote gets `409 Conflict`.
+func respondPaymentError(c *gin.Context, err error) bool {
+	var responseBody entity.CommonResponse
+	responseBody.ApplyResponseBody(entity.FAILURE)
+
+	var locked *service.UPIPinLockedError
+	if errors.As(err, &locked) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, responseBody)
+		return true
+	}
+
+	var blocked *service.AccountStatusError
+	if errors.As(err, &blocked) {
+		c.JSON(http.StatusForbidden, responseBody)
+		return true
+	}
+
+	var foreign *service.AccountOwnershipError
+	if errors.As(err, &foreign) {
+		c.JSON(http.StatusForbidden, responseBody)
+		return true
+	}
+
+	var quoteErr *service.QuoteError
+	if errors.As(err, &quoteErr) {
+		c.JSON(http.StatusConflict, responseBody)
+		return true
+	}
+	return false
+}
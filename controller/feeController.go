@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"go-transaction/entity"
+	"go-transaction/service"
+	"go-transaction/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// QuoteFee returns the fee the authenticated user would be charged for a transfer, and the total it would debit.
+func QuoteFee(c *gin.Context) {
+	var requestBody entity.FeeQuoteRequest
+	var responseBody entity.CommonResponse
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadFeeQuoteRequest(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	quote, err := service.QuoteFee(ctx, uid, requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error quoting transfer fee")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, quote)
+}
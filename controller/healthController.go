@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"go-transaction/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Healthz reports that the process is alive and serving HTTP.
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz reports whether the service can handle traffic: Firestore (and RabbitMQ, when used)
+// must be reachable and the server must not be shutting down.
+func Readyz(c *gin.Context) {
+	checks, ready := service.CheckReadiness(c.Request.Context())
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": checks})
+}
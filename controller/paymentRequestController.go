@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"go-transaction/entity"
+	"go-transaction/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// ListIncomingRequests returns the payment requests the authenticated user has been asked to pay.
+func ListIncomingRequests(c *gin.Context) {
+	listPaymentRequests(c, "incoming")
+}
+
+// ListOutgoingRequests returns the payment requests the authenticated user has created.
+func ListOutgoingRequests(c *gin.Context) {
+	listPaymentRequests(c, "outgoing")
+}
+
+func listPaymentRequests(c *gin.Context, direction string) {
+	var responseBody entity.CommonResponse
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	pageSize, _ := strconv.Atoi(c.Query("pageSize"))
+	pageNumber, _ := strconv.Atoi(c.Query("pageNumber"))
+
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	if pageNumber <= 0 {
+		pageNumber = 1
+	}
+
+	ctx := c.Request.Context()
+
+	requests, err := service.ListPaymentRequests(ctx, uid, direction, c.Query("status"), pageSize, pageNumber)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("direction", direction).
+			Msg("Error fetching payment requests")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	responseBody.ApplyResponseBody(entity.SUCCESS)
+	c.JSON(http.StatusOK, gin.H{
+		"data": requests,
+		"metadata": gin.H{
+			"status":     responseBody,
+			"pageSize":   pageSize,
+			"pageNumber": pageNumber,
+			"totalCount": len(requests),
+		},
+	})
+}
+
+// GetPaymentRequest returns a single payment request to its requester, its payer or an admin.
+func GetPaymentRequest(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, role, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	request, err := service.GetPaymentRequest(ctx, c.Param("id"), role, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error fetching payment request")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, request)
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"go-transaction/entity"
+	"go-transaction/service"
+	"go-transaction/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// ListReviewQueue returns the held transactions awaiting a decision.
+// The queue can be filtered with the `status` query parameter and paginated with `pageSize` and `pageNumber`.
+func ListReviewQueue(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	pageSize, _ := strconv.Atoi(c.Query("pageSize"))
+	pageNumber, _ := strconv.Atoi(c.Query("pageNumber"))
+
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	if pageNumber <= 0 {
+		pageNumber = 1
+	}
+
+	ctx := c.Request.Context()
+
+	items, err := service.ListReviewQueue(ctx, c.Query("status"), pageSize, pageNumber)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error fetching review queue")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	responseBody.ApplyResponseBody(entity.SUCCESS)
+	c.JSON(http.StatusOK, gin.H{
+		"data": items,
+		"metadata": gin.H{
+			"status":     responseBody,
+			"pageSize":   pageSize,
+			"pageNumber": pageNumber,
+			"totalCount": len(items),
+		},
+	})
+}
+
+// GetReviewItem returns a single review item along with the held transaction.
+func GetReviewItem(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	ctx := c.Request.Context()
+
+	details, err := service.GetReviewItem(ctx, c.Param("id"))
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error fetching review item")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	responseBody.ApplyResponseBody(entity.SUCCESS)
+	c.JSON(http.StatusOK, gin.H{
+		"data": details,
+		"metadata": gin.H{
+			"status": responseBody,
+		},
+	})
+}
+
+// ApproveReview executes a held transaction. A note is mandatory.
+func ApproveReview(c *gin.Context) {
+	decideReview(c, service.ApproveReview)
+}
+
+// RejectReview releases the reservation of a held transaction. A note is mandatory.
+func RejectReview(c *gin.Context) {
+	decideReview(c, service.RejectReview)
+}
+
+func decideReview(c *gin.Context, decide func(ctx context.Context, reviewID, adminID, note string) error) {
+	var responseBody entity.CommonResponse
+	var requestBody entity.ReviewDecision
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadReviewDecision(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	err = decide(ctx, c.Param("id"), uid, requestBody.Note)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing review decision")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	responseBody.ApplyResponseBody(entity.SUCCESS)
+	c.JSON(http.StatusOK, responseBody)
+}
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"go-transaction/entity"
+	"go-transaction/service"
+	"go-transaction/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// CreateSchedule registers a one-off future or recurring transfer for the authenticated user.
+func CreateSchedule(c *gin.Context) {
+	var responseBody entity.CommonResponse
+	var requestBody entity.ScheduleRequest
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadScheduleRequest(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	schedule, err := service.CreateSchedule(ctx, requestBody, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error creating schedule")
+		if respondPaymentError(c, err) {
+			return
+		}
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, schedule)
+}
+
+// ListSchedules returns the authenticated user's schedules.
+func ListSchedules(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	schedules, err := service.ListSchedules(ctx, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error fetching schedules")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, schedules)
+}
+
+// GetSchedule returns a single schedule to its owner or an admin.
+func GetSchedule(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, role, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	schedule, err := service.GetSchedule(ctx, c.Param("id"), role, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error fetching schedule")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, schedule)
+}
+
+// ListScheduleRuns returns the execution history of a schedule.
+func ListScheduleRuns(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, role, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	runs, err := service.ListScheduleRuns(ctx, c.Param("id"), role, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error fetching schedule runs")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, runs)
+}
+
+// PauseSchedule stops a schedule from running until it is resumed.
+func PauseSchedule(c *gin.Context) {
+	updateScheduleStatus(c, "pause")
+}
+
+// ResumeSchedule re-activates a paused schedule.
+func ResumeSchedule(c *gin.Context) {
+	updateScheduleStatus(c, "resume")
+}
+
+// CancelSchedule permanently stops a schedule.
+func CancelSchedule(c *gin.Context) {
+	updateScheduleStatus(c, "cancel")
+}
+
+func updateScheduleStatus(c *gin.Context, action string) {
+	var responseBody entity.CommonResponse
+
+	uid, role, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	schedule, err := service.UpdateScheduleStatus(ctx, c.Param("id"), action, role, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("action", action).
+			Msg("Error updating schedule")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, schedule)
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"go-transaction/entity"
+	"go-transaction/service"
+	"go-transaction/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// CreateSplitRequest splits a bill between several payers, sending each of them a payment request.
+func CreateSplitRequest(c *gin.Context) {
+	var responseBody entity.CommonResponse
+	var requestBody entity.SplitRequest
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadSplitRequest(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	group, err := service.CreateSplitRequest(ctx, requestBody, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error creating split request")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, group)
+}
+
+// GetSplitRequest returns a split request with the status of each payer's request and the group totals.
+func GetSplitRequest(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, role, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	details, err := service.GetSplitRequest(ctx, c.Param("id"), role, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error fetching split request")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, details)
+}
+
+// CancelSplitRequest cancels every outstanding payment request of a split request.
+func CancelSplitRequest(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, role, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	err = service.CancelSplitRequest(ctx, c.Param("id"), role, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error cancelling split request")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	responseBody.ApplyResponseBody(entity.SUCCESS)
+	c.JSON(http.StatusOK, responseBody)
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"go-transaction/entity"
+	"go-transaction/service"
+	"go-transaction/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// EnrollTOTP starts two-factor enrollment for the authenticated user and returns the TOTP secret
+// and otpauth:// URL for their authenticator app.
+func EnrollTOTP(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	setup, err := service.EnrollTOTP(ctx, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error enrolling two-factor authentication")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	respondData(c, setup)
+}
+
+// ConfirmTOTPEnrollment completes two-factor enrollment with a code from the authenticator app.
+func ConfirmTOTPEnrollment(c *gin.Context) {
+	var requestBody entity.TOTPCode
+	var responseBody entity.CommonResponse
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadTOTPCode(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if err := service.ConfirmTOTPEnrollment(ctx, uid, requestBody.Code); err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error confirming two-factor enrollment")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	responseBody.ApplyResponseBody(entity.SUCCESS)
+	c.JSON(http.StatusOK, responseBody)
+}
+
+// ConfirmChallenge confirms a step-up challenge with a TOTP code and completes the transfer or
+// payment request acceptance that was waiting for it.
+func ConfirmChallenge(c *gin.Context) {
+	var requestBody entity.TOTPCode
+	var responseBody entity.CommonResponse
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadTOTPCode(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	result, err := service.ConfirmChallenge(ctx, c.Param("id"), requestBody.Code, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error confirming step-up challenge")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	respondData(c, result)
+}
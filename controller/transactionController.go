@@ -1,8 +1,8 @@
 package controller
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"go-transaction/entity"
 	"go-transaction/service"
 	"go-transaction/utils"
@@ -18,7 +18,17 @@ func InitiateTransaction(c *gin.Context) {
 	var requestBody entity.RequestBody
 	var responseBody entity.CommonResponse
 
-	err := utils.ReadRequestBody(c.Request, &requestBody)
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadRequestBody(c.Request, &requestBody)
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -28,13 +38,26 @@ func InitiateTransaction(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	// Transfers can only be sent from the authenticated user's own accounts
+	if !strings.EqualFold(requestBody.SenderID, uid) {
+		log.Error().
+			Str("sender_id", requestBody.SenderID).
+			Msg("Sender does not match the authenticated user")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusForbidden, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
 
-	err = service.InitiateTransaction(ctx, requestBody)
+	result, err := service.InitiateTransaction(ctx, requestBody)
 	if err != nil {
 		log.Error().
 			Err(err).
 			Msg("Error processing transaction")
+		if respondPaymentError(c, err) {
+			return
+		}
 		responseBody.ApplyResponseBody(entity.FAILURE)
 
 		c.JSON(http.StatusInternalServerError, responseBody)
@@ -42,9 +65,75 @@ func InitiateTransaction(c *gin.Context) {
 	}
 
 	responseBody.ApplyResponseBody(entity.SUCCESS)
+
+	// Held transactions and those waiting for a step-up code are accepted but not yet executed
+	if result.Status == "held" || result.Status == "challenge_required" {
+		c.JSON(http.StatusAccepted, gin.H{
+			"data": result,
+			"metadata": gin.H{
+				"status": responseBody,
+			},
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, responseBody)
 }
 
+// QuoteTransfer checks a transfer without executing it and returns a quote with its fee and a signed,
+// short-lived quote ID that /initiate can execute it with.
+func QuoteTransfer(c *gin.Context) {
+	var requestBody entity.RequestBody
+	var responseBody entity.CommonResponse
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadRequestBody(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	// Transfers can only be sent from the authenticated user's own accounts
+	if !strings.EqualFold(requestBody.SenderID, uid) {
+		log.Error().
+			Str("sender_id", requestBody.SenderID).
+			Msg("Sender does not match the authenticated user")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusForbidden, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	quote, err := service.QuoteTransfer(ctx, requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error quoting transfer")
+		if respondPaymentError(c, err) {
+			return
+		}
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	respondData(c, quote)
+}
+
 func MakeRequest(c *gin.Context) {
 	var requestBody entity.MakePaymentRequest
 	var responseBody entity.CommonResponse
@@ -59,7 +148,7 @@ func MakeRequest(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	err = service.MakeRequest(ctx, requestBody)
 	if err != nil {
@@ -110,13 +199,33 @@ func PaymentRequestAction(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	err = service.PaymentRequestAction(ctx, requestBody, uid)
+
+	// The acceptance waits for the payer to confirm the step-up challenge
+	var stepUp *service.StepUpRequiredError
+	if errors.As(err, &stepUp) {
+		responseBody.ApplyResponseBody(entity.SUCCESS)
+		c.JSON(http.StatusAccepted, gin.H{
+			"data": entity.TransactionResult{
+				TransactionID: stepUp.Challenge.TransactionID,
+				Status:        "challenge_required",
+				ChallengeID:   stepUp.Challenge.ID,
+			},
+			"metadata": gin.H{
+				"status": responseBody,
+			},
+		})
+		return
+	}
 	if err != nil {
 		log.Error().
 			Err(err).
 			Msg("Error processing transaction")
+		if respondPaymentError(c, err) {
+			return
+		}
 		responseBody.ApplyResponseBody(entity.FAILURE)
 
 		c.JSON(http.StatusInternalServerError, responseBody)
@@ -140,7 +249,7 @@ func GetTransactionByID(c *gin.Context) {
 
 	id := c.Param("id")
 	var responseBody entity.CommonResponse
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	pageSize, _ := strconv.Atoi(c.Query("pageSize"))
 	pageNumber, _ := strconv.Atoi(c.Query("pageNumber"))
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"errors"
+	"go-transaction/entity"
+	"go-transaction/service"
+	"go-transaction/utils"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// SetUPIPin sets the transaction PIN of one of the authenticated user's UPI IDs.
+func SetUPIPin(c *gin.Context) {
+	var requestBody entity.UPIPinSetup
+	var responseBody entity.CommonResponse
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadUPIPinSetup(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if err := service.SetUPIPin(ctx, uid, requestBody); err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error setting UPI PIN")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	responseBody.ApplyResponseBody(entity.SUCCESS)
+	c.JSON(http.StatusOK, responseBody)
+}
+
+// ResetUPIPin replaces the PIN of one of the authenticated user's UPI IDs after checking their password.
+//   - If the account is locked out after repeated wrong passwords, it returns `429 Too Many Requests` with a `Retry-After` header.
+func ResetUPIPin(c *gin.Context) {
+	var requestBody entity.UPIPinReset
+	var responseBody entity.CommonResponse
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadUPIPinReset(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if err := service.ResetUPIPin(ctx, uid, requestBody); err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error resetting UPI PIN")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+
+		var locked *service.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, responseBody)
+			return
+		}
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	responseBody.ApplyResponseBody(entity.SUCCESS)
+	c.JSON(http.StatusOK, responseBody)
+}
@@ -1,11 +1,13 @@
 package controller
 
 import (
-	"context"
+	"errors"
 	"go-transaction/entity"
 	"go-transaction/service"
 	"go-transaction/utils"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/rs/zerolog/log"
 
@@ -16,6 +18,7 @@ import (
 // It validates the login credentials from the request body, authenticates the user,
 // and generates an authentication token.
 //   - If the credentials are invalid, it returns a `400 Bad Request` error response with the error details.
+//   - If the account is locked out after repeated failed logins, it returns `429 Too Many Requests` with a `Retry-After` header.
 //   - If the authentication fails, an error is logged, and a failure response is sent to the client.
 //   - If the login is successful, a JWT token is generated and returned along with a success message.
 func Login(c *gin.Context) {
@@ -29,7 +32,7 @@ func Login(c *gin.Context) {
 	}
 
 	// Create a context for the login process
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	// Attempt to authenticate the user with the provided credentials
 	user, err := service.LoginUser(ctx, credentials)
@@ -40,6 +43,13 @@ func Login(c *gin.Context) {
 			Err(err).
 			Msg("Error")
 		responseBody.ApplyResponseBody(entity.FAILURE)
+
+		var locked *service.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, responseBody)
+			return
+		}
 		c.JSON(http.StatusBadRequest, responseBody)
 		return
 	}
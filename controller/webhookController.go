@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"go-transaction/entity"
+	"go-transaction/service"
+	"go-transaction/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// CreateWebhookSubscription registers a webhook endpoint for the authenticated user.
+// The signing secret is only included in this response.
+func CreateWebhookSubscription(c *gin.Context) {
+	var responseBody entity.CommonResponse
+	var requestBody entity.WebhookSubscriptionRequest
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	err = utils.ReadWebhookSubscriptionRequest(c.Request, &requestBody)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error parsing request body")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusBadRequest, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	subscription, err := service.CreateWebhookSubscription(ctx, requestBody, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error creating webhook subscription")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, subscription)
+}
+
+// ListWebhookSubscriptions returns the authenticated user's active webhook subscriptions.
+func ListWebhookSubscriptions(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, _, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	subscriptions, err := service.ListWebhookSubscriptions(ctx, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error fetching webhook subscriptions")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, subscriptions)
+}
+
+// DeleteWebhookSubscription stops deliveries to a webhook subscription.
+func DeleteWebhookSubscription(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, role, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	err = service.DeleteWebhookSubscription(ctx, c.Param("id"), role, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error deleting webhook subscription")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	responseBody.ApplyResponseBody(entity.SUCCESS)
+	c.JSON(http.StatusOK, responseBody)
+}
+
+// ListWebhookDeliveries returns the delivery log of a webhook subscription.
+func ListWebhookDeliveries(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, role, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	pageSize, _ := strconv.Atoi(c.Query("pageSize"))
+	pageNumber, _ := strconv.Atoi(c.Query("pageNumber"))
+
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	if pageNumber <= 0 {
+		pageNumber = 1
+	}
+
+	ctx := c.Request.Context()
+
+	deliveries, err := service.ListWebhookDeliveries(ctx, c.Param("id"), role, uid, pageSize, pageNumber)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error fetching webhook deliveries")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	responseBody.ApplyResponseBody(entity.SUCCESS)
+	c.JSON(http.StatusOK, gin.H{
+		"data": deliveries,
+		"metadata": gin.H{
+			"status":     responseBody,
+			"pageSize":   pageSize,
+			"pageNumber": pageNumber,
+			"totalCount": len(deliveries),
+		},
+	})
+}
+
+// RedeliverWebhook queues a webhook delivery to be sent again.
+func RedeliverWebhook(c *gin.Context) {
+	var responseBody entity.CommonResponse
+
+	uid, role, err := readClaims(c)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error processing payload")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	delivery, err := service.RedeliverWebhook(ctx, c.Param("id"), role, uid)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error queueing webhook redelivery")
+		responseBody.ApplyResponseBody(entity.FAILURE)
+		c.JSON(http.StatusInternalServerError, responseBody)
+		return
+	}
+
+	respondData(c, delivery)
+}
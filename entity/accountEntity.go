@@ -0,0 +1,77 @@
+package entity
+
+// AccountVerifyRequest represents the request body for looking up who a UPI ID or bank account belongs to.
+// Method is 'UPI' or 'BANK' and selects which of the payment details is used.
+type AccountVerifyRequest struct {
+	Method  string         `json:"method"`
+	Details PaymentDetails `json:"details"`
+}
+
+// AccountVerification is the result of an account lookup, shown to a user before they pay the account.
+//
+// Fields:
+//   - Method: The method the account was looked up by ('UPI' or 'BANK').
+//   - HolderName: The account holder's name, masked.
+//   - Status: The account status (e.g., 'active').
+type AccountVerification struct {
+	Method     string `json:"method"`
+	HolderName string `json:"holder_name"`
+	Status     string `json:"status"`
+}
+
+// Account statuses, stored in the status field of a BankDetails document.
+// Accounts without a status are active.
+const (
+	AccountActive      = "active"       // Debits and credits are allowed.
+	AccountDebitFrozen = "debit_frozen" // Credits are allowed, debits are blocked.
+	AccountFrozen      = "frozen"       // Debits and credits are blocked.
+	AccountClosed      = "closed"       // Debits and credits are blocked for good.
+	AccountDormant     = "dormant"      // No activity for the configured period; debits are blocked until it is reactivated.
+)
+
+// AccountStatusChange represents the request body an admin sends to change an account's status.
+type AccountStatusChange struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// AccountState is the status of an account as shown to admins.
+//
+// Fields:
+//   - Account: The account number, masked.
+//   - Status: The account status (see AccountActive and the other statuses).
+//   - StatusReason: Why the status was last changed.
+//   - StatusChangedAt: Unix time at which the status was last changed.
+//   - LastActivityAt: Unix time of the last debit or credit (0 if none was recorded).
+type AccountState struct {
+	Account         string `json:"account"`
+	Status          string `json:"status"`
+	StatusReason    string `json:"status_reason,omitempty"`
+	StatusChangedAt int64  `json:"status_changed_at,omitempty"`
+	LastActivityAt  int64  `json:"last_activity_at,omitempty"`
+}
+
+// CreditAccount is the credit line of an account, as shown to admins and passed to accrual hooks.
+// The balance of a credit account may go below zero, down to minus its credit limit, for credit card payments.
+//
+// Fields:
+//   - Account: The account number; masked when shown to admins.
+//   - Balance: The account's ledger balance; negative while the credit line is in use.
+//   - Reserved: Funds reserved for held transactions and authorizations.
+//   - CreditLimit: How far below zero the balance may go.
+//   - Utilized: The part of the credit line in use (the negative part of the balance).
+//   - LastAccrualDay: The last day (YYYY-MM-DD, UTC) interest and fees were charged.
+type CreditAccount struct {
+	Account        string  `json:"account"`
+	Balance        float64 `json:"balance"`
+	Reserved       float64 `json:"reserved"`
+	CreditLimit    float64 `json:"credit_limit"`
+	Utilized       float64 `json:"utilized"`
+	LastAccrualDay string  `json:"last_accrual_day,omitempty"`
+}
+
+// CreditLimitChange represents the request body an admin sends to set an account's credit limit.
+type CreditLimitChange struct {
+	CreditLimit float64 `json:"credit_limit"`
+	Reason      string  `json:"reason"`
+}
@@ -0,0 +1,34 @@
+package entity
+
+// AuditEntry is one record of the audit log: a change to an account's balance, reservation or status,
+// or an action an admin took on someone else's behalf.
+// Entries are only ever created, never updated or deleted, and identifiers such as account
+// numbers are stored masked.
+//
+// Fields:
+//   - ID: Identifier of the entry.
+//   - Action: What happened (e.g., 'balance.debit', 'balance.credit', 'funds.reserved', 'funds.released', 'admin.review.approved').
+//   - Actor: The user who caused the change, or 'system' for background jobs.
+//   - RequestID: Correlation ID of the HTTP request, if any.
+//   - TransactionID: The transaction the change belongs to, if any.
+//   - Reference: Another related document (review item, authorization, schedule, ...), if any.
+//   - Account: The masked account number whose funds or status changed (balance and status entries only).
+//   - Amount: The amount moved, reserved or released (balance entries only).
+//   - Balance: The account's ledger balance after the change (balance entries only).
+//   - Reserved: The account's reserved funds after the change (balance entries only).
+//   - Note: Free-text context, such as a reviewer's note.
+//   - Timestamp: Unix time of the change.
+type AuditEntry struct {
+	ID            string  `json:"id"`
+	Action        string  `json:"action"`
+	Actor         string  `json:"actor"`
+	RequestID     string  `json:"request_id,omitempty"`
+	TransactionID string  `json:"transaction_id,omitempty"`
+	Reference     string  `json:"reference,omitempty"`
+	Account       string  `json:"account,omitempty"`
+	Amount        float64 `json:"amount,omitempty"`
+	Balance       float64 `json:"balance,omitempty"`
+	Reserved      float64 `json:"reserved,omitempty"`
+	Note          string  `json:"note,omitempty"`
+	Timestamp     int64   `json:"timestamp"`
+}
@@ -0,0 +1,41 @@
+package entity
+
+// Authorization represents a two-phase payment hold on a sender's account.
+// The authorized amount is reserved against the sender's balance, reducing the available
+// balance but not the ledger balance, until it is captured, voided or expires.
+//
+// Fields:
+//   - ID: Unique identifier for the authorization.
+//   - SenderID: Identifier of the paying user.
+//   - ReceiverID: Identifier of the merchant receiving the funds (optional).
+//   - SenderAccNo: Account number the funds are reserved against.
+//   - ReceiverAccNo: Account number credited on capture.
+//   - Amount: The authorized amount.
+//   - CapturedAmount: The total amount captured so far.
+//   - PaymentMethod: The sender's payment method (e.g., 'UPI').
+//   - RecievingMethod: The receiver's payment method (e.g., 'UPI', 'BANK').
+//   - Status: The authorization status ('authorized', 'partially_captured', 'captured', 'voided', 'expired').
+//   - CreatedAt: Unix time at which the funds were reserved.
+//   - ExpiresAt: Unix time after which the remaining reservation is released automatically.
+//   - TransactionIDs: Identifiers of the transactions created by each capture.
+type Authorization struct {
+	ID              string   `json:"id"`
+	SenderID        string   `json:"sender_id"`
+	ReceiverID      string   `json:"receiver_id,omitempty"`
+	SenderAccNo     string   `json:"sender_acc_no"`
+	ReceiverAccNo   string   `json:"receiver_acc_no"`
+	Amount          float64  `json:"amount"`
+	CapturedAmount  float64  `json:"captured_amount"`
+	PaymentMethod   string   `json:"payment_method"`
+	RecievingMethod string   `json:"recieving_method"`
+	Status          string   `json:"status"`
+	CreatedAt       int64    `json:"created_at"`
+	ExpiresAt       int64    `json:"expires_at"`
+	TransactionIDs  []string `json:"transaction_ids,omitempty"`
+}
+
+// CaptureRequest represents the body of a capture call on an authorization.
+// An amount of 0 captures the full remaining authorized amount.
+type CaptureRequest struct {
+	Amount float64 `json:"amount" validate:"gte=0"`
+}
@@ -0,0 +1,41 @@
+package entity
+
+// Beneficiary represents a payee a user saved to send transfers to without entering their details again.
+// Its account is resolved when it is saved; transfers reference it by ID.
+//
+// Fields:
+//   - ID: Unique identifier for the beneficiary.
+//   - OwnerID: Identifier of the user who saved the beneficiary.
+//   - Nickname: Name the owner gave the beneficiary.
+//   - ReceiverID: Identifier of the payee as a user of the service (optional).
+//   - Method: How the payee receives transfers ('UPI' or 'BANK').
+//   - Details: The payee's UPI ID or bank account details.
+//   - AccountNumber: The account the details resolved to when the beneficiary was saved.
+//   - HolderName: Name of the account holder, masked, shown to the owner to confirm the payee.
+//   - CoolingOffUntil: Unix time until which transfers to the beneficiary are limited (0 for none).
+//   - CreatedAt: Unix time at which the beneficiary was saved.
+type Beneficiary struct {
+	ID              string         `json:"id"`
+	OwnerID         string         `json:"owner_id"`
+	Nickname        string         `json:"nickname"`
+	ReceiverID      string         `json:"receiver_id,omitempty"`
+	Method          string         `json:"method"`
+	Details         PaymentDetails `json:"details"`
+	AccountNumber   string         `json:"-"`
+	HolderName      string         `json:"holder_name"`
+	CoolingOffUntil int64          `json:"cooling_off_until,omitempty"`
+	CreatedAt       int64          `json:"created_at"`
+}
+
+// BeneficiaryRequest represents the request body for saving a beneficiary.
+type BeneficiaryRequest struct {
+	Nickname   string         `json:"nickname"`
+	ReceiverID string         `json:"receiver_id,omitempty"`
+	Method     string         `json:"method"`
+	Details    PaymentDetails `json:"details"`
+}
+
+// BeneficiaryRename represents the request body for renaming a beneficiary.
+type BeneficiaryRename struct {
+	Nickname string `json:"nickname"`
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "encoding/json"
+
+// Command represents a transaction command received from the message queue.
+//
+// Fields:
+//   - ID: Identifier assigned by the producer, unique per command; a command whose ID was already
+//     processed is not run again.
+//   - Type: The command type ('transfer', 'payment_request' or 'payment_request_action').
+//   - UserID: The user on whose behalf the command runs (required for 'payment_request_action').
+//   - Payload: The command body: a RequestBody, MakePaymentRequest or PaymentRequestAction depending on Type.
+type Command struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	UserID  string          `json:"user_id,omitempty"`
+	Payload json.RawMessage `json:"payload"`
+}
@@ -3,15 +3,86 @@
 // this 
 package entity
 
+import "time"
+
+// Config:
+// This struct is the root of the service configuration. It is loaded once at startup by config.Load,
+// layering defaults, the YAML file, environment variables and command-line flags.
+//
+// Fields:
+// 	1. Project: 		The deployment environment (e.g. 'stag', 'prod'); selects config.<project>.yaml.
+// 	2. Server: 			Listener settings (top-level keys in the YAML file).
+// 	3. Api: 			API version prefix (top-level key in the YAML file).
+// 	4. Firebase: 		Firebase project and credentials.
+// 	5. Secrets: 		JWT signing key and issuer.
+// 	6. Payment ... Quote: The feature sections, documented on their own types.
+//
+type Config struct {
+	Project        string               `koanf:"project"`
+	Server         ServerConfig         `koanf:",squash"`
+	Api            Api                  `koanf:",squash"`
+	Firebase       FirebaseConfig       `koanf:"firebase"`
+	Secrets        SecretsConfig        `koanf:"secrets"`
+	Payment        PaymentConfig        `koanf:"paymentconfig"`
+	Swagger        Swagger              `koanf:"swagger"`
+	Review         ReviewConfig         `koanf:"review"`
+	Authorization  AuthorizationConfig  `koanf:"authorization"`
+	Scheduler      SchedulerConfig      `koanf:"scheduler"`
+	PaymentRequest PaymentRequestConfig `koanf:"paymentrequest"`
+	RabbitMQ       RabbitMQConfig       `koanf:"rabbitmq"`
+	Outbox         OutboxConfig         `koanf:"outbox"`
+	Webhook        WebhookConfig        `koanf:"webhook"`
+	Tracing        TracingConfig        `koanf:"tracing"`
+	RateLimit      RateLimitConfig      `koanf:"ratelimit"`
+	CORS           CORSConfig           `koanf:"cors"`
+	Security       SecurityConfig       `koanf:"security"`
+	StepUp         StepUpConfig         `koanf:"stepup"`
+	UPIPin         UPIPinConfig         `koanf:"upipin"`
+	Beneficiary    BeneficiaryConfig    `koanf:"beneficiary"`
+	Account        AccountConfig        `koanf:"account"`
+	Credit         CreditConfig         `koanf:"credit"`
+	Fees           FeeConfig            `koanf:"fees"`
+	Quote          QuoteConfig          `koanf:"quote"`
+}
+
+// FirebaseConfig:
+// This struct holds the Firebase project the service connects to.
+//
+// Fields:
+// 	1. ProjectID: 		The Firebase / Google Cloud project ID.
+// 	2. CredentialsFile: Path to the service account JSON file.
+//
+type FirebaseConfig struct {
+	ProjectID       string `koanf:"projectid"`
+	CredentialsFile string `koanf:"credentialsfile"`
+}
+
+// SecretsConfig:
+// This struct holds the secrets used to sign and verify auth tokens and transfer quote IDs.
+// They are normally supplied through the environment rather than the YAML file.
+//
+// Fields:
+// 	1. JWTKey: 		HMAC key used to sign JWTs (SECRET_KEY).
+// 	2. JWTIssuer: 	Issuer claim written into JWTs (SECRET_STRING).
+// 	3. QuoteKey: 	HMAC key used to sign transfer quote IDs (TX_SECRETS_QUOTEKEY), distinct from JWTKey.
+//
+type SecretsConfig struct {
+	JWTKey    string `koanf:"jwtkey"`
+	JWTIssuer string `koanf:"jwtissuer"`
+	QuoteKey  string `koanf:"quotekey"`
+}
+
 // ServerConfig:
 // This struct holds the configuration related to the server's settings.
 // It contains details such as the port the server listens on.
 //
 // Fields:
 // 	1. Port: The port number the server will listen to for incoming requests.
+// 	2. ShutdownTimeout: How long a graceful shutdown may take to drain requests and stop workers.
 //
 type ServerConfig struct {
-	Port int `koanf:"port"`
+	Port            int           `koanf:"port"`
+	ShutdownTimeout time.Duration `koanf:"shutdowntimeout"`
 }
 
 // PaymentConfig:
@@ -58,3 +129,400 @@ type Swagger struct {
 	BasePath string `koanf:"basePath"`
 	Url      string `koanf:"url"`
 }
+
+// ReviewConfig:
+// This struct holds the configuration for the manual review queue.
+// Transfers above a review threshold are held for an admin decision instead of being executed immediately.
+//
+// Fields:
+// 	1. UpiThreshold: 	UPI amounts above this value are held for review (0 disables the check).
+// 	2. CreditThreshold: Credit card amounts above this value are held for review (0 disables the check).
+// 	3. Timeout: 		How long a held transaction waits for a decision before it auto-expires.
+// 	4. SweepInterval: 	How often the queue is scanned for expired items.
+//
+type ReviewConfig struct {
+	UpiThreshold    float64       `koanf:"upi"`
+	CreditThreshold float64       `koanf:"credit"`
+	Timeout         time.Duration `koanf:"timeout"`
+	SweepInterval   time.Duration `koanf:"sweepinterval"`
+}
+
+// AuthorizationConfig:
+// This struct holds the configuration for two-phase payments (authorize, then capture or void).
+//
+// Fields:
+// 	1. TTL: 			How long an authorization keeps its funds reserved before it auto-expires.
+// 	2. SweepInterval: 	How often authorizations are scanned for expiry.
+//
+type AuthorizationConfig struct {
+	TTL           time.Duration `koanf:"ttl"`
+	SweepInterval time.Duration `koanf:"sweepinterval"`
+}
+
+// SchedulerConfig:
+// This struct holds the configuration for the scheduled transfer runner.
+//
+// Fields:
+// 	1. PollInterval: 	How often the scheduler looks for due schedules.
+// 	2. RunTimeout: 		How long a claimed run may stay running before it is marked failed.
+//
+type SchedulerConfig struct {
+	PollInterval time.Duration `koanf:"pollinterval"`
+	RunTimeout   time.Duration `koanf:"runtimeout"`
+}
+
+// PaymentRequestConfig:
+// This struct holds the configuration for the lifecycle of payment requests.
+//
+// Fields:
+// 	1. Expiry: 			Default lifetime of a payment request.
+// 	2. MaxExpiry: 		Longest lifetime a requester may ask for.
+// 	3. ReminderBefore: 	How long before expiry the payer is reminded (0 disables reminders).
+// 	4. SweepInterval: 	How often pending requests are scanned for reminders and expiry.
+//
+type PaymentRequestConfig struct {
+	Expiry         time.Duration `koanf:"expiry"`
+	MaxExpiry      time.Duration `koanf:"maxexpiry"`
+	ReminderBefore time.Duration `koanf:"reminderbefore"`
+	SweepInterval  time.Duration `koanf:"sweepinterval"`
+}
+
+// RabbitMQConfig:
+// This struct holds the configuration for the RabbitMQ command consumer.
+//
+// Fields:
+// 	1. Enabled: 			Whether the consumer runs alongside the HTTP server.
+// 	2. URL: 				AMQP connection URL of the broker.
+// 	3. Queue: 				Durable queue the transaction commands are consumed from.
+// 	4. DeadLetterExchange: 	Exchange that rejected (poison) messages are routed to.
+// 	5. DeadLetterQueue: 	Durable queue bound to the dead-letter exchange.
+// 	6. Prefetch: 			Maximum number of unacknowledged messages delivered at once.
+// 	7. ReconnectDelay: 		How long to wait before reconnecting after the connection is lost.
+//
+type RabbitMQConfig struct {
+	Enabled            bool          `koanf:"enabled"`
+	URL                string        `koanf:"url"`
+	Queue              string        `koanf:"queue"`
+	DeadLetterExchange string        `koanf:"deadletterexchange"`
+	DeadLetterQueue    string        `koanf:"deadletterqueue"`
+	Prefetch           int           `koanf:"prefetch"`
+	ReconnectDelay     time.Duration `koanf:"reconnectdelay"`
+}
+
+// OutboxConfig:
+// This struct holds the configuration for the outbox relay that publishes transaction events.
+//
+// Fields:
+// 	1. Enabled: 		Whether the relay runs alongside the HTTP server.
+// 	2. Exchange: 		Durable topic exchange the events are published to (routing key is the event type).
+// 	3. PollInterval: 	How often the outbox is scanned for unpublished events.
+//
+type OutboxConfig struct {
+	Enabled      bool          `koanf:"enabled"`
+	Exchange     string        `koanf:"exchange"`
+	PollInterval time.Duration `koanf:"pollinterval"`
+}
+
+// WebhookConfig:
+// This struct holds the configuration for outgoing webhook deliveries.
+//
+// Fields:
+// 	1. Timeout: 		How long a receiver has to respond to a delivery.
+// 	2. MaxAttempts: 	Number of attempts before a delivery is marked failed.
+// 	3. BaseBackoff: 	Delay before the first retry; it doubles after every failed attempt.
+// 	4. MaxBackoff: 		Upper bound of the retry delay.
+// 	5. PollInterval: 	How often pending deliveries are dispatched.
+//
+type WebhookConfig struct {
+	Timeout      time.Duration `koanf:"timeout"`
+	MaxAttempts  int           `koanf:"maxattempts"`
+	BaseBackoff  time.Duration `koanf:"basebackoff"`
+	MaxBackoff   time.Duration `koanf:"maxbackoff"`
+	PollInterval time.Duration `koanf:"pollinterval"`
+}
+
+// TracingConfig:
+// This struct holds the configuration for OpenTelemetry tracing.
+//
+// Fields:
+// 	1. Enabled: 		Whether spans are exported. Trace context is propagated either way.
+// 	2. Exporter: 		Where spans go: 'stdout' (pretty-printed, for local use), 'file', 'otlp' or 'none'.
+// 	3. Endpoint: 		OTLP/HTTP endpoint URL (e.g. http://localhost:4318); defaults to the OTEL_EXPORTER_OTLP_* variables.
+// 	4. File: 			Path the 'file' exporter appends JSON spans to.
+// 	5. SampleRatio: 	Fraction of new traces that are sampled (0 to 1); traces started upstream keep their decision.
+// 	6. ServiceName: 	The service.name resource attribute.
+//
+type TracingConfig struct {
+	Enabled     bool    `koanf:"enabled"`
+	Exporter    string  `koanf:"exporter"`
+	Endpoint    string  `koanf:"endpoint"`
+	File        string  `koanf:"file"`
+	SampleRatio float64 `koanf:"sampleratio"`
+	ServiceName string  `koanf:"servicename"`
+}
+
+// RateLimitConfig:
+// This struct holds the request rate limits and the lockout applied to failed logins.
+// Limits are read on every request, so a configuration reload applies them right away.
+//
+// Fields:
+// 	1. Enabled: 		Whether requests are rate limited. The login lockout applies either way.
+// 	2. Store: 			Where buckets and lockouts are kept; only 'memory' (per instance) is supported for now.
+// 	3. Login: 			Limits of the /login route.
+// 	4. Money: 			Limits of the routes that move or reserve money (/initiate, /request-action, ...).
+// 	5. Verify: 			Limits of /accounts/verify, kept low so it cannot be used to enumerate accounts.
+// 	6. Default: 		Limits of every API route; it runs before authentication, so only PerIP applies.
+// 	7. Lockout: 		Backoff applied to an account after repeated failed logins.
+//
+type RateLimitConfig struct {
+	Enabled bool          `koanf:"enabled"`
+	Store   string        `koanf:"store"`
+	Login   RateLimitRule `koanf:"login"`
+	Money   RateLimitRule `koanf:"money"`
+	Verify  RateLimitRule `koanf:"verify"`
+	Default RateLimitRule `koanf:"default"`
+	Lockout LockoutConfig `koanf:"lockout"`
+}
+
+// Rule returns the limits of the named route group ('login', 'money', 'verify' or 'default').
+// Unknown names get the default limits.
+func (r RateLimitConfig) Rule(group string) RateLimitRule {
+	switch group {
+	case "login":
+		return r.Login
+	case "money":
+		return r.Money
+	case "verify":
+		return r.Verify
+	default:
+		return r.Default
+	}
+}
+
+// RateLimitRule:
+// This struct holds the token buckets of one route group. A request must get a token from both.
+//
+// Fields:
+// 	1. PerIP: 			Bucket per client IP.
+// 	2. PerUser: 		Bucket per authenticated user; not used on routes without authentication.
+//
+type RateLimitRule struct {
+	PerIP   RateLimit `koanf:"perip"`
+	PerUser RateLimit `koanf:"peruser"`
+}
+
+// RateLimit:
+// This struct holds one token bucket: it holds up to Requests tokens and refills at Requests per Period.
+// A Requests of 0 disables the bucket.
+//
+// Fields:
+// 	1. Requests: 		Bucket size, and the number of requests allowed per Period on average.
+// 	2. Period: 			Time it takes to refill the bucket from empty.
+//
+type RateLimit struct {
+	Requests int           `koanf:"requests"`
+	Period   time.Duration `koanf:"period"`
+}
+
+// LockoutConfig:
+// This struct holds the lockout applied to an account after repeated failed logins.
+//
+// Fields:
+// 	1. Threshold: 		Failed logins allowed before the account is locked.
+// 	2. BaseDelay: 		Length of the first lockout; it doubles with every further failure.
+// 	3. MaxDelay: 		Upper bound of a lockout.
+// 	4. Window: 			How long failures are remembered after the last one.
+//
+type LockoutConfig struct {
+	Threshold int           `koanf:"threshold"`
+	BaseDelay time.Duration `koanf:"basedelay"`
+	MaxDelay  time.Duration `koanf:"maxdelay"`
+	Window    time.Duration `koanf:"window"`
+}
+
+// CORSConfig:
+// This struct holds the cross-origin policy for browser clients. It is applied when the router is built,
+// so changes take effect on restart.
+//
+// Fields:
+// 	1. AllowOrigins: 	Origins allowed to call the API (e.g. 'https://app.example.com'); one '*' per origin is
+// 						allowed as a wildcard, and a lone '*' allows every origin (only without AllowCredentials).
+// 	2. AllowMethods: 	Methods allowed in cross-origin requests.
+// 	3. AllowHeaders: 	Request headers allowed in cross-origin requests.
+// 	4. ExposeHeaders: 	Response headers browsers may expose to the calling script.
+// 	5. AllowCredentials: Whether cookies and the Authorization header may be sent cross-origin.
+// 	6. MaxAge: 			How long browsers may cache a preflight response.
+//
+type CORSConfig struct {
+	AllowOrigins     []string      `koanf:"alloworigins"`
+	AllowMethods     []string      `koanf:"allowmethods"`
+	AllowHeaders     []string      `koanf:"allowheaders"`
+	ExposeHeaders    []string      `koanf:"exposeheaders"`
+	AllowCredentials bool          `koanf:"allowcredentials"`
+	MaxAge           time.Duration `koanf:"maxage"`
+}
+
+// SecurityConfig:
+// This struct holds the security headers added to every response. They are read on every request,
+// so a configuration reload applies them right away.
+//
+// Fields:
+// 	1. HSTSMaxAge: 		max-age of the Strict-Transport-Security header; 0 leaves the header out.
+// 	2. HSTSSubdomains: 	Whether the HSTS policy covers subdomains.
+// 	3. FrameOptions: 	Value of X-Frame-Options ('DENY' or 'SAMEORIGIN'); empty leaves the header out.
+// 	4. NoSniff: 		Whether to send X-Content-Type-Options: nosniff.
+// 	5. NoStore: 		Whether responses of the routes that move money or return transactions
+// 						carry Cache-Control: no-store.
+//
+type SecurityConfig struct {
+	HSTSMaxAge     time.Duration `koanf:"hstsmaxage"`
+	HSTSSubdomains bool          `koanf:"hstssubdomains"`
+	FrameOptions   string        `koanf:"frameoptions"`
+	NoSniff        bool          `koanf:"nosniff"`
+	NoStore        bool          `koanf:"nostore"`
+}
+
+// StepUpConfig:
+// This struct holds when a transfer or payment request acceptance must be confirmed with a TOTP code.
+//
+// Fields:
+// 	1. Enabled: 			Whether step-up challenges are issued at all.
+// 	2. Amount: 				Transfers and acceptances above this amount need a code; 0 disables the amount check.
+// 	3. NewPayee: 			Whether the first successful transfer to a receiver needs a code.
+// 	4. RequestAcceptance: 	Whether every payment request acceptance needs a code.
+// 	5. ChallengeTTL: 		How long a challenge can be confirmed; the bound transfer expires with it.
+// 	6. MaxAttempts: 		Wrong codes allowed before a challenge fails.
+// 	7. Issuer: 				Issuer shown by authenticator apps for enrolled accounts.
+// 	8. SweepInterval: 		How often unconfirmed challenges are checked for expiry.
+//
+type StepUpConfig struct {
+	Enabled           bool          `koanf:"enabled"`
+	Amount            float64       `koanf:"amount"`
+	NewPayee          bool          `koanf:"newpayee"`
+	RequestAcceptance bool          `koanf:"requestacceptance"`
+	ChallengeTTL      time.Duration `koanf:"challengettl"`
+	MaxAttempts       int           `koanf:"maxattempts"`
+	Issuer            string        `koanf:"issuer"`
+	SweepInterval     time.Duration `koanf:"sweepinterval"`
+}
+
+// UPIPinConfig:
+// This struct holds the transaction PIN users set for each of their UPI IDs.
+//
+// Fields:
+// 	1. Enabled: 		Whether UPI transfers and request acceptances must carry the PIN of the paying UPI ID.
+// 	2. Length: 			Number of digits a PIN has (4 to 6).
+// 	3. MaxAttempts: 	Wrong PINs in a row before the UPI ID is locked.
+// 	4. Lockout: 		How long a UPI ID stays locked; PINs are refused until it ends.
+//
+type UPIPinConfig struct {
+	Enabled     bool          `koanf:"enabled"`
+	Length      int           `koanf:"length"`
+	MaxAttempts int           `koanf:"maxattempts"`
+	Lockout     time.Duration `koanf:"lockout"`
+}
+
+// BeneficiaryConfig:
+// This struct holds the cooling-off period of newly saved beneficiaries.
+//
+// Fields:
+// 	1. CoolingOff: 		How long after it is saved a beneficiary is in its cooling-off period;
+// 						0 disables it. Beneficiaries keep the period they were saved with.
+// 	2. CoolingOffLimit: Largest transfer to a beneficiary during its cooling-off period.
+//
+type BeneficiaryConfig struct {
+	CoolingOff      time.Duration `koanf:"coolingoff"`
+	CoolingOffLimit float64       `koanf:"coolingofflimit"`
+}
+
+// AccountConfig:
+// This struct holds when active accounts are marked dormant.
+//
+// Fields:
+// 	1. DormantAfter: 	How long an account may go without a debit or credit before it is marked dormant;
+// 						0 disables dormancy detection.
+// 	2. SweepInterval: 	How often accounts are checked for dormancy.
+//
+type AccountConfig struct {
+	DormantAfter  time.Duration `koanf:"dormantafter"`
+	SweepInterval time.Duration `koanf:"sweepinterval"`
+}
+
+// CreditConfig:
+// This struct holds the daily batch that charges interest and fees on utilized credit lines.
+//
+// Fields:
+// 	1. InterestRate: 	Yearly interest rate on the utilized amount (e.g. 0.36 for 36%), accrued daily; 0 charges none.
+// 	2. AccrualInterval: How often the batch checks for credit accounts not yet charged for the day.
+//
+type CreditConfig struct {
+	InterestRate    float64       `koanf:"interestrate"`
+	AccrualInterval time.Duration `koanf:"accrualinterval"`
+}
+
+// FeeConfig:
+// This struct holds the pricing rules that decide the fee charged on a transfer.
+//
+// Fields:
+// 	1. RevenueAccount: 	Account number every fee is credited to, in the same Firestore transaction as the transfer.
+// 	2. Rules: 			The pricing rules; the first rule matching a transfer prices it, and a transfer
+// 						no rule matches is free.
+//
+type FeeConfig struct {
+	RevenueAccount string    `koanf:"revenueaccount"`
+	Rules          []FeeRule `koanf:"rules"`
+}
+
+// FeeRule:
+// This struct holds one pricing rule. Empty match fields match anything. The fee is Fixed plus Percent of
+// the amount plus the Tiers charge, then raised to MinFee and capped at MaxFee.
+//
+// Fields:
+// 	1. Name: 			Identifies the rule in fee breakdowns.
+// 	2. PaymentMethod: 	Sender's method the rule applies to (e.g. 'UPI', 'CREDIT_CARD').
+// 	3. ReceivingMethod: Receiver's method the rule applies to (e.g. 'UPI', 'BANK').
+// 	4. Tier: 			Sender's user tier the rule applies to (e.g. 'standard', 'premium').
+// 	5. MinAmount: 		Smallest amount the rule applies to.
+// 	6. MaxAmount: 		Largest amount the rule applies to; 0 for no upper bound.
+// 	7. Fixed: 			Flat part of the fee.
+// 	8. Percent: 		Percentage of the amount charged (e.g. 1.5 for 1.5%).
+// 	9. Tiers: 			Marginal percentages charged on successive slices of the amount.
+// 	10. MinFee: 		Smallest fee the rule charges.
+// 	11. MaxFee: 		Largest fee the rule charges; 0 for no cap.
+//
+type FeeRule struct {
+	Name            string    `koanf:"name"`
+	PaymentMethod   string    `koanf:"paymentmethod"`
+	ReceivingMethod string    `koanf:"receivingmethod"`
+	Tier            string    `koanf:"tier"`
+	MinAmount       float64   `koanf:"minamount"`
+	MaxAmount       float64   `koanf:"maxamount"`
+	Fixed           float64   `koanf:"fixed"`
+	Percent         float64   `koanf:"percent"`
+	Tiers           []FeeTier `koanf:"tiers"`
+	MinFee          float64   `koanf:"minfee"`
+	MaxFee          float64   `koanf:"maxfee"`
+}
+
+// FeeTier:
+// This struct holds one slice of a tiered fee.
+//
+// Fields:
+// 	1. UpTo: 			Upper bound of the slice of the amount; 0 for the rest of the amount. Tiers are listed in
+// 						increasing order.
+// 	2. Percent: 		Percentage charged on the part of the amount within the slice.
+//
+type FeeTier struct {
+	UpTo    float64 `koanf:"upto"`
+	Percent float64 `koanf:"percent"`
+}
+
+// QuoteConfig:
+// This struct holds the transfer quotes clients get before initiating a transfer.
+//
+// Fields:
+// 	1. TTL: 			How long a quote can be executed at its locked terms.
+//
+type QuoteConfig struct {
+	TTL time.Duration `koanf:"ttl"`
+}
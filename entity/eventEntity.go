@@ -0,0 +1,43 @@
+package entity
+
+// EventSchemaVersion is the version of the TransactionEvent JSON schema.
+// It is bumped whenever a field is removed or changes meaning; adding fields keeps the version.
+const EventSchemaVersion = 1
+
+// TransactionEvent represents a transaction state change recorded in the outbox
+// and published to the event exchange.
+//
+// Fields:
+//   - ID: Unique identifier of the event ("<transactionID>-<sequence>"), used by consumers for de-duplication.
+//   - SchemaVersion: Version of this schema (see EventSchemaVersion).
+//   - Type: The event type (e.g., 'transaction.created', 'transaction.succeeded', 'transaction.failed').
+//   - TransactionID: Identifier of the transaction that changed.
+//   - Sequence: Position of the event in the transaction's history, starting at 1.
+//   - Status: The transaction status after the change.
+//   - OccurredAt: Unix time at which the change was made.
+//   - Data: A snapshot of the transaction's main fields.
+//   - Published: Whether the relay has published the event (outbox bookkeeping, not part of the message).
+//   - PublishedAt: Unix time at which the event was published (outbox bookkeeping, not part of the message).
+type TransactionEvent struct {
+	ID            string               `json:"id"`
+	SchemaVersion int                  `json:"schema_version"`
+	Type          string               `json:"type"`
+	TransactionID string               `json:"transaction_id"`
+	Sequence      int64                `json:"sequence"`
+	Status        string               `json:"status"`
+	OccurredAt    int64                `json:"occurred_at"`
+	Data          TransactionEventData `json:"data"`
+	Published     bool                 `json:"-"`
+	PublishedAt   int64                `json:"-"`
+}
+
+// TransactionEventData is the snapshot of a transaction carried by a TransactionEvent.
+type TransactionEventData struct {
+	SenderID        string  `json:"sender_id"`
+	ReceiverID      string  `json:"receiver_id,omitempty"`
+	Amount          float64 `json:"amount"`
+	PaymentMethod   string  `json:"payment_method"`
+	RecievingMethod string  `json:"recieving_method"`
+	TransactionType string  `json:"transaction_type"`
+	ActionBy        string  `json:"action_by,omitempty"`
+}
@@ -0,0 +1,36 @@
+package entity
+
+// FeeBreakdown shows how the fee of a transfer was priced. It is stored on the transaction and
+// returned in fee quotes.
+//
+// Fields:
+//   - Rule: Name of the pricing rule that matched the transfer.
+//   - Tier: The sender's user tier the transfer was priced for.
+//   - Fixed: The flat part of the fee.
+//   - Variable: The part of the fee charged as a percentage of the amount, tiered or not.
+//   - Adjustment: What raising the fee to the rule's minimum (positive) or capping it (negative) changed.
+//   - Fee: The fee charged, debited from the sender on top of the amount.
+type FeeBreakdown struct {
+	Rule       string  `json:"rule"`
+	Tier       string  `json:"tier"`
+	Fixed      float64 `json:"fixed"`
+	Variable   float64 `json:"variable"`
+	Adjustment float64 `json:"adjustment,omitempty"`
+	Fee        float64 `json:"fee"`
+}
+
+// FeeQuoteRequest represents the request body for quoting the fee of a transfer.
+type FeeQuoteRequest struct {
+	Amount          float64 `json:"amount"`
+	PaymentMethod   string  `json:"payment_method"`
+	RecievingMethod string  `json:"recieving_method"`
+}
+
+// FeeQuote is the fee a transfer would be charged, and the total debited from the sender.
+// Breakdown is empty when no pricing rule applies and the transfer is free.
+type FeeQuote struct {
+	Amount    float64       `json:"amount"`
+	Fee       float64       `json:"fee"`
+	Total     float64       `json:"total"`
+	Breakdown *FeeBreakdown `json:"breakdown,omitempty"`
+}
@@ -0,0 +1,21 @@
+package entity
+
+// Notification represents an event addressed to a single user, such as a payment request reminder.
+//
+// Fields:
+//   - ID: Unique identifier for the notification.
+//   - UserID: Identifier of the user the notification is for.
+//   - Type: The event type (e.g., 'payment_request.reminder', 'payment_request.declined').
+//   - RequestID: Identifier of the related payment request (optional).
+//   - TransactionID: Identifier of the related transaction (optional).
+//   - Message: Human readable description of the event.
+//   - CreatedAt: Unix time at which the event fired.
+type Notification struct {
+	ID            string `json:"id"`
+	UserID        string `json:"user_id"`
+	Type          string `json:"type"`
+	RequestID     string `json:"request_id,omitempty"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	Message       string `json:"message"`
+	CreatedAt     int64  `json:"created_at"`
+}
@@ -0,0 +1,39 @@
+package entity
+
+// TransferQuote is the preview of a transfer: it passed every check a transfer goes through, without
+// moving money, and can be executed once, before it expires, at the fee it was priced at.
+//
+// Fields:
+//   - QuoteID: The signed quote ID clients pass to /initiate; it is not stored.
+//   - ID: Identifier of the quote document.
+//   - UserID: Identifier of the user the quote was given to.
+//   - SenderAccNo, ReceiverAccNo: Account numbers the transfer resolved to.
+//   - Amount: The amount that will be transferred.
+//   - PaymentMethod: The sender's payment method.
+//   - RecievingMethod: The receiver's method.
+//   - Fee: The fee locked by the quote (omitted for free transfers).
+//   - Total: The amount plus the fee, debited from the sender.
+//   - Review: Why the transfer will be held for manual review (empty if it will not be).
+//   - StepUp: Why the transfer will need a TOTP code (empty if it will not).
+//   - Status: The quote status ('open' or 'used').
+//   - CreatedAt: Unix time at which the quote was given.
+//   - ExpiresAt: Unix time after which the quote can no longer be executed.
+//   - UsedAt: Unix time at which the quote was executed.
+type TransferQuote struct {
+	QuoteID         string        `json:"quote_id" firestore:"-"`
+	ID              string        `json:"-"`
+	UserID          string        `json:"-"`
+	SenderAccNo     string        `json:"-"`
+	ReceiverAccNo   string        `json:"-"`
+	Amount          float64       `json:"amount"`
+	PaymentMethod   string        `json:"payment_method"`
+	RecievingMethod string        `json:"recieving_method"`
+	Fee             *FeeBreakdown `json:"fee,omitempty" firestore:",omitempty"`
+	Total           float64       `json:"total"`
+	Review          string        `json:"review,omitempty"`
+	StepUp          string        `json:"step_up,omitempty"`
+	Status          string        `json:"status"`
+	CreatedAt       int64         `json:"created_at"`
+	ExpiresAt       int64         `json:"expires_at"`
+	UsedAt          int64         `json:"used_at,omitempty"`
+}
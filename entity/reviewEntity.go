@@ -0,0 +1,56 @@
+package entity
+
+// ReviewItem represents a transaction that has been held for manual review.
+// The sender's funds are reserved against their account while the item is pending,
+// and are either moved (approve) or released (reject/expire) once a decision is made.
+//
+// Fields:
+//   - ID: Identifier of the review item (same as the held transaction ID).
+//   - TransactionID: Identifier of the held transaction.
+//   - SenderID: Identifier of the user who initiated the transfer.
+//   - ReceiverID: Identifier of the receiving user, if known.
+//   - SenderAccNo: Account number the funds are reserved against.
+//   - ReceiverAccNo: Account number that is credited on approval.
+//   - Amount: The amount of the transfer.
+//   - Fee: The fee charged on the transfer; the amount plus the fee is reserved.
+//   - PaymentMethod: The sender's payment method (e.g., 'UPI', 'CREDIT').
+//   - ReceivingMethod: The receiver's method (e.g., 'UPI', 'BANK').
+//   - Reason: Why the transaction was held (e.g., review threshold exceeded).
+//   - Status: The review status ('pending', 'approved', 'rejected', 'expired').
+//   - CreatedAt: Unix time at which the transaction was held.
+//   - ExpiresAt: Unix time after which the item auto-expires.
+//   - ReviewedBy: Identifier of the admin who decided the item (optional).
+//   - Note: The reviewer's note for the decision (optional).
+//   - ReviewedAt: Unix time of the decision (optional).
+type ReviewItem struct {
+	ID              string  `json:"id"`
+	TransactionID   string  `json:"transaction_id"`
+	SenderID        string  `json:"sender_id"`
+	ReceiverID      string  `json:"receiver_id,omitempty"`
+	SenderAccNo     string  `json:"sender_acc_no"`
+	ReceiverAccNo   string  `json:"receiver_acc_no"`
+	Amount          float64 `json:"amount"`
+	Fee             float64 `json:"fee,omitempty"`
+	PaymentMethod   string  `json:"payment_method"`
+	ReceivingMethod string  `json:"receiving_method,omitempty"`
+	Reason          string  `json:"reason"`
+	Status          string  `json:"status"`
+	CreatedAt       int64   `json:"created_at"`
+	ExpiresAt       int64   `json:"expires_at"`
+	ReviewedBy      string  `json:"reviewed_by,omitempty"`
+	Note            string  `json:"note,omitempty"`
+	ReviewedAt      int64   `json:"reviewed_at,omitempty"`
+}
+
+// ReviewDetails bundles a review item with the transaction it holds,
+// giving a reviewer the full context needed to make a decision.
+type ReviewDetails struct {
+	Review      *ReviewItem  `json:"review"`
+	Transaction *Transaction `json:"transaction"`
+}
+
+// ReviewDecision represents the body of an approve or reject call on a review item.
+// A note explaining the decision is mandatory.
+type ReviewDecision struct {
+	Note string `json:"note" validate:"required"`
+}
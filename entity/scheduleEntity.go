@@ -0,0 +1,71 @@
+package entity
+
+// Schedule represents a future or recurring transfer owned by a user.
+// Due schedules are executed by the scheduler through the same path as an initiated transaction.
+//
+// Fields:
+//   - ID: Unique identifier for the schedule.
+//   - OwnerID: Identifier of the user who created the schedule.
+//   - Transfer: The transfer to execute on every run.
+//   - Frequency: How often the transfer runs ('once', 'daily', 'weekly', 'monthly', 'cron').
+//   - Cron: Five-field cron expression, used when Frequency is 'cron'.
+//   - StartAt: Unix time of the first run.
+//   - EndAt: Unix time after which no further runs happen (0 for no end date).
+//   - MaxRuns: Maximum number of runs (0 for no limit).
+//   - RunCount: Number of runs executed so far.
+//   - NextRunAt: Unix time of the next run (0 once the schedule has finished).
+//   - LastRunAt: Unix time of the most recent run.
+//   - Status: The schedule status ('active', 'paused', 'cancelled', 'completed').
+//   - CreatedAt: Unix time at which the schedule was created.
+type Schedule struct {
+	ID        string      `json:"id"`
+	OwnerID   string      `json:"owner_id"`
+	Transfer  RequestBody `json:"transfer"`
+	Frequency string      `json:"frequency"`
+	Cron      string      `json:"cron,omitempty"`
+	StartAt   int64       `json:"start_at"`
+	EndAt     int64       `json:"end_at,omitempty"`
+	MaxRuns   int         `json:"max_runs,omitempty"`
+	RunCount  int         `json:"run_count"`
+	NextRunAt int64       `json:"next_run_at"`
+	LastRunAt int64       `json:"last_run_at,omitempty"`
+	Status    string      `json:"status"`
+	CreatedAt int64       `json:"created_at"`
+}
+
+// ScheduleRequest represents the request body for creating a schedule.
+// StartAt is a Unix timestamp; EndAt and MaxRuns are optional end conditions.
+// Code is the sender's TOTP code, required when the transfer needs step-up authentication.
+type ScheduleRequest struct {
+	Transfer  RequestBody `json:"transfer" validate:"required"`
+	Frequency string      `json:"frequency" validate:"required"`
+	Cron      string      `json:"cron,omitempty"`
+	StartAt   int64       `json:"start_at" validate:"required"`
+	EndAt     int64       `json:"end_at,omitempty"`
+	MaxRuns   int         `json:"max_runs,omitempty"`
+	Code      string      `json:"code,omitempty"`
+}
+
+// ScheduleRun records a single execution of a schedule.
+// Its ID is derived from the schedule ID and the scheduled time, so a run can only ever be claimed once.
+//
+// Fields:
+//   - ID: Identifier of the run ('<scheduleID>_<scheduledFor>').
+//   - ScheduleID: Identifier of the schedule that ran.
+//   - ScheduledFor: Unix time the run was due.
+//   - StartedAt: Unix time the run was claimed.
+//   - FinishedAt: Unix time the transfer completed (0 while running).
+//   - Status: The run status ('running', 'success', 'held', 'fail').
+//   - TransactionID: Identifier of the transaction created by the run (optional).
+//   - Error: The failure reason, if the run failed (optional). Runs left running past the scheduler's
+//     run timeout, e.g. by a crash, are failed with an error saying their outcome is unknown.
+type ScheduleRun struct {
+	ID            string `json:"id"`
+	ScheduleID    string `json:"schedule_id"`
+	ScheduledFor  int64  `json:"scheduled_for"`
+	StartedAt     int64  `json:"started_at"`
+	FinishedAt    int64  `json:"finished_at,omitempty"`
+	Status        string `json:"status"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
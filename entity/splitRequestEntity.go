@@ -0,0 +1,68 @@
+package entity
+
+// SplitRequest represents the request body for splitting a bill between several payers.
+// One payment request is created per payer under a shared group.
+//
+// Fields:
+//   - RequesterID: Identifier of the user collecting the money.
+//   - RequesterPaymentMethod: The requester's receiving method (only 'upi' is supported).
+//   - RequesterPaymentDetails: The requester's receiving details.
+//   - TotalAmount: The amount to split (ignored for 'exact' splits, where it is the sum of the shares).
+//   - SplitType: How the total is divided ('equal', 'percentage' or 'exact').
+//   - Payers: The payers and their shares.
+//   - ExpiresIn: Optional lifetime of every request in seconds.
+type SplitRequest struct {
+	RequesterID             string         `json:"requester_id" validate:"required"`
+	RequesterPaymentMethod  string         `json:"requester_payment_method" validate:"required,eq=upi"`
+	RequesterPaymentDetails PaymentDetails `json:"requester_payment_details" validate:"required"`
+	TotalAmount             float64        `json:"total_amount"`
+	SplitType               string         `json:"split_type" validate:"required"`
+	Payers                  []SplitPayer   `json:"payers" validate:"required,min=2"`
+	ExpiresIn               int64          `json:"expires_in,omitempty"`
+}
+
+// SplitPayer represents one payer of a split request.
+// Percentage is used by 'percentage' splits and Amount by 'exact' splits.
+type SplitPayer struct {
+	PayerID             string         `json:"payer_id" validate:"required"`
+	PayerPaymentMethod  string         `json:"payer_payment_method" validate:"required,eq=upi"`
+	PayerPaymentDetails PaymentDetails `json:"payer_payment_details" validate:"required"`
+	Percentage          float64        `json:"percentage,omitempty"`
+	Amount              float64        `json:"amount,omitempty"`
+}
+
+// PaymentRequestGroup represents a split bill: the parent of one payment request per payer.
+//
+// Fields:
+//   - ID: Unique identifier for the group.
+//   - RequesterID: Identifier of the user collecting the money.
+//   - TotalAmount: The total amount requested across all payers.
+//   - SplitType: How the total was divided ('equal', 'percentage' or 'exact').
+//   - Status: 'open' while requests are outstanding, 'closed' once none are, or 'cancelled'.
+//   - CreatedAt: Unix time at which the group was created.
+//   - Members: The payers, their shares and their request IDs.
+type PaymentRequestGroup struct {
+	ID          string        `json:"id"`
+	RequesterID string        `json:"requester_id"`
+	TotalAmount float64       `json:"total_amount"`
+	SplitType   string        `json:"split_type"`
+	Status      string        `json:"status"`
+	CreatedAt   int64         `json:"created_at"`
+	Members     []GroupMember `json:"members"`
+}
+
+// GroupMember links a payer of a split group to the payment request created for them.
+type GroupMember struct {
+	PayerID   string  `json:"payer_id"`
+	RequestID string  `json:"request_id"`
+	Amount    float64 `json:"amount"`
+}
+
+// PaymentRequestGroupDetails bundles a group with the current state of each payer's request
+// and the amounts collected and still outstanding.
+type PaymentRequestGroupDetails struct {
+	Group       *PaymentRequestGroup     `json:"group"`
+	Requests    []*PaymentRequestDetails `json:"requests"`
+	Collected   float64                  `json:"collected"`
+	Outstanding float64                  `json:"outstanding"`
+}
@@ -0,0 +1,70 @@
+package entity
+
+// TOTPEnrollment represents a user's authenticator app, used to confirm step-up challenges.
+// It is stored under the user's ID; the secret never leaves the service after enrollment.
+//
+// Fields:
+//   - UserID: Identifier of the enrolled user.
+//   - Secret: Base32 TOTP secret shared with the authenticator app.
+//   - Confirmed: Whether the user has proven the app works by entering a code.
+//   - LastUsedStep: TOTP time step of the last accepted code; codes from this step or earlier are refused.
+//   - CreatedAt: Unix time at which the secret was generated.
+//   - ConfirmedAt: Unix time at which the enrollment was confirmed.
+type TOTPEnrollment struct {
+	UserID       string `json:"user_id"`
+	Secret       string `json:"-"`
+	Confirmed    bool   `json:"confirmed"`
+	LastUsedStep int64  `json:"-"`
+	CreatedAt    int64  `json:"created_at"`
+	ConfirmedAt  int64  `json:"confirmed_at,omitempty"`
+}
+
+// TOTPSetup is returned once when a user starts enrollment, for the authenticator app.
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URL    string `json:"otpauth_url"`
+}
+
+// StepUpChallenge represents a transfer or payment request acceptance waiting for the user to confirm
+// it with a TOTP code. The challenge is bound to the transaction it was issued for and can only
+// release that transaction.
+//
+// Fields:
+//   - ID: Unique identifier for the challenge.
+//   - UserID: Identifier of the user who must confirm it.
+//   - Kind: What is waiting ('transfer', 'authorization' or 'request_acceptance').
+//   - Reason: Why a code is needed ('amount', 'new_payee' or 'request_acceptance').
+//   - TransactionID: The pending transaction the challenge releases.
+//   - RequestID: The payment request being accepted (request acceptances only).
+//   - Amount: The amount that will be moved.
+//   - Fee: The fee that will be charged on top of the amount (transfers only).
+//   - Transfer: The original transfer or hold request (transfers and authorizations only).
+//   - SenderAccNo, ReceiverAccNo: Account numbers resolved when the transfer was initiated (transfers and authorizations only).
+//   - Status: The challenge status ('pending', 'confirmed', 'failed', 'expired').
+//   - Attempts: Number of wrong codes entered so far.
+//   - CreatedAt: Unix time at which the challenge was issued.
+//   - ExpiresAt: Unix time after which the challenge can no longer be confirmed.
+//   - ConfirmedAt: Unix time at which the challenge was confirmed.
+type StepUpChallenge struct {
+	ID            string       `json:"id"`
+	UserID        string       `json:"user_id"`
+	Kind          string       `json:"kind"`
+	Reason        string       `json:"reason"`
+	TransactionID string       `json:"transaction_id"`
+	RequestID     string       `json:"request_id,omitempty"`
+	Amount        float64      `json:"amount"`
+	Fee           float64      `json:"fee,omitempty"`
+	Transfer      *RequestBody `json:"-"`
+	SenderAccNo   string       `json:"-"`
+	ReceiverAccNo string       `json:"-"`
+	Status        string       `json:"status"`
+	Attempts      int          `json:"attempts"`
+	CreatedAt     int64        `json:"created_at"`
+	ExpiresAt     int64        `json:"expires_at"`
+	ConfirmedAt   int64        `json:"confirmed_at,omitempty"`
+}
+
+// TOTPCode represents the body of a call that takes a TOTP code, such as confirming an enrollment or a challenge.
+type TOTPCode struct {
+	Code string `json:"code"`
+}
@@ -14,10 +14,14 @@ package entity
 //   - RecievingMethod: The payment method used by the receiver (e.g., 'UPI', 'CreditCard', 'Bank').
 //   - SenderPaymentDetails: The payment details of the sender, including UPI, credit card, or bank details.
 //   - RecieverPaymentDetails: The payment details of the receiver, including UPI, credit card, or bank details.
-//   - Status: The current status of the transaction (e.g., 'success', 'failed', 'pending', 'cancelled').
+//   - Status: The current status of the transaction (e.g., 'success', 'failed', 'pending', 'cancelled', 'held').
 //   - Timestamp: The time when the transaction occurred.
 //   - TransactionType: The type of the transaction (e.g., 'transfer', 'payment').
 //   - ActionBy: Identifier of the person performing the action on the transaction (optional).
+//   - SenderAccNo: Account number debited by the transaction. Stored, but not returned by the API.
+//   - ReceiverAccNo: Account number credited by the transaction, used to tell new payees apart. Stored, but not returned by the API.
+//   - EventSeq: Sequence number of the last event written to the outbox for the transaction.
+//   - Fee: How the fee charged on the transfer was priced (omitted for free transfers).
 type Transaction struct {
 	ID                     string         `json:"id"`
 	SenderID               string         `json:"sender_id" validate:"required"`
@@ -33,6 +37,10 @@ type Transaction struct {
 	Timestamp              int64          `json:"timestamp"`
 	TransactionType        string         `json:"transaction_type" validate:"required"`
 	ActionBy               string         `json:"action_by,omitempty"`
+	SenderAccNo            string         `json:"-" firestore:",omitempty"`
+	ReceiverAccNo          string         `json:"-" firestore:",omitempty"`
+	EventSeq               int64          `json:"-"`
+	Fee                    *FeeBreakdown  `json:"fee,omitempty" firestore:",omitempty"`
 }
 
 // PaymentDetails contains the payment information for both sender and receiver.
@@ -66,6 +74,10 @@ type BankDetails struct {
 
 // RequestBody represents the structure of the request body for initiating a transaction.
 // It includes sender and receiver details, payment methods, and payment details for both participants.
+// Pin is the sender's UPI PIN, required for UPI transfers; it is never stored.
+// BeneficiaryID names one of the sender's saved beneficiaries to pay instead of giving the
+// receiving method and details. QuoteID executes the transfer at the terms of a quote given for it;
+// like the PIN, it is never stored.
 type RequestBody struct {
 	SenderID               string         `json:"sender_id"`
 	ReceiverID             string         `json:"receiver_id,omitempty"`
@@ -75,10 +87,14 @@ type RequestBody struct {
 	TransactionType        string         `json:"transaction_type"`
 	SenderPaymentDetails   PaymentDetails `json:"sender_payment_details"`
 	ReceiverPaymentDetails PaymentDetails `json:"receiver_payment_details"`
+	BeneficiaryID          string         `json:"beneficiary_id,omitempty"`
+	Pin                    string         `json:"pin,omitempty" firestore:"-"`
+	QuoteID                string         `json:"quote_id,omitempty" firestore:"-"`
 }
 
 // MakePaymentRequest represents the structure for a request to make a payment.
 // It includes sender and receiver details, amount, payment methods, and payment details.
+// ExpiresIn optionally sets the request's lifetime in seconds; the configured default is used when it is 0.
 type MakePaymentRequest struct {
 	RequesterID             string         `json:"requester_id" validate:"required"`
 	PayerID                 string         `json:"payer_id" validate:"required"`
@@ -88,17 +104,27 @@ type MakePaymentRequest struct {
 	TransactionType         string         `json:"transaction_type"`
 	RequesterPaymentDetails PaymentDetails `json:"requester_payment_details" validate:"required"`
 	PayerPaymentDetails     PaymentDetails `json:"payer_payment_details" validate:"required"`
+	ExpiresIn               int64          `json:"expires_in,omitempty"`
 }
 
 // PaymentRequestAction represents the structure for an action to be performed on a payment request.
-// It includes the request ID and the action to be performed (e.g., "approve", "reject").
+// It includes the request ID and the action to be performed ("Accept", "Cancel" or "Decline").
+// A reason is required when the payer declines, and is shown to the requester.
+// Pin is the payer's UPI PIN, required to accept.
 type PaymentRequestAction struct {
 	RequestID string `json:"request_id" validate:"required"`
 	Action    string `json:"action" validate:"required"`
+	Reason    string `json:"reason,omitempty"`
+	Pin       string `json:"pin,omitempty"`
 }
 
 // TransactionRequest represents the structure for a transaction request.
 // It includes the transaction ID, sender and receiver account numbers, amount, payment method, and other relevant details.
+//
+// Status is one of 'pending', 'accepted', 'cancelled', 'declined' or 'expired'.
+// A pending request expires at ExpiresAt; ReminderSentAt records when the payer was reminded,
+// and DeclineReason holds the payer's reason when the request was declined.
+// GroupID links the request to its split group, if it was created as part of one.
 type TransactionRequest struct {
 	ID             string  `json:"id"`
 	RequesterAccNo string  `json:"requesterAccNo"`
@@ -108,4 +134,38 @@ type TransactionRequest struct {
 	TransactionID  string  `json:"transactionID"`
 	From           string  `json:"from"`
 	To             string  `json:"to"`
+	Status         string  `json:"status"`
+	CreatedAt      int64   `json:"createdAt"`
+	ExpiresAt      int64   `json:"expiresAt"`
+	ReminderSentAt int64   `json:"reminderSentAt,omitempty"`
+	DeclineReason  string  `json:"declineReason,omitempty"`
+	GroupID        string  `json:"groupID,omitempty"`
+}
+
+// TransactionResult represents the outcome of initiating a transaction.
+// It carries the stored transaction ID and the status the transaction ended up in
+// (e.g., 'success', 'held' when it was queued for manual review, or 'challenge_required'
+// when it waits for the sender to confirm the step-up challenge ChallengeID).
+// Fee is the fee charged on top of the amount, if any.
+type TransactionResult struct {
+	TransactionID string  `json:"transaction_id"`
+	Status        string  `json:"status"`
+	ChallengeID   string  `json:"challenge_id,omitempty"`
+	Fee           float64 `json:"fee,omitempty"`
+}
+
+// PaymentRequestDetails is the view of a TransactionRequest returned to its requester and payer.
+// Account numbers are internal and are not exposed.
+type PaymentRequestDetails struct {
+	ID            string  `json:"id"`
+	RequesterID   string  `json:"requester_id"`
+	PayerID       string  `json:"payer_id"`
+	Amount        float64 `json:"amount"`
+	PaymentMethod string  `json:"payment_method"`
+	TransactionID string  `json:"transaction_id"`
+	Status        string  `json:"status"`
+	CreatedAt     int64   `json:"created_at,omitempty"`
+	ExpiresAt     int64   `json:"expires_at,omitempty"`
+	DeclineReason string  `json:"decline_reason,omitempty"`
+	GroupID       string  `json:"group_id,omitempty"`
 }
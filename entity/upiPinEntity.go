@@ -0,0 +1,36 @@
+package entity
+
+// UPIPin represents the transaction PIN a user set for one of their UPI IDs.
+// It is stored under the lower-cased UPI ID; only the bcrypt hash of the PIN is kept.
+//
+// Fields:
+//   - UserID: Identifier of the user the UPI ID is linked to.
+//   - UpiID: The UPI ID the PIN protects.
+//   - Hash: bcrypt hash of the PIN.
+//   - FailedAttempts: Wrong PINs entered in a row since the last correct PIN or lockout.
+//   - LockedUntil: Unix time until which PINs are refused after too many wrong ones (0 when not locked).
+//   - CreatedAt: Unix time at which the PIN was first set.
+//   - UpdatedAt: Unix time at which the PIN was last set or reset.
+type UPIPin struct {
+	UserID         string `json:"user_id"`
+	UpiID          string `json:"upi_id"`
+	Hash           string `json:"-"`
+	FailedAttempts int    `json:"failed_attempts"`
+	LockedUntil    int64  `json:"locked_until,omitempty"`
+	CreatedAt      int64  `json:"created_at"`
+	UpdatedAt      int64  `json:"updated_at"`
+}
+
+// UPIPinSetup is the request body for setting the PIN of a UPI ID that has none yet.
+type UPIPinSetup struct {
+	UpiID string `json:"upi_id"`
+	Pin   string `json:"pin"`
+}
+
+// UPIPinReset is the request body for replacing the PIN of a UPI ID.
+// The user re-authenticates with their account password.
+type UPIPinReset struct {
+	UpiID    string `json:"upi_id"`
+	Password string `json:"password"`
+	Pin      string `json:"pin"`
+}
@@ -11,6 +11,7 @@ package entity
 // 	- Address: 		The physical address of the user.
 // 	- Name: 		The full name of the user.
 // 	- Status: 		The current status of the user (e.g., 'active', 'inactive').
+// 	- Tier: 		The pricing tier of the user (e.g., 'standard', 'premium'); empty means 'standard'.
 // 	- Password: 	The password used by the user to authenticate.
 type User struct {
 	UserID   string `json:"user_id"`
@@ -20,5 +21,6 @@ type User struct {
 	Address  string `json:"address"`
 	Name     string `json:"name"`
 	Status   string `json:"status"`
+	Tier     string `json:"tier,omitempty"`
 	Password string `json:"password"`
 }
@@ -0,0 +1,69 @@
+package entity
+
+// WebhookSubscription represents a user's or merchant's registration for webhook callbacks.
+//
+// Fields:
+//   - ID: Unique identifier for the subscription.
+//   - OwnerID: Identifier of the user the subscription belongs to; only events for this user are sent.
+//   - URL: The endpoint the events are POSTed to.
+//   - EventTypes: The event types to deliver (e.g., 'payment_request.accepted'); empty means every type.
+//   - Secret: Key used to sign deliveries. It is only returned when the subscription is created.
+//   - Active: Whether events are still delivered to the subscription.
+//   - CreatedAt: Unix time at which the subscription was created.
+type WebhookSubscription struct {
+	ID         string   `json:"id"`
+	OwnerID    string   `json:"owner_id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret,omitempty"`
+	Active     bool     `json:"active"`
+	CreatedAt  int64    `json:"created_at"`
+}
+
+// WebhookSubscriptionRequest represents the request body for creating a webhook subscription.
+// A secret is generated when none is given.
+type WebhookSubscriptionRequest struct {
+	URL        string   `json:"url" validate:"required"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret,omitempty"`
+}
+
+// WebhookEvent is the JSON body POSTed to a subscriber.
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt int64       `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookDelivery represents one event sent to one subscription, and its delivery log.
+//
+// Fields:
+//   - ID: Unique identifier for the delivery, sent in the X-Webhook-Delivery header.
+//   - SubscriptionID: The subscription the event is delivered to.
+//   - OwnerID: Identifier of the subscription's owner.
+//   - EventType: The event type.
+//   - Payload: The JSON body that is sent.
+//   - Status: 'pending' while attempts remain, 'succeeded' or 'failed'.
+//   - Attempts: Number of delivery attempts made.
+//   - NextAttemptAt: Unix time of the next attempt while pending.
+//   - LastAttemptAt: Unix time of the latest attempt.
+//   - LastStatusCode: HTTP status returned by the latest attempt (0 if no response was received).
+//   - LastError: Error of the latest failed attempt.
+//   - CreatedAt: Unix time at which the delivery was queued.
+//   - DeliveredAt: Unix time at which the receiver acknowledged the event.
+type WebhookDelivery struct {
+	ID             string `json:"id"`
+	SubscriptionID string `json:"subscription_id"`
+	OwnerID        string `json:"owner_id"`
+	EventType      string `json:"event_type"`
+	Payload        string `json:"payload"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	NextAttemptAt  int64  `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  int64  `json:"last_attempt_at,omitempty"`
+	LastStatusCode int    `json:"last_status_code,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	CreatedAt      int64  `json:"created_at"`
+	DeliveredAt    int64  `json:"delivered_at,omitempty"`
+}
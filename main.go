@@ -1,11 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"go-transaction/config"
+	"go-transaction/consumer"
 	"go-transaction/docs"
+	"go-transaction/outbox"
 	"go-transaction/routes"
+	"go-transaction/service"
+	"go-transaction/tracing"
 
 	"github.com/rs/zerolog/log"
 	
@@ -15,11 +27,30 @@ import (
 
 // main initializes Firebase, Firestore, Swagger, and starts the API server.
 //
+// - Loads and validates the configuration (see config.Load for flags and env overrides).
+// - Sets up tracing (exporter per the tracing config).
 // - Initializes Firebase and Firestore clients.
 // - Loads Swagger configuration.
-// - Starts listening to Firestore updates in a separate goroutine.
+// - Starts the background workers (expiry sweepers, the transfer scheduler and the webhook dispatcher) in separate goroutines.
+// - Watches the config file and hot-reloads it (payment limits, review thresholds, ...) when it changes.
+// - Starts the RabbitMQ command consumer and the outbox relay alongside the server when they are enabled in the config.
 // - Initializes routes and runs the HTTP server.
+// - On SIGINT or SIGTERM, drains in-flight requests, then stops the background workers,
+//   all within the configured shutdown timeout.
 func main() {
+	// Load the configuration once; everything below reads from it
+	if _, err := config.Load(os.Args[1:]); err != nil {
+		log.Error().Err(err).Msg("Error loading configuration")
+		os.Exit(1)
+	}
+
+	// Install the tracer provider before anything creates spans
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		log.Error().Err(err).Msg("Error setting up tracing")
+		os.Exit(1)
+	}
+
 	// Initialize Firebase
 	app, err := config.InitFirebase()
 	if err != nil {
@@ -44,13 +75,6 @@ func main() {
 	docs.SwaggerInfo.Host = swagger.Host
 	docs.SwaggerInfo.BasePath = fmt.Sprintf("/%s", swagger.BasePath)
 
-	// Start listening to Firestore updates asynchronously
-
-	
-	// Initialize API routes
-	router := routes.InitRoutes()
-	router.GET(fmt.Sprintf("%s/*any", swagger.Url), ginSwagger.WrapHandler(swaggerFiles.Handler))
-
 	// Load server configuration
 	serverConfig, err := config.GetServerYamlConfig()
 	if err != nil {
@@ -58,8 +82,113 @@ func main() {
 		return
 	}
 
+	// SIGINT/SIGTERM start a graceful shutdown
+	signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Background workers share one context that is cancelled once HTTP traffic has drained
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	startWorker := func(run func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+
+	startWorker(service.RunReviewExpiry)
+	startWorker(service.RunAuthorizationExpiry)
+	startWorker(service.RunStepUpExpiry)
+	startWorker(service.RunDormancySweep)
+	startWorker(service.RunCreditAccrual)
+	startWorker(service.RunScheduler)
+	startWorker(service.RunPaymentRequestSweeper)
+	startWorker(service.RunWebhookDispatcher)
+	startWorker(func(ctx context.Context) {
+		if err := config.Watch(ctx); err != nil {
+			log.Error().Err(err).Msg("Config watcher stopped, changes to the config file need a restart")
+		}
+	})
+
+	// Run the command consumer in-process if enabled; it can also run on its own via cmd/consumer
+	rabbitMQConfig, err := config.GetRabbitMQYamlConfig()
+	if err != nil {
+		log.Error().Err(err).Msg("Error loading RabbitMQ YAML configuration")
+		return
+	}
+	if rabbitMQConfig.Enabled {
+		startWorker(func(ctx context.Context) {
+			if err := consumer.Run(ctx); err != nil {
+				log.Error().Err(err).Msg("RabbitMQ consumer failed")
+			}
+		})
+	}
+
+	// Publish transaction events recorded in the outbox
+	outboxConfig, err := config.GetOutboxYamlConfig()
+	if err != nil {
+		log.Error().Err(err).Msg("Error loading outbox YAML configuration")
+		return
+	}
+	if outboxConfig.Enabled {
+		startWorker(func(ctx context.Context) {
+			if err := outbox.Run(ctx); err != nil {
+				log.Error().Err(err).Msg("Outbox relay failed")
+			}
+		})
+	}
+
+	// Initialize API routes
+	router := routes.InitRoutes()
+	router.GET(fmt.Sprintf("%s/*any", swagger.Url), ginSwagger.WrapHandler(swaggerFiles.Handler))
+
 	log.Info().Msgf("Swagger UI available at: http://localhost:%d/%s/index.html", serverConfig.Port, swagger.Url)
 
 	// Start the HTTP server
-	router.Run(fmt.Sprintf(":%d", serverConfig.Port))
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", serverConfig.Port),
+		Handler: router,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("HTTP server failed")
+			stop()
+		}
+	}()
+
+	<-signalCtx.Done()
+	log.Info().Msg("Shutting down")
+
+	shutdownTimeout := serverConfig.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 30 * time.Second
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Fail readiness first, then stop accepting connections and wait for in-flight requests
+	service.BeginShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("HTTP server did not drain before the shutdown timeout")
+	}
+
+	stopWorkers()
+	drained := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-shutdownCtx.Done():
+		log.Error().Msg("Background workers did not stop before the shutdown timeout")
+	}
+
+	// Flush the spans of the drained requests and workers
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Failed to flush traces")
+	}
+	log.Info().Msg("Shutdown complete")
 }
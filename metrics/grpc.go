@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// FirestoreOptions instrument a Firestore client so the latency of every RPC it makes (document gets,
+// queries, commits, ...) is recorded. They are passed to firebase.NewApp, which hands them on to the client.
+func FirestoreOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(unaryInterceptor)),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(streamInterceptor)),
+	}
+}
+
+func unaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	observeFirestore(method, start, err)
+	return err
+}
+
+// streamInterceptor times a streaming RPC until the server ends the stream.
+// Long-lived streams (Listen, Write) are not timed.
+func streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	start := time.Now()
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		observeFirestore(method, start, err)
+		return nil, err
+	}
+
+	if desc.ClientStreams {
+		return stream, nil
+	}
+	return &timedStream{ClientStream: stream, method: method, start: start}, nil
+}
+
+// timedStream records the duration of a server-streaming RPC when its last message has been received.
+type timedStream struct {
+	grpc.ClientStream
+	method string
+	start  time.Time
+	once   sync.Once
+}
+
+func (s *timedStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.once.Do(func() {
+			if errors.Is(err, io.EOF) {
+				observeFirestore(s.method, s.start, nil)
+			} else {
+				observeFirestore(s.method, s.start, err)
+			}
+		})
+	}
+	return err
+}
+
+func observeFirestore(method string, start time.Time, err error) {
+	firestoreDuration.WithLabelValues(path.Base(method), status.Code(err).String()).
+		Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,108 @@
+// Package metrics defines the Prometheus metrics of the service and the helpers that record them.
+// Everything is registered on the default registry and exposed by Handler.
+package metrics
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "transaction"
+
+var (
+	transfers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_total",
+		Help:      "Transfers by payment method, receiving method and outcome (success, failed, held, rejected, expired).",
+	}, []string{"payment_method", "receiving_method", "outcome"})
+
+	transferredAmount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transferred_amount_total",
+		Help:      "Sum of the amounts of successful transfers, by payment method.",
+	}, []string{"payment_method"})
+
+	paymentRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payment_requests_total",
+		Help:      "Payment request lifecycle events (created, accepted, declined, cancelled, failed, expired, reminded).",
+	}, []string{"event"})
+
+	logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by outcome (success, unknown_user, invalid_password, locked, error).",
+	}, []string{"outcome"})
+
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by the rate limiter, by route group and bucket (ip, user).",
+	}, []string{"group", "bucket"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	firestoreDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "firestore_call_duration_seconds",
+		Help:      "Latency of Firestore RPCs by method and gRPC status code.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method", "code"})
+
+	queueMessages = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rabbitmq_queue_messages",
+		Help:      "Messages ready for delivery in a RabbitMQ queue, i.e. how far the consumer is behind.",
+	}, []string{"queue"})
+
+	queueConsumers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rabbitmq_queue_consumers",
+		Help:      "Consumers attached to a RabbitMQ queue.",
+	}, []string{"queue"})
+)
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RecordTransfer counts a transfer with its outcome; the amount is added to the total only for successful transfers.
+func RecordTransfer(paymentMethod, receivingMethod, outcome string, amount float64) {
+	paymentMethod, receivingMethod = strings.ToUpper(paymentMethod), strings.ToUpper(receivingMethod)
+
+	transfers.WithLabelValues(paymentMethod, receivingMethod, outcome).Inc()
+	if outcome == "success" {
+		transferredAmount.WithLabelValues(paymentMethod).Add(amount)
+	}
+}
+
+// RecordPaymentRequest counts a payment request lifecycle event.
+func RecordPaymentRequest(event string) {
+	paymentRequests.WithLabelValues(event).Inc()
+}
+
+// RecordLogin counts a login attempt.
+func RecordLogin(outcome string) {
+	logins.WithLabelValues(outcome).Inc()
+}
+
+// RecordRateLimited counts a request rejected by the rate limiter.
+func RecordRateLimited(group, bucket string) {
+	rateLimited.WithLabelValues(group, bucket).Inc()
+}
+
+// SetQueueStats records the backlog and consumer count of a RabbitMQ queue.
+func SetQueueStats(queue string, messages, consumers int) {
+	queueMessages.WithLabelValues(queue).Set(float64(messages))
+	queueConsumers.WithLabelValues(queue).Set(float64(consumers))
+}
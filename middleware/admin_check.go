@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"go-transaction/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminCheck is a middleware function that restricts a route to users with the ADMIN role.
+//
+// It must run after AuthCheck, which guarantees a well-formed "Bearer <token>" Authorization header.
+// The role claim is read from the token payload; any role other than ADMIN is rejected with a 403 Forbidden status.
+func AdminCheck() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Extract the token from the Authorization header
+		token := c.GetHeader("Authorization")[len("Bearer "):]
+
+		payload, err := utils.GetPayloadFromJWT(token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+
+		// Only administrators may proceed
+		role, ok := payload["role"].(string)
+		if !ok || !strings.EqualFold(role, "ADMIN") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
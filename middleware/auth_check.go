@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
 )
 
 // AuthCheck is a middleware function that validates the Authorization header in the incoming request.
@@ -15,7 +16,8 @@ import (
 // 
 // If the Authorization header is missing or invalid, or the token is invalid, it responds with a 401 Unauthorized status.
 // 
-// If the token is valid, it allows the request to proceed by calling c.Next().
+// If the token is valid, the user ID is added to the request context and its logger,
+// and it allows the request to proceed by calling c.Next().
 func AuthCheck() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Retrieve the Authorization header from the request
@@ -39,13 +41,21 @@ func AuthCheck() gin.HandlerFunc {
 		token := authHeader[len("Bearer "):]
 		
 		// Validate the token using the utils.ValidateToken function
-		_, err := utils.ValidateToken(token)
+		parsed, err := utils.ValidateToken(token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
+		// Tag the request context (and its logger) with the authenticated user
+		if claims, ok := parsed.Claims.(jwt.MapClaims); ok {
+			if uid, ok := claims["uid"].(string); ok {
+				ctx := utils.WithLogField(utils.WithUserID(c.Request.Context(), uid), "user_id", uid)
+				c.Request = c.Request.WithContext(ctx)
+			}
+		}
+
 		// Proceed to the next handler if the token is valid
 		c.Next()
 	}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"go-transaction/utils"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the correlation ID of a request in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits caller-supplied IDs to something safe to log and echo back.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID is a middleware function that gives every request a correlation ID.
+//
+// The caller's X-Request-ID header is reused when it is well formed; otherwise a random ID is generated.
+// The ID is echoed in the response header and stored in the request context together with a logger
+// carrying it (and the trace ID, when the request is traced), so everything logged through
+// log.Ctx(ctx) while serving the request can be tied together.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		ctx := c.Request.Context()
+		logContext := log.With().Str("request_id", requestID)
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			logContext = logContext.Str("trace_id", spanContext.TraceID().String())
+		}
+		logger := logContext.Logger()
+
+		ctx = utils.WithRequestID(logger.WithContext(ctx), requestID)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Error().Err(err).Msg("Failed to generate request ID")
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"fmt"
+	"go-transaction/config"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityHeaders is a middleware function that adds the configured security headers to every response:
+// Strict-Transport-Security, X-Content-Type-Options and X-Frame-Options.
+//
+// The configuration is read on every request, so header changes follow configuration reloads.
+func SecurityHeaders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		securityConfig, err := config.GetSecurityYamlConfig()
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		if securityConfig.HSTSMaxAge > 0 {
+			hsts := fmt.Sprintf("max-age=%d", int(securityConfig.HSTSMaxAge.Seconds()))
+			if securityConfig.HSTSSubdomains {
+				hsts += "; includeSubDomains"
+			}
+			c.Header("Strict-Transport-Security", hsts)
+		}
+		if securityConfig.NoSniff {
+			c.Header("X-Content-Type-Options", "nosniff")
+		}
+		if securityConfig.FrameOptions != "" {
+			c.Header("X-Frame-Options", strings.ToUpper(securityConfig.FrameOptions))
+		}
+
+		c.Next()
+	}
+}
+
+// NoStore is a middleware function that stops browsers and proxies from caching the response,
+// for routes that move money or return transaction details. It can be turned off with security.nostore.
+func NoStore() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if securityConfig, err := config.GetSecurityYamlConfig(); err == nil && securityConfig.NoStore {
+			c.Header("Cache-Control", "no-store")
+			c.Header("Pragma", "no-cache")
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,122 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-transaction/entity"
+	"go-transaction/tracing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// AMQPPublisher publishes events to a durable RabbitMQ topic exchange using publisher confirms.
+// The routing key is the event type (e.g. 'transaction.succeeded'). It connects lazily and
+// reconnects on the next Publish after the connection is lost. It is not safe for concurrent use.
+type AMQPPublisher struct {
+	url      string
+	exchange string
+	conn     *amqp.Connection
+	ch       *amqp.Channel
+}
+
+// NewAMQPPublisher returns a publisher for the given broker URL and exchange.
+func NewAMQPPublisher(url, exchange string) *AMQPPublisher {
+	return &AMQPPublisher{url: url, exchange: exchange}
+}
+
+// Publish sends an event and waits for the broker to confirm it.
+// The publish span's trace context is added to the message headers so consumers can continue the trace.
+func (p *AMQPPublisher) Publish(ctx context.Context, event entity.TransactionEvent) (err error) {
+	ctx, span := tracing.Start(ctx, "outbox.publish "+event.Type,
+		attribute.String("messaging.system", "rabbitmq"),
+		attribute.String("messaging.destination.name", p.exchange),
+		attribute.String("messaging.message.id", event.ID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if err := p.connect(); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+
+	headers := amqp.Table{
+		"schema_version": int32(event.SchemaVersion),
+		"transaction_id": event.TransactionID,
+		"sequence":       event.Sequence,
+	}
+	tracing.InjectAMQP(ctx, headers)
+
+	confirmation, err := p.ch.PublishWithDeferredConfirmWithContext(ctx, p.exchange, event.Type, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    event.ID,
+		Type:         event.Type,
+		Timestamp:    time.Unix(event.OccurredAt, 0),
+		Headers:      headers,
+		Body:         body,
+	})
+	if err != nil {
+		p.Close()
+		return fmt.Errorf("failed to publish event: %v", err)
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to confirm event: %v", err)
+	}
+	if !acked {
+		return fmt.Errorf("broker rejected event %s", event.ID)
+	}
+	return nil
+}
+
+// Close closes the channel and connection, if open.
+func (p *AMQPPublisher) Close() {
+	if p.ch != nil {
+		p.ch.Close()
+		p.ch = nil
+	}
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+	}
+}
+
+// connect opens the connection and confirm-mode channel and declares the exchange, unless already open.
+func (p *AMQPPublisher) connect() error {
+	if p.ch != nil && !p.ch.IsClosed() {
+		return nil
+	}
+	p.Close()
+
+	conn, err := amqp.Dial(p.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to RabbitMQ: %v", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to open a channel: %v", err)
+	}
+
+	if err := ch.ExchangeDeclare(p.exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to declare exchange: %v", err)
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to enable publisher confirms: %v", err)
+	}
+
+	p.conn, p.ch = conn, ch
+	return nil
+}
@@ -0,0 +1,140 @@
+// Package outbox relays the transaction events recorded in the Firestore outbox to RabbitMQ.
+//
+// Events are written to the outbox by the service layer in the same Firestore transaction as the
+// state change they describe. The relay publishes them with at-least-once delivery: an event is only
+// marked published once the broker has confirmed it, so consumers must de-duplicate on the event ID.
+// Events of one transaction are published in sequence order; run a single relay to keep that guarantee.
+package outbox
+
+import (
+	"context"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Publisher publishes a single event.
+// Implementations must only return nil once the broker has accepted the event.
+type Publisher interface {
+	Publish(ctx context.Context, event entity.TransactionEvent) error
+}
+
+// Store gives the relay access to the outbox.
+type Store interface {
+	// Pending returns every event that has not been published yet.
+	Pending(ctx context.Context) ([]entity.TransactionEvent, error)
+	// MarkPublished records that an event was accepted by the broker.
+	MarkPublished(ctx context.Context, eventID string) error
+}
+
+// Relay moves events from a Store to a Publisher.
+type Relay struct {
+	Store     Store
+	Publisher Publisher
+}
+
+// RelayOnce publishes all pending events and returns how many were published.
+//
+// Events are published per transaction in sequence order. When an event cannot be published
+// (or marked), the remaining events of that transaction are left for the next pass so that
+// a later event never overtakes an earlier one.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	events, err := r.Store.Pending(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].OccurredAt != events[j].OccurredAt {
+			return events[i].OccurredAt < events[j].OccurredAt
+		}
+		if events[i].TransactionID != events[j].TransactionID {
+			return events[i].TransactionID < events[j].TransactionID
+		}
+		return events[i].Sequence < events[j].Sequence
+	})
+
+	blocked := make(map[string]bool)
+	published := 0
+	for _, event := range events {
+		if ctx.Err() != nil {
+			return published, ctx.Err()
+		}
+		if blocked[event.TransactionID] {
+			continue
+		}
+
+		if err := r.Publisher.Publish(ctx, event); err != nil {
+			log.Error().Err(err).Str("event_id", event.ID).Msg("Failed to publish event")
+			blocked[event.TransactionID] = true
+			continue
+		}
+
+		// A failure here means the event is published again on the next pass
+		if err := r.Store.MarkPublished(ctx, event.ID); err != nil {
+			log.Error().Err(err).Str("event_id", event.ID).Msg("Failed to mark event as published")
+			blocked[event.TransactionID] = true
+			continue
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+// Run calls RelayOnce every interval until the context is cancelled.
+// A pass in progress completes, so no event is left published but unmarked.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			published, err := r.RelayOnce(context.WithoutCancel(ctx))
+			if err != nil {
+				log.Error().Err(err).Msg("Outbox relay pass failed")
+			}
+			if published > 0 {
+				log.Info().Int("published", published).Msg("Published transaction events")
+			}
+		}
+	}
+}
+
+// Run starts the relay configured by the outbox and rabbitmq config sections and
+// runs it until the context is cancelled.
+func Run(ctx context.Context) error {
+	outboxConfig, err := config.GetOutboxYamlConfig()
+	if err != nil {
+		return err
+	}
+
+	rabbitMQConfig, err := config.GetRabbitMQYamlConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	publisher := NewAMQPPublisher(rabbitMQConfig.URL, outboxConfig.Exchange)
+	defer publisher.Close()
+
+	interval := outboxConfig.PollInterval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	relay := &Relay{Store: &FirestoreStore{Client: client}, Publisher: publisher}
+	relay.Run(ctx, interval)
+	return nil
+}
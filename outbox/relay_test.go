@@ -0,0 +1,161 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-transaction/entity"
+	"reflect"
+	"testing"
+)
+
+// memoryStore is an in-memory Store standing in for the Firestore outbox.
+type memoryStore struct {
+	events   []entity.TransactionEvent
+	markErrs map[string]error
+}
+
+func (s *memoryStore) Pending(ctx context.Context) ([]entity.TransactionEvent, error) {
+	var pending []entity.TransactionEvent
+	for _, event := range s.events {
+		if !event.Published {
+			pending = append(pending, event)
+		}
+	}
+	return pending, nil
+}
+
+func (s *memoryStore) MarkPublished(ctx context.Context, eventID string) error {
+	if err := s.markErrs[eventID]; err != nil {
+		return err
+	}
+	for i := range s.events {
+		if s.events[i].ID == eventID {
+			s.events[i].Published = true
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown event %s", eventID)
+}
+
+// fakeBroker is a Publisher standing in for RabbitMQ. It confirms every event except those in failures.
+type fakeBroker struct {
+	published []string
+	failures  map[string]error
+}
+
+func (b *fakeBroker) Publish(ctx context.Context, event entity.TransactionEvent) error {
+	if err := b.failures[event.ID]; err != nil {
+		return err
+	}
+	b.published = append(b.published, event.ID)
+	return nil
+}
+
+func event(transactionID string, sequence, occurredAt int64) entity.TransactionEvent {
+	return entity.TransactionEvent{
+		ID:            fmt.Sprintf("%s-%d", transactionID, sequence),
+		TransactionID: transactionID,
+		Sequence:      sequence,
+		OccurredAt:    occurredAt,
+	}
+}
+
+func TestRelayOncePublishesEachTransactionInSequenceOrder(t *testing.T) {
+	store := &memoryStore{events: []entity.TransactionEvent{
+		event("b", 2, 200),
+		event("a", 3, 300),
+		event("a", 1, 100),
+		event("b", 1, 100),
+		event("a", 2, 100),
+	}}
+	broker := &fakeBroker{}
+	relay := &Relay{Store: store, Publisher: broker}
+
+	published, err := relay.RelayOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RelayOnce returned %v", err)
+	}
+	if published != 5 {
+		t.Fatalf("published %d events, want 5", published)
+	}
+
+	last := map[string]int64{}
+	for _, id := range broker.published {
+		for _, e := range store.events {
+			if e.ID != id {
+				continue
+			}
+			if e.Sequence <= last[e.TransactionID] {
+				t.Fatalf("event %s published after sequence %d of its transaction: %v", id, last[e.TransactionID], broker.published)
+			}
+			last[e.TransactionID] = e.Sequence
+		}
+	}
+}
+
+func TestRelayOnceBlocksTransactionAfterFailedPublish(t *testing.T) {
+	store := &memoryStore{events: []entity.TransactionEvent{
+		event("a", 1, 100),
+		event("a", 2, 100),
+		event("a", 3, 100),
+		event("b", 1, 100),
+	}}
+	broker := &fakeBroker{failures: map[string]error{"a-2": errors.New("broker unavailable")}}
+	relay := &Relay{Store: store, Publisher: broker}
+
+	published, err := relay.RelayOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RelayOnce returned %v", err)
+	}
+	if want := []string{"a-1", "b-1"}; !reflect.DeepEqual(broker.published, want) {
+		t.Fatalf("first pass published %v, want %v", broker.published, want)
+	}
+	if published != 2 {
+		t.Fatalf("first pass published %d events, want 2", published)
+	}
+
+	// Once the broker recovers, the blocked events go out in order
+	broker.failures = nil
+	if _, err := relay.RelayOnce(context.Background()); err != nil {
+		t.Fatalf("RelayOnce returned %v", err)
+	}
+	if want := []string{"a-1", "b-1", "a-2", "a-3"}; !reflect.DeepEqual(broker.published, want) {
+		t.Fatalf("published %v, want %v", broker.published, want)
+	}
+}
+
+func TestRelayOnceBlocksTransactionWhenMarkFails(t *testing.T) {
+	store := &memoryStore{
+		events:   []entity.TransactionEvent{event("a", 1, 100), event("a", 2, 100)},
+		markErrs: map[string]error{"a-1": errors.New("firestore unavailable")},
+	}
+	broker := &fakeBroker{}
+	relay := &Relay{Store: store, Publisher: broker}
+
+	published, err := relay.RelayOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RelayOnce returned %v", err)
+	}
+	if published != 0 {
+		t.Fatalf("published %d events, want 0", published)
+	}
+	if want := []string{"a-1"}; !reflect.DeepEqual(broker.published, want) {
+		t.Fatalf("published %v, want %v", broker.published, want)
+	}
+}
+
+func TestRelayOnceDoesNotRepublishConfirmedEvents(t *testing.T) {
+	store := &memoryStore{events: []entity.TransactionEvent{event("a", 1, 100), event("a", 2, 100)}}
+	broker := &fakeBroker{}
+	relay := &Relay{Store: store, Publisher: broker}
+
+	for pass := 0; pass < 3; pass++ {
+		if _, err := relay.RelayOnce(context.Background()); err != nil {
+			t.Fatalf("RelayOnce returned %v", err)
+		}
+	}
+	if want := []string{"a-1", "a-2"}; !reflect.DeepEqual(broker.published, want) {
+		t.Fatalf("published %v, want %v", broker.published, want)
+	}
+}
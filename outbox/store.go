@@ -0,0 +1,54 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/entity"
+	"go-transaction/service"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// FirestoreStore is the Store backed by the Firestore outbox collection.
+type FirestoreStore struct {
+	Client *firestore.Client
+}
+
+// Pending returns every event that has not been published yet.
+func (s *FirestoreStore) Pending(ctx context.Context) ([]entity.TransactionEvent, error) {
+	iter := s.Client.Collection(service.OutboxCollection).Where("Published", "==", false).Documents(ctx)
+	defer iter.Stop()
+
+	var events []entity.TransactionEvent
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch outbox events: %v", err)
+		}
+
+		var event entity.TransactionEvent
+		if err := docSnap.DataTo(&event); err != nil {
+			return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// MarkPublished records that an event was accepted by the broker.
+func (s *FirestoreStore) MarkPublished(ctx context.Context, eventID string) error {
+	_, err := s.Client.Collection(service.OutboxCollection).Doc(eventID).Update(ctx, []firestore.Update{
+		{Path: "Published", Value: true},
+		{Path: "PublishedAt", Value: time.Now().Unix()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark event %s as published: %v", eventID, err)
+	}
+	return nil
+}
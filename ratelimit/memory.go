@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"go-transaction/entity"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often a MemoryStore drops buckets that have refilled and lockouts that have expired.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	period time.Duration
+	last   time.Time
+}
+
+// MemoryStore is a Store that keeps its state in the process.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lockouts  map[string]Lockout
+	lastSweep time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  map[string]*bucket{},
+		lockouts: map[string]Lockout{},
+	}
+}
+
+// Take implements Store.
+func (s *MemoryStore) Take(key string, limit entity.RateLimit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	size := float64(limit.Requests)
+	rate := size / float64(limit.Period) // tokens per nanosecond
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: size, last: now}
+		s.buckets[key] = b
+	}
+	b.period = limit.Period
+	b.tokens = math.Min(size, b.tokens+float64(now.Sub(b.last))*rate)
+	b.last = now
+
+	result := Result{Limit: limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - b.tokens) / rate))
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration(math.Ceil((size - b.tokens) / rate))
+	return result, nil
+}
+
+// UpdateLockout implements Store.
+func (s *MemoryStore) UpdateLockout(key string, now time.Time, update func(*Lockout)) (Lockout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	lockout := s.lockouts[key]
+	if !lockout.Expires.After(now) {
+		lockout = Lockout{}
+	}
+
+	update(&lockout)
+	if lockout == (Lockout{}) {
+		delete(s.lockouts, key)
+	} else {
+		s.lockouts[key] = lockout
+	}
+	return lockout, nil
+}
+
+// sweep drops state that no longer affects any request. The caller must hold s.mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.Sub(b.last) >= b.period {
+			delete(s.buckets, key)
+		}
+	}
+	for key, lockout := range s.lockouts {
+		if !lockout.Expires.After(now) {
+			delete(s.lockouts, key)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"go-transaction/entity"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	limit := entity.RateLimit{Requests: 3, Period: time.Minute}
+	start := time.Unix(1700000000, 0)
+
+	// Each step takes a token at the given offset from the first request
+	tests := []struct {
+		name          string
+		at            time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}{
+		{"first request", 0, true, 2, 0},
+		{"second request", 0, true, 1, 0},
+		{"last token", 0, true, 0, 0},
+		{"bucket empty", time.Second, false, 0, 19 * time.Second},
+		{"still empty", 10 * time.Second, false, 0, 10 * time.Second},
+		{"one token refilled", 20 * time.Second, true, 0, 0},
+		{"refill capped at the bucket size", 10 * time.Minute, true, 2, 0},
+	}
+
+	store := NewMemoryStore()
+	for _, tt := range tests {
+		result, err := store.Take("ip:203.0.113.7", limit, start.Add(tt.at))
+		if err != nil {
+			t.Fatalf("%s: Take returned %v", tt.name, err)
+		}
+		if result.Allowed != tt.wantAllowed || result.Remaining != tt.wantRemaining || result.RetryAfter != tt.wantRetry {
+			t.Fatalf("%s: Take = %+v, want allowed %v, remaining %d, retry after %v",
+				tt.name, result, tt.wantAllowed, tt.wantRemaining, tt.wantRetry)
+		}
+		if result.Limit != limit.Requests {
+			t.Fatalf("%s: limit is %d, want %d", tt.name, result.Limit, limit.Requests)
+		}
+	}
+}
+
+func TestMemoryStoreBucketsAreIndependent(t *testing.T) {
+	limit := entity.RateLimit{Requests: 1, Period: time.Hour}
+	now := time.Unix(1700000000, 0)
+	store := NewMemoryStore()
+
+	if result, _ := store.Take("user:alice", limit, now); !result.Allowed {
+		t.Fatal("first request for alice was refused")
+	}
+	if result, _ := store.Take("user:alice", limit, now); result.Allowed {
+		t.Fatal("second request for alice was allowed")
+	}
+	if result, _ := store.Take("user:bob", limit, now); !result.Allowed {
+		t.Fatal("bob was limited by alice's requests")
+	}
+}
+
+func TestMemoryStoreLockoutExpires(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := NewMemoryStore()
+
+	_, err := store.UpdateLockout("login:alice", now, func(lockout *Lockout) {
+		lockout.Failures = 3
+		lockout.Expires = now.Add(time.Minute)
+	})
+	if err != nil {
+		t.Fatalf("UpdateLockout returned %v", err)
+	}
+
+	read := func(at time.Time) Lockout {
+		lockout, err := store.UpdateLockout("login:alice", at, func(*Lockout) {})
+		if err != nil {
+			t.Fatalf("UpdateLockout returned %v", err)
+		}
+		return lockout
+	}
+	if lockout := read(now.Add(30 * time.Second)); lockout.Failures != 3 {
+		t.Fatalf("lockout before it expired is %+v, want 3 failures", lockout)
+	}
+	if lockout := read(now.Add(time.Minute)); lockout != (Lockout{}) {
+		t.Fatalf("lockout after it expired is %+v, want none", lockout)
+	}
+}
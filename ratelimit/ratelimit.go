@@ -0,0 +1,129 @@
+// Package ratelimit implements the token buckets behind the rate-limiting middleware and the
+// lockout applied to accounts after repeated failed logins.
+//
+// State is kept in a Store. The default MemoryStore is local to the process, so with several
+// instances each one enforces the limits on its own share of the traffic; a shared Store can
+// be installed with SetStore to enforce them across instances.
+package ratelimit
+
+import (
+	"go-transaction/config"
+	"go-transaction/entity"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed bool
+	// Limit is the size of the bucket and Remaining the whole tokens left in it.
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token is available; zero if the request was allowed.
+	RetryAfter time.Duration
+}
+
+// Lockout is the failed-login state of an account.
+type Lockout struct {
+	Failures    int
+	LockedUntil time.Time
+	// Expires is when the state can be forgotten: the end of the failure window or of the lockout, whichever is later.
+	Expires time.Time
+}
+
+// Store keeps token buckets and lockouts. Implementations must be safe for concurrent use and
+// apply each call atomically.
+type Store interface {
+	// Take refills the bucket under key for the time elapsed since it was last used and removes one token if it can.
+	Take(key string, limit entity.RateLimit, now time.Time) (Result, error)
+
+	// UpdateLockout passes the lockout state under key (the zero value if there is none, or it has expired)
+	// to update and stores the result. A zero result removes the state.
+	UpdateLockout(key string, now time.Time, update func(*Lockout)) (Lockout, error)
+}
+
+var store atomic.Value
+
+func init() {
+	store.Store(storeHolder{NewMemoryStore()})
+}
+
+// storeHolder gives every Store the same concrete type, as atomic.Value requires.
+type storeHolder struct{ Store }
+
+// SetStore replaces the store used by Allow and the login lockout.
+func SetStore(s Store) {
+	store.Store(storeHolder{s})
+}
+
+func currentStore() Store {
+	return store.Load().(storeHolder).Store
+}
+
+// Allow takes a token from the bucket under key.
+func Allow(key string, limit entity.RateLimit) (Result, error) {
+	return currentStore().Take(key, limit, time.Now())
+}
+
+// LoginLockedFor returns how much longer the account under key is locked out, or zero if it is not.
+func LoginLockedFor(key string) (time.Duration, error) {
+	now := time.Now()
+	lockout, err := currentStore().UpdateLockout(key, now, func(*Lockout) {})
+	if err != nil {
+		return 0, err
+	}
+	if lockout.LockedUntil.After(now) {
+		return lockout.LockedUntil.Sub(now), nil
+	}
+	return 0, nil
+}
+
+// LoginFailed records a failed login for the account under key. Once the failures reach the configured
+// threshold the account is locked for the base delay, doubling with every further failure up to the
+// maximum delay. It returns the length of the lockout, or zero if the account is not locked.
+func LoginFailed(key string) (time.Duration, error) {
+	rateLimitConfig, err := config.GetRateLimitYamlConfig()
+	if err != nil {
+		return 0, err
+	}
+	lockoutConfig := rateLimitConfig.Lockout
+	if lockoutConfig.Threshold == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	var delay time.Duration
+	_, err = currentStore().UpdateLockout(key, now, func(lockout *Lockout) {
+		lockout.Failures++
+		lockout.Expires = now.Add(lockoutConfig.Window)
+
+		if excess := lockout.Failures - lockoutConfig.Threshold; excess >= 0 {
+			delay = backoff(lockoutConfig, excess)
+			lockout.LockedUntil = now.Add(delay)
+			if lockout.LockedUntil.After(lockout.Expires) {
+				lockout.Expires = lockout.LockedUntil
+			}
+		}
+	})
+	return delay, err
+}
+
+// LoginSucceeded clears the failed logins of the account under key.
+func LoginSucceeded(key string) error {
+	_, err := currentStore().UpdateLockout(key, time.Now(), func(lockout *Lockout) {
+		*lockout = Lockout{}
+	})
+	return err
+}
+
+// backoff returns the lockout after the given number of failures past the threshold.
+func backoff(lockoutConfig entity.LockoutConfig, excess int) time.Duration {
+	delay := float64(lockoutConfig.BaseDelay) * math.Pow(2, float64(excess))
+	if lockoutConfig.MaxDelay > 0 && delay > float64(lockoutConfig.MaxDelay) {
+		return lockoutConfig.MaxDelay
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// Tests run from the package directory, so the YAML file is named explicitly
+	if _, err := config.Load([]string{
+		"-project", "prod",
+		"-config", "../config/config.prod.yaml",
+		"-set", "secrets.jwtkey=test-jwt-key",
+		"-set", "secrets.quotekey=test-quote-key",
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to load test configuration: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+func TestBackoff(t *testing.T) {
+	lockoutConfig := entity.LockoutConfig{BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute}
+
+	tests := []struct {
+		excess int
+		want   time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 5 * time.Minute},
+		{20, 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(lockoutConfig, tt.excess); got != tt.want {
+			t.Errorf("backoff after %d failures past the threshold is %v, want %v", tt.excess, got, tt.want)
+		}
+	}
+
+	lockoutConfig.MaxDelay = 0
+	if got := backoff(lockoutConfig, 6); got != 32*time.Minute {
+		t.Errorf("uncapped backoff is %v, want %v", got, 32*time.Minute)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	SetStore(NewMemoryStore())
+	defer SetStore(NewMemoryStore())
+
+	rateLimitConfig, err := config.GetRateLimitYamlConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lockoutConfig := rateLimitConfig.Lockout
+	if lockoutConfig.Threshold == 0 {
+		t.Skip("login lockout is disabled in the test configuration")
+	}
+
+	for failure := 1; failure < lockoutConfig.Threshold; failure++ {
+		if lockedFor, err := LoginFailed("login:alice"); err != nil || lockedFor != 0 {
+			t.Fatalf("failure %d locked the account for %v (%v), want no lockout below the threshold", failure, lockedFor, err)
+		}
+	}
+	if retryAfter, _ := LoginLockedFor("login:alice"); retryAfter != 0 {
+		t.Fatalf("account is locked for %v below the threshold", retryAfter)
+	}
+
+	lockedFor, err := LoginFailed("login:alice")
+	if err != nil || lockedFor != lockoutConfig.BaseDelay {
+		t.Fatalf("failure at the threshold locked the account for %v (%v), want %v", lockedFor, err, lockoutConfig.BaseDelay)
+	}
+	if retryAfter, _ := LoginLockedFor("login:alice"); retryAfter <= 0 || retryAfter > lockoutConfig.BaseDelay {
+		t.Fatalf("locked account reports %v left, want up to %v", retryAfter, lockoutConfig.BaseDelay)
+	}
+	if lockedFor, _ := LoginFailed("login:alice"); lockedFor != backoff(lockoutConfig, 1) {
+		t.Fatalf("failure past the threshold locked the account for %v, want %v", lockedFor, backoff(lockoutConfig, 1))
+	}
+
+	if retryAfter, _ := LoginLockedFor("login:bob"); retryAfter != 0 {
+		t.Fatalf("bob is locked for %v by alice's failures", retryAfter)
+	}
+
+	if err := LoginSucceeded("login:alice"); err != nil {
+		t.Fatalf("LoginSucceeded returned %v", err)
+	}
+	if retryAfter, _ := LoginLockedFor("login:alice"); retryAfter != 0 {
+		t.Fatalf("account is still locked for %v after a successful login", retryAfter)
+	}
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"go-transaction/entity"
+	"go-transaction/utils"
+
+	"github.com/rs/zerolog/log"
+
+	"cloud.google.com/go/firestore"
+)
+
+// AccountQuery returns the query that matches the BankDetails document for the given account number.
+//
+// Parameters:
+//   - client: Firestore client to interact with the database.
+//   - accNo: The account number to look up.
+func AccountQuery(client *firestore.Client, accNo string) firestore.Query {
+	return client.Collection("BankDetails").Where("account_number", "==", accNo).Limit(1)
+}
+
+// GetAccountInTx fetches the BankDetails document for the given account number inside a Firestore transaction.
+//
+// Parameters:
+//   - tx: The running Firestore transaction.
+//   - client: Firestore client to interact with the database.
+//   - accNo: The account number to look up.
+//
+// Returns:
+//   - The matching document snapshot.
+//   - An error if no matching document is found or if an issue occurs during retrieval.
+func GetAccountInTx(tx *firestore.Transaction, client *firestore.Client, accNo string) (*firestore.DocumentSnapshot, error) {
+	docs, err := tx.Documents(AccountQuery(client, accNo)).GetAll()
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Error fetching account document")
+		return nil, fmt.Errorf("failed to fetch account document: %v", err)
+	}
+	if len(docs) == 0 {
+		log.Error().
+			Str("accNo", utils.MaskAccountNumber(accNo)).
+			Msg("No matching document found for account")
+		return nil, fmt.Errorf("no matching document found for account number: %s", utils.MaskAccountNumber(accNo))
+	}
+	return docs[0], nil
+}
+
+// GetAccount fetches the BankDetails document for the given account number.
+//
+// Parameters:
+//   - ctx: The context for Firestore operations.
+//   - client: Firestore client to interact with the database.
+//   - accNo: The account number to look up.
+//
+// Returns:
+//   - The matching document snapshot.
+//   - An error if no matching document is found or if an issue occurs during retrieval.
+func GetAccount(ctx context.Context, client *firestore.Client, accNo string) (*firestore.DocumentSnapshot, error) {
+	docs, err := AccountQuery(client, accNo).Documents(ctx).GetAll()
+	if err != nil {
+		log.Ctx(ctx).Error().
+			Err(err).
+			Msg("Error fetching account document")
+		return nil, fmt.Errorf("failed to fetch account document: %v", err)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("no matching document found for account number: %s", utils.MaskAccountNumber(accNo))
+	}
+	return docs[0], nil
+}
+
+// GetAccountHolderName returns the name of the holder of the given account, as stored in the
+// holder_name field of its BankDetails document. It is empty if the document has no name.
+func GetAccountHolderName(ctx context.Context, client *firestore.Client, accNo string) (string, error) {
+	docSnap, err := GetAccount(ctx, client, accNo)
+	if err != nil {
+		return "", err
+	}
+
+	holderName, _ := docSnap.Data()["holder_name"].(string)
+	return holderName, nil
+}
+
+// GetAccountHolderID returns the user ID of the holder of the given account, as stored in the
+// holder_id field of its BankDetails document. It is empty if the document has no holder.
+func GetAccountHolderID(ctx context.Context, client *firestore.Client, accNo string) (string, error) {
+	docSnap, err := GetAccount(ctx, client, accNo)
+	if err != nil {
+		return "", err
+	}
+
+	holderID, _ := docSnap.Data()["holder_id"].(string)
+	return holderID, nil
+}
+
+// AccountStatus returns the status field of a BankDetails document; accounts without one are active.
+func AccountStatus(data map[string]interface{}) string {
+	if status, _ := data["status"].(string); status != "" {
+		return status
+	}
+	return entity.AccountActive
+}
+
+// FloatField reads a numeric field from Firestore document data.
+//
+// Firestore returns whole numbers as int64, so both int64 and float64 values are accepted.
+// A missing or non-numeric field is treated as 0.
+func FloatField(data map[string]interface{}, key string) float64 {
+	switch v := data[key].(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	default:
+		return 0
+	}
+}
+
+// BalanceUpdates returns the updates that set an account's balance. For credit accounts (those with a
+// credit_limit) the credit_utilized field is kept in step with the balance.
+//
+// Parameters:
+//   - data: The account's BankDetails document data, as read before the change.
+//   - balance: The new balance.
+func BalanceUpdates(data map[string]interface{}, balance float64) []firestore.Update {
+	updates := []firestore.Update{{Path: "balance", Value: balance}}
+	if FloatField(data, "credit_limit") > 0 {
+		updates = append(updates, firestore.Update{Path: "credit_utilized", Value: math.Max(0, -balance)})
+	}
+	return updates
+}
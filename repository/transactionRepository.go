@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"go-transaction/entity"
+	"go-transaction/tracing"
+	"go-transaction/utils"
 
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/api/iterator"
@@ -22,7 +25,10 @@ import (
 // Returns:
 //   - The corresponding account number if found.
 //   - An error if no matching document is found or if an issue occurs during retrieval.
-func GetAccNo(ctx context.Context, client *firestore.Client, payment_method, details string) (string, error) {
+func GetAccNo(ctx context.Context, client *firestore.Client, payment_method, details string) (accNo string, err error) {
+	ctx, span := tracing.Start(ctx, "repository.GetAccNo", attribute.String("lookup.field", payment_method))
+	defer func() { tracing.End(span, err) }()
+
 	bankDetailsRef := client.Collection("BankDetails")
 
 	// Query Firestore for a document matching the given payment method and details
@@ -30,10 +36,10 @@ func GetAccNo(ctx context.Context, client *firestore.Client, payment_method, det
 	senderDoc, err := senderQuery.Next()
 	if err != nil {
 		if err == iterator.Done {
-			log.Error().
-				Str("sender "+payment_method, details).
+			log.Ctx(ctx).Error().
+				Str("sender "+payment_method, utils.MaskPaymentIdentifier(payment_method, details)).
 				Msg("No matching document found for sender")
-			return "", fmt.Errorf("no matching document found for sender %s: %s", payment_method, details)
+			return "", fmt.Errorf("no matching document found for sender %s: %s", payment_method, utils.MaskPaymentIdentifier(payment_method, details))
 		}
 		log.Error().
 			Err(err).
@@ -66,7 +72,13 @@ func GetAccNo(ctx context.Context, client *firestore.Client, payment_method, det
 //   - Sender's account number.
 //   - Receiver's account number.
 //   - An error if account retrieval fails.
-func GetUserAccNo(ctx context.Context, client *firestore.Client, paymentMethod, receivingMethod string, paymentDetails entity.PaymentDetails, receivingDetails entity.PaymentDetails) (string, string, error) {
+func GetUserAccNo(ctx context.Context, client *firestore.Client, paymentMethod, receivingMethod string, paymentDetails entity.PaymentDetails, receivingDetails entity.PaymentDetails) (senderAccNo, receiverAccNo string, err error) {
+	ctx, span := tracing.Start(ctx, "repository.GetUserAccNo",
+		attribute.String("payment.method", paymentMethod),
+		attribute.String("payment.receiving_method", receivingMethod),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	switch {
 	case paymentMethod == "UPI" && receivingMethod == "UPI":
 		senderAccNo, err := GetAccNo(ctx, client, "upi_id", paymentDetails.UPI.UpiId)
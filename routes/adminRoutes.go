@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"go-transaction/controller"
+	"go-transaction/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRoutes defines the routes reserved for administrators.
+//
+// Every route requires authentication and the ADMIN role.
+//
+// Routes:
+//   - GET /admin/review: Lists held transactions in the manual review queue.
+//   - GET /admin/review/:id: Retrieves a review item with its held transaction.
+//   - POST /admin/review/:id/approve: Approves a held transaction, executing the transfer.
+//   - POST /admin/review/:id/reject: Rejects a held transaction, releasing the reserved funds.
+//   - GET /admin/config: Shows the active configuration with secrets redacted.
+//   - POST /admin/accounts/:accNo/status: Changes an account's status ('active', 'debit_frozen', 'frozen',
+//     'closed' or 'dormant') with a reason.
+//   - POST /admin/accounts/:accNo/credit-limit: Sets an account's credit limit with a reason; 0 removes the credit line.
+func AdminRoutes(router *gin.RouterGroup) {
+	admin := router.Group("/admin", middleware.AuthCheck(), middleware.AdminCheck())
+
+	admin.GET("/review", controller.ListReviewQueue)
+	admin.GET("/review/:id", controller.GetReviewItem)
+	admin.POST("/review/:id/approve", controller.ApproveReview)
+	admin.POST("/review/:id/reject", controller.RejectReview)
+
+	admin.GET("/config", controller.GetEffectiveConfig)
+
+	admin.POST("/accounts/:accNo/status", controller.SetAccountStatus)
+	admin.POST("/accounts/:accNo/credit-limit", controller.SetCreditLimit)
+}
@@ -3,9 +3,13 @@ package routes
 import (
 	"fmt"
 	"go-transaction/config"
+	"go-transaction/controller"
+	"go-transaction/metrics"
+	"go-transaction/middleware"
+	"go-transaction/tracing"
 
 	"github.com/rs/zerolog/log"
-	"github.com/rs/cors"
+	"github.com/gin-contrib/cors"
 	ginzerolog "github.com/dn365/gin-zerolog"
 	"github.com/gin-gonic/gin"
 )
@@ -13,10 +17,16 @@ import (
 // # InitRoutes initializes and returns a configured Gin router instance.
 //
 // This function:
+// 		- Applies the CORS policy and the security headers from the configuration.
 // 		- Loads the API configuration from a YAML file.
 // 		- Applies the gin-zerolog middleware for structured logging.
-// 		- Creates a route group based on the API version.
+// 		- Records the latency of every route for Prometheus and starts a trace span per request.
+// 		- Tags every request with an X-Request-ID and a request-scoped logger.
+// 		- Registers the /healthz and /readyz probes and the /metrics endpoint outside the API version group.
+// 		- Creates a route group based on the API version, with the default per-IP rate limit.
 // 		- Delegates the setup of transaction-specific routes to TransactionRoutes().
+// 		- Delegates the setup of admin-only routes to AdminRoutes().
+// 		- Delegates the setup of webhook management routes to WebhookRoutes().
 //
 // Returns:
 // 		- *gin.Engine: Configured Gin router instance.
@@ -24,12 +34,25 @@ import (
 func InitRoutes() *gin.Engine {
 	router := gin.Default()
 
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"}, // Allow all origins (change this for security)
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Content-Type", "Authorization"},
-		AllowCredentials: true,
-	}))
+	corsConfig, err := config.GetCORSYamlConfig()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load CORS configuration")
+		return nil
+	}
+
+	// Without allowed origins the API is same-origin only and no CORS headers are sent
+	if len(corsConfig.AllowOrigins) > 0 {
+		router.Use(cors.New(cors.Config{
+			AllowOrigins:     corsConfig.AllowOrigins,
+			AllowMethods:     corsConfig.AllowMethods,
+			AllowHeaders:     corsConfig.AllowHeaders,
+			ExposeHeaders:    corsConfig.ExposeHeaders,
+			AllowCredentials: corsConfig.AllowCredentials,
+			AllowWildcard:    true,
+			MaxAge:           corsConfig.MaxAge,
+		}))
+	}
+	router.Use(middleware.SecurityHeaders())
 
 	api, err := config.GetApiYamlConfig()
 	if err != nil {
@@ -38,10 +61,20 @@ func InitRoutes() *gin.Engine {
 	}
 
 	router.Use(ginzerolog.Logger("gin"))
+	router.Use(metrics.Middleware())
+	router.Use(tracing.Middleware())
+	router.Use(middleware.RequestID())
+
+	router.GET("/healthz", controller.Healthz)
+	router.GET("/readyz", controller.Readyz)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	routerGroup := router.Group(fmt.Sprintf("/%s", api.Api))
+	routerGroup.Use(middleware.RateLimit("default"))
 
 	TransactionRoutes(routerGroup)
+	AdminRoutes(routerGroup)
+	WebhookRoutes(routerGroup)
 
 	return router
 }
@@ -12,18 +12,85 @@ import (
 // These routes include user authentication, transaction initiation, 
 // payment request actions, and retrieval of transaction details.
 //
+// /login and the routes that move or reserve money have their own rate limits on top of the default
+// limit every route gets (see middleware.RateLimit). Their responses, and those carrying transaction details
+// or the login token, are marked as not cacheable.
+//
 // Routes:
 //   - POST /login: User authentication endpoint to log in.
-//   - POST /initiate: Initiates a transaction, requiring authentication.
+//   - POST /initiate: Initiates a transaction, requiring authentication and, for UPI, the sender's UPI PIN.
+//     The fee priced by the configured rules is debited on top of the amount.
+//     With a quote_id, the transfer must match the quote and is charged the quoted fee.
+//   - POST /transfers/quote: Runs a transfer through every check without moving money and returns its fee,
+//     whether it would be held or need a TOTP code, and a short-lived signed quote ID, requiring authentication.
+//   - POST /fees/quote: Returns the fee a transfer would be charged, requiring authentication.
 //   - POST /make-request: Makes a payment request, requiring authentication.
-//   - POST /request-action: Handles actions on payment requests, requiring authentication.
+//   - POST /request-action: Accepts, cancels or declines a payment request, requiring authentication.
+//     Accepting also requires the payer's UPI PIN.
+//   - GET /requests/incoming: Lists payment requests addressed to the user, requiring authentication.
+//   - GET /requests/outgoing: Lists payment requests created by the user, requiring authentication.
+//   - GET /requests/:id: Retrieves a payment request, requiring authentication.
 //   - GET /txnID/:id: Retrieves transaction details by transaction ID, requiring authentication.
 //   - GET /txnID: Retrieves transaction details, requiring authentication.
+//   - POST /authorizations: Reserves funds for a two-phase payment, requiring authentication.
+//   - GET /authorizations/:id: Retrieves an authorization, requiring authentication.
+//   - POST /authorizations/:id/capture: Captures all or part of an authorization, requiring authentication.
+//   - POST /authorizations/:id/void: Releases an authorization's reserved funds, requiring authentication.
+//   - POST /schedules: Creates a one-off or recurring scheduled transfer, requiring authentication.
+//   - GET /schedules: Lists the user's schedules, requiring authentication.
+//   - GET /schedules/:id: Retrieves a schedule, requiring authentication.
+//   - GET /schedules/:id/runs: Retrieves the run history of a schedule, requiring authentication.
+//   - POST /schedules/:id/pause, /resume, /cancel: Changes a schedule's status, requiring authentication.
+//   - POST /split-requests: Splits a bill into one payment request per payer, requiring authentication.
+//   - GET /split-requests/:id: Retrieves a split request with per-payer status and totals, requiring authentication.
+//   - POST /split-requests/:id/cancel: Cancels the outstanding requests of a split, requiring authentication.
+//   - POST /2fa/enroll: Starts TOTP enrollment and returns the secret, requiring authentication.
+//   - POST /2fa/verify: Confirms TOTP enrollment with a first code, requiring authentication.
+//   - POST /challenges/:id/confirm: Confirms a step-up challenge with a TOTP code and completes the
+//     transfer or request acceptance waiting for it, requiring authentication.
+//   - POST /upi-pin: Sets the transaction PIN of one of the user's UPI IDs, requiring authentication.
+//   - POST /upi-pin/reset: Replaces a UPI PIN after the user re-enters their password, requiring authentication.
+//   - POST /beneficiaries: Saves a payee after resolving its account, requiring authentication. It resolves
+//     accounts like /accounts/verify, so it shares its rate limit.
+//   - GET /beneficiaries: Lists the user's beneficiaries, requiring authentication.
+//   - PATCH /beneficiaries/:id: Renames a beneficiary, requiring authentication.
+//   - DELETE /beneficiaries/:id: Removes a beneficiary, requiring authentication.
+//   - POST /accounts/verify: Returns the masked holder name and status of a UPI ID or bank account before
+//     paying it, requiring authentication. It has its own low rate limit against account enumeration.
 func TransactionRoutes(router *gin.RouterGroup) {
-	router.POST("/login", controller.Login)
-	router.POST("/initiate", middleware.AuthCheck(), controller.InitiateTransaction)
-	router.POST("/make-request", middleware.AuthCheck(), controller.MakeRequest)
-	router.POST("/request-action", middleware.AuthCheck(), controller.PaymentRequestAction)
-	router.GET("/txnID/:id", middleware.AuthCheck(), controller.GetTransactionByID)
-	router.GET("/txnID", middleware.AuthCheck(), controller.GetTransactionByID)
+	router.POST("/login", middleware.RateLimit("login"), middleware.NoStore(), controller.Login)
+	router.POST("/initiate", middleware.AuthCheck(), middleware.RateLimit("money"), middleware.NoStore(), controller.InitiateTransaction)
+	router.POST("/transfers/quote", middleware.AuthCheck(), middleware.RateLimit("money"), middleware.NoStore(), controller.QuoteTransfer)
+	router.POST("/fees/quote", middleware.AuthCheck(), controller.QuoteFee)
+	router.POST("/make-request", middleware.AuthCheck(), middleware.RateLimit("money"), middleware.NoStore(), controller.MakeRequest)
+	router.POST("/request-action", middleware.AuthCheck(), middleware.RateLimit("money"), middleware.NoStore(), controller.PaymentRequestAction)
+	router.GET("/requests/incoming", middleware.AuthCheck(), controller.ListIncomingRequests)
+	router.GET("/requests/outgoing", middleware.AuthCheck(), controller.ListOutgoingRequests)
+	router.GET("/requests/:id", middleware.AuthCheck(), controller.GetPaymentRequest)
+	router.GET("/txnID/:id", middleware.AuthCheck(), middleware.NoStore(), controller.GetTransactionByID)
+	router.GET("/txnID", middleware.AuthCheck(), middleware.NoStore(), controller.GetTransactionByID)
+	router.POST("/authorizations", middleware.AuthCheck(), middleware.RateLimit("money"), middleware.NoStore(), controller.Authorize)
+	router.GET("/authorizations/:id", middleware.AuthCheck(), controller.GetAuthorization)
+	router.POST("/authorizations/:id/capture", middleware.AuthCheck(), middleware.RateLimit("money"), middleware.NoStore(), controller.CaptureAuthorization)
+	router.POST("/authorizations/:id/void", middleware.AuthCheck(), middleware.RateLimit("money"), middleware.NoStore(), controller.VoidAuthorization)
+	router.POST("/schedules", middleware.AuthCheck(), middleware.RateLimit("money"), middleware.NoStore(), controller.CreateSchedule)
+	router.GET("/schedules", middleware.AuthCheck(), controller.ListSchedules)
+	router.GET("/schedules/:id", middleware.AuthCheck(), controller.GetSchedule)
+	router.GET("/schedules/:id/runs", middleware.AuthCheck(), controller.ListScheduleRuns)
+	router.POST("/schedules/:id/pause", middleware.AuthCheck(), controller.PauseSchedule)
+	router.POST("/schedules/:id/resume", middleware.AuthCheck(), controller.ResumeSchedule)
+	router.POST("/schedules/:id/cancel", middleware.AuthCheck(), controller.CancelSchedule)
+	router.POST("/split-requests", middleware.AuthCheck(), middleware.RateLimit("money"), middleware.NoStore(), controller.CreateSplitRequest)
+	router.GET("/split-requests/:id", middleware.AuthCheck(), controller.GetSplitRequest)
+	router.POST("/split-requests/:id/cancel", middleware.AuthCheck(), controller.CancelSplitRequest)
+	router.POST("/2fa/enroll", middleware.AuthCheck(), middleware.NoStore(), controller.EnrollTOTP)
+	router.POST("/2fa/verify", middleware.AuthCheck(), middleware.RateLimit("login"), controller.ConfirmTOTPEnrollment)
+	router.POST("/challenges/:id/confirm", middleware.AuthCheck(), middleware.RateLimit("money"), middleware.NoStore(), controller.ConfirmChallenge)
+	router.POST("/upi-pin", middleware.AuthCheck(), middleware.NoStore(), controller.SetUPIPin)
+	router.POST("/upi-pin/reset", middleware.AuthCheck(), middleware.RateLimit("login"), middleware.NoStore(), controller.ResetUPIPin)
+	router.POST("/beneficiaries", middleware.AuthCheck(), middleware.RateLimit("verify"), controller.AddBeneficiary)
+	router.GET("/beneficiaries", middleware.AuthCheck(), controller.ListBeneficiaries)
+	router.PATCH("/beneficiaries/:id", middleware.AuthCheck(), controller.RenameBeneficiary)
+	router.DELETE("/beneficiaries/:id", middleware.AuthCheck(), controller.DeleteBeneficiary)
+	router.POST("/accounts/verify", middleware.AuthCheck(), middleware.RateLimit("verify"), middleware.NoStore(), controller.VerifyAccount)
 }
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"go-transaction/controller"
+	"go-transaction/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookRoutes defines the routes for managing outgoing webhooks.
+//
+// Every route requires authentication.
+//
+// Routes:
+//   - POST /webhooks: Registers a webhook subscription and returns its signing secret.
+//   - GET /webhooks: Lists the user's webhook subscriptions.
+//   - DELETE /webhooks/:id: Deactivates a webhook subscription.
+//   - GET /webhooks/:id/deliveries: Retrieves the delivery log of a subscription.
+//   - POST /webhooks/deliveries/:id/redeliver: Sends a delivery again.
+func WebhookRoutes(router *gin.RouterGroup) {
+	webhooks := router.Group("/webhooks", middleware.AuthCheck())
+
+	webhooks.POST("", controller.CreateWebhookSubscription)
+	webhooks.GET("", controller.ListWebhookSubscriptions)
+	webhooks.DELETE("/:id", controller.DeleteWebhookSubscription)
+	webhooks.GET("/:id/deliveries", controller.ListWebhookDeliveries)
+	webhooks.POST("/deliveries/:id/redeliver", controller.RedeliverWebhook)
+}
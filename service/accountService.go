@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"go-transaction/repository"
+	"go-transaction/utils"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/iterator"
+)
+
+// AccountStatusError is returned when a transfer would debit or credit an account whose status does not allow it.
+type AccountStatusError struct {
+	Account string
+	Status  string
+	Debit   bool
+}
+
+func (e *AccountStatusError) Error() string {
+	action := "credited"
+	if e.Debit {
+		action = "debited"
+	}
+	return fmt.Sprintf("account %s is %s and cannot be %s", utils.MaskAccountNumber(e.Account), accountStatusText(e.Status), action)
+}
+
+// AccountOwnershipError is returned when a user tries to debit, hold or quote against an account they do not hold.
+type AccountOwnershipError struct {
+	Account string
+}
+
+func (e *AccountOwnershipError) Error() string {
+	return fmt.Sprintf("account %s is not held by the user", utils.MaskAccountNumber(e.Account))
+}
+
+func accountStatusText(status string) string {
+	switch status {
+	case entity.AccountDebitFrozen:
+		return "frozen for debits"
+	default:
+		return status
+	}
+}
+
+// VerifyAccount looks up the account behind a UPI ID or bank account and returns the masked name of
+// its holder and its status, so a user can check who they are about to pay.
+func VerifyAccount(ctx context.Context, requestBody entity.AccountVerifyRequest) (*entity.AccountVerification, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	field, value := lookupField(requestBody.Method, requestBody.Details)
+	accNo, err := repository.GetAccNo(ctx, client, field, value)
+	if err != nil {
+		return nil, fmt.Errorf("no account found for the given details")
+	}
+
+	docSnap, err := repository.GetAccount(ctx, client, accNo)
+	if err != nil {
+		return nil, err
+	}
+	data := docSnap.Data()
+	holderName, _ := data["holder_name"].(string)
+
+	return &entity.AccountVerification{
+		Method:     requestBody.Method,
+		HolderName: utils.MaskName(holderName),
+		Status:     repository.AccountStatus(data),
+	}, nil
+}
+
+// lookupField returns the BankDetails field and value an account is looked up by for a receiving method ('UPI' or 'BANK').
+func lookupField(method string, details entity.PaymentDetails) (string, string) {
+	if method == "BANK" {
+		return "account_number", details.BankDetails.AccountNumber
+	}
+	return "upi_id", details.UPI.UpiId
+}
+
+// checkAccountStatus returns an AccountStatusError if the status of an account, read from its
+// BankDetails document, does not allow it to be debited (or credited, if debit is false).
+func checkAccountStatus(accNo string, data map[string]interface{}, debit bool) error {
+	status := repository.AccountStatus(data)
+	allowed := true
+	switch status {
+	case entity.AccountActive:
+	case entity.AccountDebitFrozen, entity.AccountDormant:
+		allowed = !debit
+	default:
+		allowed = false
+	}
+	if !allowed {
+		return &AccountStatusError{Account: accNo, Status: status, Debit: debit}
+	}
+	return nil
+}
+
+// checkAccountHolder returns an AccountOwnershipError unless the holder_id of an account, read from its
+// BankDetails document, is the given user. Accounts without a holder belong to no one.
+func checkAccountHolder(accNo string, data map[string]interface{}, userID string) error {
+	if holderID, _ := data["holder_id"].(string); holderID == "" || !strings.EqualFold(holderID, userID) {
+		return &AccountOwnershipError{Account: accNo}
+	}
+	return nil
+}
+
+// checkSenderHolder fetches the account a payment would be sent from and checks that userID holds it,
+// whichever payment method (UPI ID, account number or card) it was resolved from.
+func checkSenderHolder(ctx context.Context, client *firestore.Client, senderAccNo, userID string) error {
+	senderDoc, err := repository.GetAccount(ctx, client, senderAccNo)
+	if err != nil {
+		return err
+	}
+	if err := checkAccountHolder(senderAccNo, senderDoc.Data(), userID); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("user_id", userID).Msg("Sender account is not held by the user")
+		return err
+	}
+	return nil
+}
+
+// SetAccountStatus changes the status of an account on behalf of an admin. Closed accounts cannot
+// be reopened, and an account can only be closed once it holds no funds.
+func SetAccountStatus(ctx context.Context, accNo string, change entity.AccountStatusChange) (*entity.AccountState, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	var state entity.AccountState
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		accountDoc, err := repository.GetAccountInTx(tx, client, accNo)
+		if err != nil {
+			return err
+		}
+
+		data := accountDoc.Data()
+		current := repository.AccountStatus(data)
+		if current == change.Status {
+			return fmt.Errorf("account %s is already %s", utils.MaskAccountNumber(accNo), accountStatusText(current))
+		}
+		if current == entity.AccountClosed {
+			return fmt.Errorf("account %s is closed and cannot be reopened", utils.MaskAccountNumber(accNo))
+		}
+		if change.Status == entity.AccountClosed && (repository.FloatField(data, "balance") != 0 || repository.FloatField(data, "reserved") != 0) {
+			return fmt.Errorf("account %s still holds funds and cannot be closed", utils.MaskAccountNumber(accNo))
+		}
+
+		now := time.Now().Unix()
+		state = entity.AccountState{
+			Account:         utils.MaskAccountNumber(accNo),
+			Status:          change.Status,
+			StatusReason:    change.Reason,
+			StatusChangedAt: now,
+			LastActivityAt:  int64Field(data, "last_activity_at"),
+		}
+
+		if err := tx.Update(accountDoc.Ref, []firestore.Update{
+			{Path: "status", Value: state.Status},
+			{Path: "status_reason", Value: state.StatusReason},
+			{Path: "status_changed_at", Value: now},
+		}); err != nil {
+			return fmt.Errorf("failed to update account status: %v", err)
+		}
+		entry := adminAudit(ctx, "account."+change.Status, "", change.Reason)
+		entry.Account = state.Account
+		return stageAudit(tx, client, entry)
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("accNo", utils.MaskAccountNumber(accNo)).Msg("Failed to change account status")
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// MarkDormantAccounts marks every active account without a debit or credit for the configured period
+// as dormant, and returns the number of accounts it marked. Accounts with no recorded activity yet
+// start counting from the first sweep that sees them.
+func MarkDormantAccounts(ctx context.Context) (int, error) {
+	accountConfig, err := config.GetAccountYamlConfig()
+	if err != nil {
+		return 0, fmt.Errorf("unable to load account configuration: %w", err)
+	}
+	if accountConfig.DormantAfter <= 0 {
+		return 0, nil
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return 0, err
+	}
+	defer client.Close()
+
+	iter := client.Collection("BankDetails").Documents(ctx)
+	defer iter.Stop()
+
+	now := time.Now()
+	cutoff := now.Add(-accountConfig.DormantAfter).Unix()
+	marked := 0
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return marked, fmt.Errorf("failed to fetch accounts: %v", err)
+		}
+
+		data := docSnap.Data()
+		if repository.AccountStatus(data) != entity.AccountActive {
+			continue
+		}
+		accNo, _ := data["account_number"].(string)
+
+		lastActivityAt := int64Field(data, "last_activity_at")
+		if lastActivityAt == 0 {
+			if _, err := docSnap.Ref.Update(ctx, []firestore.Update{{Path: "last_activity_at", Value: now.Unix()}}); err != nil {
+				log.Ctx(ctx).Error().Err(err).Str("accNo", utils.MaskAccountNumber(accNo)).Msg("Failed to start dormancy clock")
+			}
+			continue
+		}
+		if lastActivityAt > cutoff {
+			continue
+		}
+
+		// Re-check inside a transaction, so a transfer that lands during the sweep keeps the account active
+		err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			current, err := tx.Get(docSnap.Ref)
+			if err != nil {
+				return err
+			}
+			data := current.Data()
+			if repository.AccountStatus(data) != entity.AccountActive || int64Field(data, "last_activity_at") > cutoff {
+				return fmt.Errorf("account is no longer inactive")
+			}
+
+			reason := fmt.Sprintf("no activity since %s", time.Unix(lastActivityAt, 0).UTC().Format(time.RFC3339))
+			if err := tx.Update(docSnap.Ref, []firestore.Update{
+				{Path: "status", Value: entity.AccountDormant},
+				{Path: "status_reason", Value: reason},
+				{Path: "status_changed_at", Value: now.Unix()},
+			}); err != nil {
+				return err
+			}
+			entry := newAuditEntry(ctx, "account.dormant")
+			entry.Account = utils.MaskAccountNumber(accNo)
+			entry.Note = reason
+			return stageAudit(tx, client, entry)
+		})
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("accNo", utils.MaskAccountNumber(accNo)).Msg("Failed to mark account dormant")
+			continue
+		}
+		marked++
+	}
+
+	return marked, nil
+}
+
+// RunDormancySweep periodically marks inactive accounts dormant until the context is cancelled.
+// A running pass finishes before the worker returns.
+func RunDormancySweep(ctx context.Context) {
+	accountConfig, err := config.GetAccountYamlConfig()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Dormancy sweep worker not started")
+		return
+	}
+
+	interval := accountConfig.SweepInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			marked, err := MarkDormantAccounts(context.WithoutCancel(ctx))
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("Failed to mark dormant accounts")
+			}
+			if marked > 0 {
+				log.Ctx(ctx).Info().Int("marked", marked).Msg("Marked dormant accounts")
+			}
+		}
+	}
+}
+
+// int64Field reads a Unix timestamp field from Firestore document data; a missing field is 0.
+func int64Field(data map[string]interface{}, key string) int64 {
+	value, _ := data[key].(int64)
+	return value
+}
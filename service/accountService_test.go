@@ -0,0 +1,41 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckAccountHolder(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]interface{}
+		userID  string
+		allowed bool
+	}{
+		{"own BANK account", map[string]interface{}{"account_number": "1234567890", "holder_id": "alice"}, "alice", true},
+		{"holder ID differs in case", map[string]interface{}{"holder_id": "Alice"}, "alice", true},
+		{"foreign BANK account", map[string]interface{}{"account_number": "1234567890", "holder_id": "alice"}, "mallory", false},
+		{"foreign card account", map[string]interface{}{"card_id": "card-1", "holder_id": "alice"}, "mallory", false},
+		{"account without a holder", map[string]interface{}{"account_number": "1234567890"}, "mallory", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAccountHolder("1234567890", tt.data, tt.userID)
+			if tt.allowed {
+				if err != nil {
+					t.Fatalf("checkAccountHolder returned %v", err)
+				}
+				return
+			}
+
+			var foreign *AccountOwnershipError
+			if !errors.As(err, &foreign) {
+				t.Fatalf("checkAccountHolder returned %v, want an AccountOwnershipError", err)
+			}
+			if foreign.Error() != "account ******7890 is not held by the user" {
+				t.Fatalf("error message %q exposes the account number", foreign.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"go-transaction/entity"
+	"go-transaction/utils"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+)
+
+// AuditCollection is the Firestore collection of the append-only audit log.
+// The service only ever creates documents in it.
+const AuditCollection = "AuditLog"
+
+// balanceAudit builds the audit entry for a change to an account's funds. balance and reserved are the
+// account's values after the change; the account number is masked.
+func balanceAudit(ctx context.Context, action, accNo string, amount, balance, reserved float64, transactionID, reference string) entity.AuditEntry {
+	entry := newAuditEntry(ctx, action)
+	entry.Account = utils.MaskAccountNumber(accNo)
+	entry.Amount = amount
+	entry.Balance = balance
+	entry.Reserved = reserved
+	entry.TransactionID = transactionID
+	entry.Reference = reference
+	return entry
+}
+
+// adminAudit builds the audit entry for an action an admin took, such as deciding a review.
+func adminAudit(ctx context.Context, action, reference, note string) entity.AuditEntry {
+	entry := newAuditEntry(ctx, "admin."+action)
+	entry.Reference = reference
+	entry.Note = note
+	return entry
+}
+
+func newAuditEntry(ctx context.Context, action string) entity.AuditEntry {
+	actor := utils.UserIDFromContext(ctx)
+	if actor == "" {
+		actor = "system"
+	}
+	return entity.AuditEntry{
+		Action:    action,
+		Actor:     actor,
+		RequestID: utils.RequestIDFromContext(ctx),
+		Timestamp: time.Now().Unix(),
+	}
+}
+
+// stageAudit adds audit entries to a Firestore transaction, so they are written if and only if
+// the change they describe is committed.
+func stageAudit(tx *firestore.Transaction, client *firestore.Client, entries ...entity.AuditEntry) error {
+	for _, entry := range entries {
+		ref := client.Collection(AuditCollection).NewDoc()
+		entry.ID = ref.ID
+		if err := tx.Create(ref, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordAudit writes audit entries for a change that has already been made outside a Firestore transaction.
+// A failure is logged at error level but does not undo or fail the change.
+func recordAudit(ctx context.Context, client *firestore.Client, entries ...entity.AuditEntry) {
+	for _, entry := range entries {
+		ref := client.Collection(AuditCollection).NewDoc()
+		entry.ID = ref.ID
+		if _, err := ref.Create(ctx, entry); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("action", entry.Action).Str("transaction_id", entry.TransactionID).Msg("Failed to write audit entry")
+		}
+	}
+}
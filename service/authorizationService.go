@@ -0,0 +1,470 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"go-transaction/repository"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/iterator"
+)
+
+// Authorize reserves the requested amount against the sender's account without moving it.
+// The reservation lowers the available balance; the ledger balance is untouched until capture.
+// Holds are checked like transfers: the sender must hold the account, UPI holds need the UPI PIN, and
+// holds that need a TOTP code return a StepUpRequiredError whose challenge creates the hold once confirmed.
+func Authorize(ctx context.Context, requestBody entity.RequestBody) (*entity.Authorization, error) {
+	paymentMethod := strings.ToUpper(requestBody.PaymentMethod)
+	if err := checkPaymentLimit(requestBody.Amount, paymentMethod); err != nil {
+		return nil, err
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	senderAccNo, receiverAccNo, err := repository.GetUserAccNo(ctx, client,
+		paymentMethod,
+		strings.ToUpper(requestBody.RecievingMethod),
+		requestBody.SenderPaymentDetails,
+		requestBody.ReceiverPaymentDetails,
+	)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to fetch account numbers")
+		return nil, err
+	}
+	if err := checkSenderHolder(ctx, client, senderAccNo, requestBody.SenderID); err != nil {
+		return nil, err
+	}
+
+	if paymentMethod == "UPI" {
+		if err := verifyUPIPin(ctx, client, requestBody.SenderID, requestBody.SenderPaymentDetails.UPI.UpiId, requestBody.Pin); err != nil {
+			return nil, err
+		}
+	}
+
+	stepUp, err := stepUpReason(ctx, client, "transfer", requestBody.SenderID, receiverAccNo, requestBody.Amount)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to run step-up checks")
+		return nil, err
+	}
+	if stepUp != "" {
+		challenge, err := issueChallenge(ctx, client, entity.StepUpChallenge{
+			UserID:        requestBody.SenderID,
+			Kind:          "authorization",
+			Reason:        stepUp,
+			Amount:        requestBody.Amount,
+			Transfer:      &requestBody,
+			SenderAccNo:   senderAccNo,
+			ReceiverAccNo: receiverAccNo,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return nil, &StepUpRequiredError{Challenge: challenge}
+	}
+
+	return createAuthorization(ctx, client, requestBody, senderAccNo, receiverAccNo)
+}
+
+// createAuthorization reserves the amount of a checked hold on the sender's account and stores the authorization.
+func createAuthorization(ctx context.Context, client *firestore.Client, requestBody entity.RequestBody, senderAccNo, receiverAccNo string) (*entity.Authorization, error) {
+	authorizationConfig, err := config.GetAuthorizationYamlConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load authorization configuration: %w", err)
+	}
+
+	paymentMethod := strings.ToUpper(requestBody.PaymentMethod)
+	now := time.Now()
+	authRef := client.Collection("Authorization").NewDoc()
+	authorization := &entity.Authorization{
+		ID:              authRef.ID,
+		SenderID:        requestBody.SenderID,
+		ReceiverID:      requestBody.ReceiverID,
+		SenderAccNo:     senderAccNo,
+		ReceiverAccNo:   receiverAccNo,
+		Amount:          requestBody.Amount,
+		PaymentMethod:   paymentMethod,
+		RecievingMethod: strings.ToUpper(requestBody.RecievingMethod),
+		Status:          "authorized",
+		CreatedAt:       now.Unix(),
+		ExpiresAt:       now.Add(authorizationConfig.TTL).Unix(),
+	}
+
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		senderDoc, err := repository.GetAccountInTx(tx, client, senderAccNo)
+		if err != nil {
+			return err
+		}
+
+		senderData := senderDoc.Data()
+		if err := checkAccountStatus(senderAccNo, senderData, true); err != nil {
+			return err
+		}
+		balance := repository.FloatField(senderData, "balance")
+		reserved := repository.FloatField(senderData, "reserved")
+		available, err := availableFunds(senderAccNo, senderData, paymentMethod)
+		if err != nil {
+			return err
+		}
+		if available < requestBody.Amount {
+			log.Ctx(ctx).Error().
+				Float64("balance", balance).
+				Float64("reserved", reserved).
+				Float64("available", available).
+				Float64("amount", requestBody.Amount).
+				Msg("Insufficient available balance for authorization")
+			return fmt.Errorf("insufficient balance in sender's account")
+		}
+
+		if err := tx.Update(senderDoc.Ref, []firestore.Update{
+			{Path: "reserved", Value: reserved + requestBody.Amount},
+			{Path: "updatedAt", Value: firestore.ServerTimestamp},
+		}); err != nil {
+			return fmt.Errorf("failed to reserve sender's funds: %v", err)
+		}
+		if err := stageAudit(tx, client, balanceAudit(ctx, "funds.reserved", senderAccNo, requestBody.Amount, balance, reserved+requestBody.Amount, "", authRef.ID)); err != nil {
+			return fmt.Errorf("failed to write audit entry: %v", err)
+		}
+
+		return tx.Create(authRef, authorization)
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to authorize payment")
+		return nil, err
+	}
+
+	return authorization, nil
+}
+
+// GetAuthorization returns an authorization visible to the given user.
+// Admins can see every authorization; users only those they send or receive.
+func GetAuthorization(ctx context.Context, authorizationID, role, userID string) (*entity.Authorization, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	docSnap, err := client.Collection("Authorization").Doc(authorizationID).Get(ctx)
+	if err != nil {
+		if err.Error() == "rpc error: code = NotFound desc = " {
+			return nil, fmt.Errorf("no authorization found with ID: %s", authorizationID)
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching authorization document")
+		return nil, fmt.Errorf("failed to fetch authorization document: %v", err)
+	}
+
+	var authorization entity.Authorization
+	if err := docSnap.DataTo(&authorization); err != nil {
+		return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+	}
+
+	if !strings.EqualFold(role, "ADMIN") && !strings.EqualFold(authorization.SenderID, userID) && !strings.EqualFold(authorization.ReceiverID, userID) {
+		return nil, fmt.Errorf("Invalid User : %s %s", userID, role)
+	}
+
+	return &authorization, nil
+}
+
+// CaptureAuthorization moves funds from an authorization to the receiver.
+// An amount of 0 captures everything that remains; smaller amounts leave the rest
+// reserved for later captures until the authorization is voided or expires.
+func CaptureAuthorization(ctx context.Context, authorizationID string, amount float64, role, userID string) (*entity.Authorization, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	authRef := client.Collection("Authorization").Doc(authorizationID)
+	var authorization entity.Authorization
+
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		authSnap, err := tx.Get(authRef)
+		if err != nil {
+			return fmt.Errorf("failed to fetch authorization document: %v", err)
+		}
+		authorization = entity.Authorization{}
+		if err := authSnap.DataTo(&authorization); err != nil {
+			return fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+
+		// Only the merchant being paid (or an admin) may capture the funds; the merchant is whoever
+		// holds the receiving account, whatever receiver ID the hold was created with
+		admin := strings.EqualFold(role, "ADMIN")
+		if !admin && !strings.EqualFold(authorization.ReceiverID, userID) {
+			return fmt.Errorf("Invalid User : %s %s", userID, role)
+		}
+		if authorization.Status != "authorized" && authorization.Status != "partially_captured" {
+			return fmt.Errorf("authorization %s is %s and cannot be captured", authorizationID, authorization.Status)
+		}
+		// Expired holds are released by RunAuthorizationExpiry, but may not be captured while they wait for it
+		if time.Now().Unix() > authorization.ExpiresAt {
+			return fmt.Errorf("authorization %s expired at %s and cannot be captured", authorizationID, time.Unix(authorization.ExpiresAt, 0).UTC().Format(time.RFC3339))
+		}
+
+		remaining := authorization.Amount - authorization.CapturedAmount
+		captureAmount := amount
+		if captureAmount == 0 {
+			captureAmount = remaining
+		}
+		if captureAmount > remaining {
+			return fmt.Errorf("capture amount %v exceeds the remaining authorized amount of %v", captureAmount, remaining)
+		}
+
+		senderDoc, err := repository.GetAccountInTx(tx, client, authorization.SenderAccNo)
+		if err != nil {
+			return err
+		}
+		receiverDoc, err := repository.GetAccountInTx(tx, client, authorization.ReceiverAccNo)
+		if err != nil {
+			return err
+		}
+
+		senderData := senderDoc.Data()
+		receiverData := receiverDoc.Data()
+		if !admin {
+			if err := checkAccountHolder(authorization.ReceiverAccNo, receiverData, userID); err != nil {
+				return err
+			}
+		}
+		if err := checkAccountStatus(authorization.SenderAccNo, senderData, true); err != nil {
+			return err
+		}
+		if err := checkAccountStatus(authorization.ReceiverAccNo, receiverData, false); err != nil {
+			return err
+		}
+		senderBalance := repository.FloatField(senderData, "balance") - captureAmount
+		senderReserved := repository.FloatField(senderData, "reserved") - captureAmount
+		receiverBalance := repository.FloatField(receiverData, "balance") + captureAmount
+		now := time.Now().Unix()
+		if err := tx.Update(senderDoc.Ref, append(repository.BalanceUpdates(senderData, senderBalance),
+			firestore.Update{Path: "reserved", Value: senderReserved},
+			firestore.Update{Path: "last_activity_at", Value: now},
+			firestore.Update{Path: "updatedAt", Value: firestore.ServerTimestamp},
+		)); err != nil {
+			return fmt.Errorf("failed to update sender's balance: %v", err)
+		}
+		if err := tx.Update(receiverDoc.Ref, append(repository.BalanceUpdates(receiverData, receiverBalance),
+			firestore.Update{Path: "last_activity_at", Value: now},
+			firestore.Update{Path: "updatedAt", Value: firestore.ServerTimestamp},
+		)); err != nil {
+			return fmt.Errorf("failed to update receiver's balance: %v", err)
+		}
+
+		transactionRef := client.Collection("transaction").NewDoc()
+		if err := stageNewTransaction(tx, client, transactionRef, &entity.Transaction{
+			SenderID:        authorization.SenderID,
+			ReceiverID:      authorization.ReceiverID,
+			SenderAccNo:     authorization.SenderAccNo,
+			ReceiverAccNo:   authorization.ReceiverAccNo,
+			Amount:          captureAmount,
+			PaymentMethod:   authorization.PaymentMethod,
+			RecievingMethod: authorization.RecievingMethod,
+			Status:          "success",
+			Timestamp:       time.Now().Unix(),
+			TransactionType: "Capture",
+			ActionBy:        userID,
+		}); err != nil {
+			return fmt.Errorf("failed to store capture transaction: %v", err)
+		}
+
+		audit := []entity.AuditEntry{
+			balanceAudit(ctx, "balance.debit", authorization.SenderAccNo, captureAmount, senderBalance, senderReserved, transactionRef.ID, authorizationID),
+			balanceAudit(ctx, "balance.credit", authorization.ReceiverAccNo, captureAmount, receiverBalance, repository.FloatField(receiverData, "reserved"), transactionRef.ID, authorizationID),
+		}
+		if strings.EqualFold(role, "ADMIN") && !strings.EqualFold(authorization.ReceiverID, userID) {
+			audit = append(audit, adminAudit(ctx, "authorization.captured", authorizationID, ""))
+		}
+		if err := stageAudit(tx, client, audit...); err != nil {
+			return fmt.Errorf("failed to write audit entry: %v", err)
+		}
+
+		authorization.CapturedAmount += captureAmount
+		authorization.TransactionIDs = append(authorization.TransactionIDs, transactionRef.ID)
+		authorization.Status = "partially_captured"
+		if authorization.CapturedAmount >= authorization.Amount {
+			authorization.Status = "captured"
+		}
+
+		return tx.Update(authRef, []firestore.Update{
+			{Path: "CapturedAmount", Value: authorization.CapturedAmount},
+			{Path: "TransactionIDs", Value: authorization.TransactionIDs},
+			{Path: "Status", Value: authorization.Status},
+		})
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("authorization_id", authorizationID).Msg("Failed to capture authorization")
+		return nil, err
+	}
+
+	return &authorization, nil
+}
+
+// VoidAuthorization releases whatever is still reserved by an authorization.
+// Either party (or an admin) may void it.
+func VoidAuthorization(ctx context.Context, authorizationID, role, userID string) (*entity.Authorization, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	authorization, err := releaseAuthorization(ctx, client, authorizationID, "voided", func(a *entity.Authorization) error {
+		if !strings.EqualFold(role, "ADMIN") && !strings.EqualFold(a.SenderID, userID) && !strings.EqualFold(a.ReceiverID, userID) {
+			return fmt.Errorf("Invalid User : %s %s", userID, role)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("authorization_id", authorizationID).Msg("Failed to void authorization")
+		return nil, err
+	}
+	if strings.EqualFold(role, "ADMIN") && !strings.EqualFold(authorization.SenderID, userID) && !strings.EqualFold(authorization.ReceiverID, userID) {
+		recordAudit(ctx, client, adminAudit(ctx, "authorization.voided", authorizationID, ""))
+	}
+
+	return authorization, nil
+}
+
+// releaseAuthorization returns the uncaptured remainder of an open authorization to the
+// sender's available balance and moves the authorization to the given final status.
+func releaseAuthorization(ctx context.Context, client *firestore.Client, authorizationID, status string, allow func(*entity.Authorization) error) (*entity.Authorization, error) {
+	authRef := client.Collection("Authorization").Doc(authorizationID)
+	var authorization entity.Authorization
+
+	err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		authSnap, err := tx.Get(authRef)
+		if err != nil {
+			return fmt.Errorf("failed to fetch authorization document: %v", err)
+		}
+		authorization = entity.Authorization{}
+		if err := authSnap.DataTo(&authorization); err != nil {
+			return fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+
+		if allow != nil {
+			if err := allow(&authorization); err != nil {
+				return err
+			}
+		}
+		if authorization.Status != "authorized" && authorization.Status != "partially_captured" {
+			return fmt.Errorf("authorization %s is already %s", authorizationID, authorization.Status)
+		}
+
+		senderDoc, err := repository.GetAccountInTx(tx, client, authorization.SenderAccNo)
+		if err != nil {
+			return err
+		}
+
+		remaining := authorization.Amount - authorization.CapturedAmount
+		senderData := senderDoc.Data()
+		reserved := repository.FloatField(senderData, "reserved") - remaining
+		if err := tx.Update(senderDoc.Ref, []firestore.Update{
+			{Path: "reserved", Value: reserved},
+			{Path: "updatedAt", Value: firestore.ServerTimestamp},
+		}); err != nil {
+			return fmt.Errorf("failed to release sender's funds: %v", err)
+		}
+		if err := stageAudit(tx, client, balanceAudit(ctx, "funds.released", authorization.SenderAccNo, remaining, repository.FloatField(senderData, "balance"), reserved, "", authorizationID)); err != nil {
+			return fmt.Errorf("failed to write audit entry: %v", err)
+		}
+
+		authorization.Status = status
+		return tx.Update(authRef, []firestore.Update{
+			{Path: "Status", Value: status},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &authorization, nil
+}
+
+// ExpireAuthorizations releases every open authorization whose TTL has passed.
+// It returns the number of authorizations that were expired.
+func ExpireAuthorizations(ctx context.Context) (int, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return 0, err
+	}
+	defer client.Close()
+
+	iter := client.Collection("Authorization").Where("Status", "in", []string{"authorized", "partially_captured"}).Documents(ctx)
+	defer iter.Stop()
+
+	now := time.Now().Unix()
+	expired := 0
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return expired, fmt.Errorf("failed to fetch authorizations: %v", err)
+		}
+
+		var authorization entity.Authorization
+		if err := docSnap.DataTo(&authorization); err != nil {
+			return expired, fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		if authorization.ExpiresAt > now {
+			continue
+		}
+
+		if _, err := releaseAuthorization(ctx, client, docSnap.Ref.ID, "expired", nil); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("authorization_id", docSnap.Ref.ID).Msg("Failed to expire authorization")
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// RunAuthorizationExpiry periodically expires authorizations past their TTL until the context is cancelled.
+// A running pass finishes before the worker returns.
+func RunAuthorizationExpiry(ctx context.Context) {
+	authorizationConfig, err := config.GetAuthorizationYamlConfig()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Authorization expiry worker not started")
+		return
+	}
+
+	interval := authorizationConfig.SweepInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := ExpireAuthorizations(context.WithoutCancel(ctx))
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("Failed to expire authorizations")
+			}
+			if expired > 0 {
+				log.Ctx(ctx).Info().Int("expired", expired).Msg("Expired authorizations")
+			}
+		}
+	}
+}
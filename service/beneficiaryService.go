@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"go-transaction/repository"
+	"go-transaction/utils"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/iterator"
+)
+
+// AddBeneficiary saves a payee for the user once its details resolve to an existing account.
+// The beneficiary starts in the configured cooling-off period, and the user is notified.
+func AddBeneficiary(ctx context.Context, requestBody entity.BeneficiaryRequest, userID string) (*entity.Beneficiary, error) {
+	beneficiaryConfig, err := config.GetBeneficiaryYamlConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load beneficiary configuration: %w", err)
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	field, value := lookupField(requestBody.Method, requestBody.Details)
+	accNo, err := repository.GetAccNo(ctx, client, field, value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch beneficiary account details")
+	}
+	holderName, err := repository.GetAccountHolderName(ctx, client, accNo)
+	if err != nil {
+		return nil, err
+	}
+
+	// The same account can only be saved once per user
+	existing, err := client.Collection("Beneficiary").
+		Where("OwnerID", "==", userID).
+		Where("AccountNumber", "==", accNo).
+		Limit(1).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching beneficiaries")
+		return nil, fmt.Errorf("failed to fetch beneficiaries: %v", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("this account is already saved as beneficiary %s", existing[0].Ref.ID)
+	}
+
+	now := time.Now()
+	beneficiary := &entity.Beneficiary{
+		OwnerID:       userID,
+		Nickname:      strings.TrimSpace(requestBody.Nickname),
+		ReceiverID:    requestBody.ReceiverID,
+		Method:        requestBody.Method,
+		Details:       requestBody.Details,
+		AccountNumber: accNo,
+		HolderName:    utils.MaskName(holderName),
+		CreatedAt:     now.Unix(),
+	}
+	if beneficiaryConfig.CoolingOff > 0 {
+		beneficiary.CoolingOffUntil = now.Add(beneficiaryConfig.CoolingOff).Unix()
+	}
+
+	docRef := client.Collection("Beneficiary").NewDoc()
+	beneficiary.ID = docRef.ID
+	if _, err := docRef.Create(ctx, beneficiary); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to store beneficiary in Firestore")
+		return nil, fmt.Errorf("failed to store beneficiary: %v", err)
+	}
+
+	notify(ctx, client, entity.Notification{
+		UserID:  userID,
+		Type:    "beneficiary.added",
+		Message: fmt.Sprintf("%s (%s) was added to your beneficiaries", beneficiary.Nickname, utils.MaskAccountNumber(accNo)),
+	})
+	return beneficiary, nil
+}
+
+// ListBeneficiaries returns the beneficiaries saved by the given user, ordered by nickname.
+func ListBeneficiaries(ctx context.Context, userID string) ([]*entity.Beneficiary, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	iter := client.Collection("Beneficiary").Where("OwnerID", "==", userID).Documents(ctx)
+	defer iter.Stop()
+
+	beneficiaries := []*entity.Beneficiary{}
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Error fetching beneficiaries")
+			return nil, fmt.Errorf("failed to fetch beneficiaries: %v", err)
+		}
+
+		var beneficiary entity.Beneficiary
+		if err := docSnap.DataTo(&beneficiary); err != nil {
+			return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		beneficiary.HolderName = utils.MaskName(beneficiary.HolderName)
+		beneficiaries = append(beneficiaries, &beneficiary)
+	}
+
+	sort.Slice(beneficiaries, func(i, j int) bool {
+		return strings.ToLower(beneficiaries[i].Nickname) < strings.ToLower(beneficiaries[j].Nickname)
+	})
+
+	return beneficiaries, nil
+}
+
+// RenameBeneficiary changes the nickname of one of the user's beneficiaries.
+func RenameBeneficiary(ctx context.Context, beneficiaryID, nickname, userID string) (*entity.Beneficiary, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	beneficiary, err := getOwnedBeneficiary(ctx, client, beneficiaryID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	beneficiary.Nickname = strings.TrimSpace(nickname)
+	if _, err := client.Collection("Beneficiary").Doc(beneficiaryID).Update(ctx, []firestore.Update{
+		{Path: "Nickname", Value: beneficiary.Nickname},
+	}); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to rename beneficiary")
+		return nil, fmt.Errorf("failed to rename beneficiary: %v", err)
+	}
+
+	return beneficiary, nil
+}
+
+// DeleteBeneficiary removes one of the user's beneficiaries. Transfers and schedules that reference it fail from then on.
+func DeleteBeneficiary(ctx context.Context, beneficiaryID, userID string) error {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return err
+	}
+	defer client.Close()
+
+	if _, err := getOwnedBeneficiary(ctx, client, beneficiaryID, userID); err != nil {
+		return err
+	}
+
+	if _, err := client.Collection("Beneficiary").Doc(beneficiaryID).Delete(ctx); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to delete beneficiary")
+		return fmt.Errorf("failed to delete beneficiary: %v", err)
+	}
+	return nil
+}
+
+// resolveBeneficiary fills in the receiver of a transfer addressed to a saved beneficiary of the sender.
+// During the beneficiary's cooling-off period only transfers up to the configured limit are allowed.
+func resolveBeneficiary(ctx context.Context, client *firestore.Client, requestBody *entity.RequestBody) error {
+	beneficiaryConfig, err := config.GetBeneficiaryYamlConfig()
+	if err != nil {
+		return fmt.Errorf("unable to load beneficiary configuration: %w", err)
+	}
+
+	beneficiary, err := getOwnedBeneficiary(ctx, client, requestBody.BeneficiaryID, requestBody.SenderID)
+	if err != nil {
+		return err
+	}
+
+	if time.Now().Unix() < beneficiary.CoolingOffUntil && requestBody.Amount > beneficiaryConfig.CoolingOffLimit {
+		return fmt.Errorf("beneficiary %s is new until %s, transfers to it are limited to %v",
+			beneficiary.ID, time.Unix(beneficiary.CoolingOffUntil, 0).UTC().Format(time.RFC3339), beneficiaryConfig.CoolingOffLimit)
+	}
+
+	requestBody.RecievingMethod = beneficiary.Method
+	requestBody.ReceiverPaymentDetails = beneficiary.Details
+	if requestBody.ReceiverID == "" {
+		requestBody.ReceiverID = beneficiary.ReceiverID
+	}
+	return nil
+}
+
+func getOwnedBeneficiary(ctx context.Context, client *firestore.Client, beneficiaryID, userID string) (*entity.Beneficiary, error) {
+	docSnap, err := client.Collection("Beneficiary").Doc(beneficiaryID).Get(ctx)
+	if err != nil {
+		if err.Error() == "rpc error: code = NotFound desc = " {
+			return nil, fmt.Errorf("no beneficiary found with ID: %s", beneficiaryID)
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching beneficiary document")
+		return nil, fmt.Errorf("failed to fetch beneficiary document: %v", err)
+	}
+
+	var beneficiary entity.Beneficiary
+	if err := docSnap.DataTo(&beneficiary); err != nil {
+		return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+	}
+
+	if !strings.EqualFold(beneficiary.OwnerID, userID) {
+		return nil, fmt.Errorf("Invalid User : %s", userID)
+	}
+	// Beneficiaries saved before names were masked still hold the full name
+	beneficiary.HolderName = utils.MaskName(beneficiary.HolderName)
+	return &beneficiary, nil
+}
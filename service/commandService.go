@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ClaimCommand records that the queued command with the given ID is being processed. It returns false
+// if the command was claimed before, so a redelivered command is never executed twice.
+//
+// The claim is written before the command runs: a crash after the claim means the command is not run
+// again on redelivery, which for commands that move money is safer than running it twice. Its record
+// stays 'processing' and can be looked up by the command ID.
+func ClaimCommand(ctx context.Context, commandID, commandType string) (bool, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return false, err
+	}
+	defer client.Close()
+
+	_, err = client.Collection("ProcessedCommand").Doc(commandID).Create(ctx, map[string]interface{}{
+		"Type":      commandType,
+		"Status":    "processing",
+		"CreatedAt": time.Now().Unix(),
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record command: %v", err)
+	}
+	return true, nil
+}
+
+// FinishCommand records the outcome of a claimed command ('done' or 'failed' with the error).
+func FinishCommand(ctx context.Context, commandID string, commandErr error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return
+	}
+	defer client.Close()
+
+	updates := []firestore.Update{
+		{Path: "Status", Value: "done"},
+		{Path: "FinishedAt", Value: time.Now().Unix()},
+	}
+	if commandErr != nil {
+		updates[0].Value = "failed"
+		updates = append(updates, firestore.Update{Path: "Error", Value: commandErr.Error()})
+	}
+	if _, err := client.Collection("ProcessedCommand").Doc(commandID).Update(ctx, updates); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("command_id", commandID).Msg("Failed to record command outcome")
+	}
+}
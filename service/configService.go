@@ -0,0 +1,9 @@
+package service
+
+import "go-transaction/config"
+
+// GetEffectiveConfig returns the configuration the service is currently running with,
+// as flat "section.key" values with secrets redacted.
+func GetEffectiveConfig() (map[string]string, error) {
+	return config.Effective()
+}
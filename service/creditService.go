@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"go-transaction/repository"
+	"go-transaction/utils"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/iterator"
+)
+
+// AccrualHook computes one charge, such as interest or a fee, for a credit account on the given day of the daily batch.
+// It returns the amount to debit, or 0 to charge nothing. Hooks may be called more than once for the same
+// account and day if the Firestore transaction is retried, so they must not have side effects.
+type AccrualHook func(ctx context.Context, account entity.CreditAccount, day time.Time) (float64, error)
+
+var (
+	accrualHooksMu sync.RWMutex
+	accrualHooks   = map[string]AccrualHook{"interest": dailyInterest}
+)
+
+// RegisterAccrualHook adds or replaces a named charge of the daily credit batch.
+// Each charge is audited as 'credit.<name>'; the built-in 'interest' hook can be replaced this way.
+func RegisterAccrualHook(name string, hook AccrualHook) {
+	accrualHooksMu.Lock()
+	defer accrualHooksMu.Unlock()
+	accrualHooks[name] = hook
+}
+
+// dailyInterest charges a day of the configured yearly interest rate on the utilized credit.
+func dailyInterest(ctx context.Context, account entity.CreditAccount, day time.Time) (float64, error) {
+	creditConfig, err := config.GetCreditYamlConfig()
+	if err != nil {
+		return 0, fmt.Errorf("unable to load credit configuration: %w", err)
+	}
+	return math.Round(account.Utilized*creditConfig.InterestRate/365*100) / 100, nil
+}
+
+// availableFunds returns how much a transfer paid by paymentMethod can draw from an account: its balance
+// less reserved funds and, for credit card payments, plus its credit limit. A card ('CREDIT_CARD') draws
+// on the credit line of the account its card_id is stored on, so that account must have one.
+func availableFunds(accNo string, data map[string]interface{}, paymentMethod string) (float64, error) {
+	available := repository.FloatField(data, "balance") - repository.FloatField(data, "reserved")
+	if paymentMethod != "CREDIT_CARD" {
+		return available, nil
+	}
+
+	creditLimit := repository.FloatField(data, "credit_limit")
+	if creditLimit <= 0 {
+		return 0, fmt.Errorf("card is not linked to a credit account: %s", utils.MaskAccountNumber(accNo))
+	}
+	return available + creditLimit, nil
+}
+
+// creditAccount reads the credit line of an account from its BankDetails document data.
+func creditAccount(accNo string, data map[string]interface{}) entity.CreditAccount {
+	balance := repository.FloatField(data, "balance")
+	lastAccrualDay, _ := data["last_accrual_day"].(string)
+	return entity.CreditAccount{
+		Account:        accNo,
+		Balance:        balance,
+		Reserved:       repository.FloatField(data, "reserved"),
+		CreditLimit:    repository.FloatField(data, "credit_limit"),
+		Utilized:       math.Max(0, -balance),
+		LastAccrualDay: lastAccrualDay,
+	}
+}
+
+// SetCreditLimit sets the credit limit of an account on behalf of an admin. The limit cannot be lowered
+// below the credit already in use; a limit of 0 turns the account back into a debit account.
+func SetCreditLimit(ctx context.Context, accNo string, change entity.CreditLimitChange) (*entity.CreditAccount, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	var account entity.CreditAccount
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		accountDoc, err := repository.GetAccountInTx(tx, client, accNo)
+		if err != nil {
+			return err
+		}
+
+		account = creditAccount(accNo, accountDoc.Data())
+		if change.CreditLimit < account.Utilized {
+			return fmt.Errorf("credit limit %v is below the %v already in use", change.CreditLimit, account.Utilized)
+		}
+		account.CreditLimit = change.CreditLimit
+		account.Account = utils.MaskAccountNumber(accNo)
+
+		if err := tx.Update(accountDoc.Ref, []firestore.Update{
+			{Path: "credit_limit", Value: account.CreditLimit},
+			{Path: "credit_utilized", Value: account.Utilized},
+		}); err != nil {
+			return fmt.Errorf("failed to update credit limit: %v", err)
+		}
+		entry := adminAudit(ctx, "account.credit_limit", fmt.Sprint(account.CreditLimit), change.Reason)
+		entry.Account = account.Account
+		return stageAudit(tx, client, entry)
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("accNo", utils.MaskAccountNumber(accNo)).Msg("Failed to set credit limit")
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// AccrueCreditCharges runs the accrual hooks for every credit account not yet charged today and debits
+// the charges, each with its own audit entry. It returns the number of accounts that were charged.
+func AccrueCreditCharges(ctx context.Context) (int, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return 0, err
+	}
+	defer client.Close()
+
+	accrualHooksMu.RLock()
+	names := make([]string, 0, len(accrualHooks))
+	hooks := make(map[string]AccrualHook, len(accrualHooks))
+	for name, hook := range accrualHooks {
+		names = append(names, name)
+		hooks[name] = hook
+	}
+	accrualHooksMu.RUnlock()
+	sort.Strings(names)
+
+	iter := client.Collection("BankDetails").Where("credit_limit", ">", 0).Documents(ctx)
+	defer iter.Stop()
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	dayKey := day.Format("2006-01-02")
+	charged := 0
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return charged, fmt.Errorf("failed to fetch credit accounts: %v", err)
+		}
+		if lastAccrualDay, _ := docSnap.Data()["last_accrual_day"].(string); lastAccrualDay == dayKey {
+			continue
+		}
+
+		var total float64
+		err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			total = 0
+
+			current, err := tx.Get(docSnap.Ref)
+			if err != nil {
+				return err
+			}
+			data := current.Data()
+			accNo, _ := data["account_number"].(string)
+			account := creditAccount(accNo, data)
+			if account.LastAccrualDay == dayKey {
+				return nil
+			}
+
+			var audit []entity.AuditEntry
+			balance := account.Balance
+			for _, name := range names {
+				amount, err := hooks[name](ctx, account, day)
+				if err != nil {
+					return fmt.Errorf("accrual hook %s failed: %v", name, err)
+				}
+				if amount <= 0 {
+					continue
+				}
+				balance -= amount
+				total += amount
+				audit = append(audit, balanceAudit(ctx, "credit."+name, accNo, amount, balance, account.Reserved, "", dayKey))
+			}
+
+			updates := append(repository.BalanceUpdates(data, balance), firestore.Update{Path: "last_accrual_day", Value: dayKey})
+			if err := tx.Update(docSnap.Ref, updates); err != nil {
+				return err
+			}
+			return stageAudit(tx, client, audit...)
+		})
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("account_id", docSnap.Ref.ID).Msg("Failed to accrue credit charges")
+			continue
+		}
+		if total > 0 {
+			charged++
+		}
+	}
+
+	return charged, nil
+}
+
+// RunCreditAccrual periodically charges interest and fees on credit accounts until the context is cancelled.
+// Each account is charged at most once per UTC day, however often the batch runs.
+func RunCreditAccrual(ctx context.Context) {
+	creditConfig, err := config.GetCreditYamlConfig()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Credit accrual worker not started")
+		return
+	}
+
+	interval := creditConfig.AccrualInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			charged, err := AccrueCreditCharges(context.WithoutCancel(ctx))
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("Failed to accrue credit charges")
+			}
+			if charged > 0 {
+				log.Ctx(ctx).Info().Int("charged", charged).Msg("Charged credit accounts")
+			}
+		}
+	}
+}
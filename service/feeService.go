@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"go-transaction/repository"
+	"math"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultTier is the pricing tier of users that have none set.
+const defaultTier = "standard"
+
+// QuoteFee returns the fee the user would be charged for a transfer, priced as InitiateTransaction would.
+func QuoteFee(ctx context.Context, userID string, request entity.FeeQuoteRequest) (*entity.FeeQuote, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	breakdown, err := transferFee(ctx, client, userID, strings.ToUpper(request.PaymentMethod), strings.ToUpper(request.RecievingMethod), request.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &entity.FeeQuote{Amount: request.Amount, Total: request.Amount, Breakdown: breakdown}
+	if breakdown != nil {
+		quote.Fee = breakdown.Fee
+		quote.Total += breakdown.Fee
+	}
+	return quote, nil
+}
+
+// transferFee prices a transfer of amount from the given user with the first matching fee rule.
+// It returns nil when no rule matches and the transfer is free.
+func transferFee(ctx context.Context, client *firestore.Client, userID, paymentMethod, receivingMethod string, amount float64) (*entity.FeeBreakdown, error) {
+	feeConfig, err := config.GetFeeYamlConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load fee configuration: %w", err)
+	}
+	if len(feeConfig.Rules) == 0 {
+		return nil, nil
+	}
+
+	tier, err := userTier(ctx, client, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range feeConfig.Rules {
+		if matchesFeeRule(rule, tier, paymentMethod, receivingMethod, amount) {
+			return priceFee(rule, tier, amount), nil
+		}
+	}
+	return nil, nil
+}
+
+func matchesFeeRule(rule entity.FeeRule, tier, paymentMethod, receivingMethod string, amount float64) bool {
+	if rule.PaymentMethod != "" && !strings.EqualFold(rule.PaymentMethod, paymentMethod) {
+		return false
+	}
+	if rule.ReceivingMethod != "" && !strings.EqualFold(rule.ReceivingMethod, receivingMethod) {
+		return false
+	}
+	if rule.Tier != "" && !strings.EqualFold(rule.Tier, tier) {
+		return false
+	}
+	return amount >= rule.MinAmount && (rule.MaxAmount == 0 || amount <= rule.MaxAmount)
+}
+
+// priceFee applies a fee rule to an amount. Every part is rounded to the paisa.
+func priceFee(rule entity.FeeRule, tier string, amount float64) *entity.FeeBreakdown {
+	variable := amount * rule.Percent / 100
+	lower := 0.0
+	for _, feeTier := range rule.Tiers {
+		upper := amount
+		if feeTier.UpTo > 0 && feeTier.UpTo < amount {
+			upper = feeTier.UpTo
+		}
+		if upper > lower {
+			variable += (upper - lower) * feeTier.Percent / 100
+		}
+		if feeTier.UpTo == 0 || feeTier.UpTo >= amount {
+			break
+		}
+		lower = feeTier.UpTo
+	}
+
+	breakdown := &entity.FeeBreakdown{
+		Rule:     rule.Name,
+		Tier:     tier,
+		Fixed:    roundMoney(rule.Fixed),
+		Variable: roundMoney(variable),
+	}
+	fee := breakdown.Fixed + breakdown.Variable
+	if fee < rule.MinFee {
+		fee = rule.MinFee
+	}
+	if rule.MaxFee > 0 && fee > rule.MaxFee {
+		fee = rule.MaxFee
+	}
+	breakdown.Fee = roundMoney(fee)
+	breakdown.Adjustment = roundMoney(breakdown.Fee - breakdown.Fixed - breakdown.Variable)
+	return breakdown
+}
+
+// creditFee stages the credit of a fee to the fee-revenue account inside tx and returns the account's new balance.
+func creditFee(tx *firestore.Transaction, revenueDoc *firestore.DocumentSnapshot, fee float64) (float64, error) {
+	data := revenueDoc.Data()
+	balance := repository.FloatField(data, "balance") + fee
+	if err := tx.Update(revenueDoc.Ref, append(repository.BalanceUpdates(data, balance),
+		firestore.Update{Path: "updatedAt", Value: firestore.ServerTimestamp},
+	)); err != nil {
+		return 0, fmt.Errorf("failed to credit fee: %v", err)
+	}
+	return balance, nil
+}
+
+// userTier returns the pricing tier of a user, or the default tier when none is set.
+func userTier(ctx context.Context, client *firestore.Client, userID string) (string, error) {
+	docSnap, err := client.Collection("users").Doc(userID).Get(ctx)
+	if err != nil {
+		if err.Error() == "rpc error: code = NotFound desc = " {
+			return defaultTier, nil
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching user document")
+		return "", fmt.Errorf("failed to fetch user document: %v", err)
+	}
+
+	if tier, _ := docSnap.Data()["tier"].(string); tier != "" {
+		return strings.ToLower(tier), nil
+	}
+	return defaultTier, nil
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
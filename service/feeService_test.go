@@ -0,0 +1,78 @@
+package service
+
+import (
+	"go-transaction/entity"
+	"testing"
+)
+
+func TestMatchesFeeRule(t *testing.T) {
+	rule := entity.FeeRule{
+		Name:            "upi-to-bank",
+		PaymentMethod:   "UPI",
+		ReceivingMethod: "BANK",
+		Tier:            "standard",
+		MinAmount:       100,
+		MaxAmount:       50000,
+	}
+
+	tests := []struct {
+		name            string
+		rule            entity.FeeRule
+		tier            string
+		paymentMethod   string
+		receivingMethod string
+		amount          float64
+		want            bool
+	}{
+		{"everything matches", rule, "standard", "UPI", "BANK", 1000, true},
+		{"methods and tier ignore case", rule, "STANDARD", "upi", "bank", 1000, true},
+		{"other payment method", rule, "standard", "CREDIT_CARD", "BANK", 1000, false},
+		{"other receiving method", rule, "standard", "UPI", "UPI", 1000, false},
+		{"other tier", rule, "premium", "UPI", "BANK", 1000, false},
+		{"at the minimum", rule, "standard", "UPI", "BANK", 100, true},
+		{"below the minimum", rule, "standard", "UPI", "BANK", 99.99, false},
+		{"at the maximum", rule, "standard", "UPI", "BANK", 50000, true},
+		{"above the maximum", rule, "standard", "UPI", "BANK", 50000.01, false},
+		{"empty fields match anything", entity.FeeRule{Name: "catch-all"}, "premium", "CREDIT_CARD", "UPI", 1e9, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesFeeRule(tt.rule, tt.tier, tt.paymentMethod, tt.receivingMethod, tt.amount); got != tt.want {
+				t.Fatalf("matchesFeeRule = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceFee(t *testing.T) {
+	tiers := []entity.FeeTier{{UpTo: 1000, Percent: 1}, {UpTo: 5000, Percent: 0.5}, {Percent: 0.25}}
+
+	tests := []struct {
+		name   string
+		rule   entity.FeeRule
+		amount float64
+		want   entity.FeeBreakdown
+	}{
+		{"fixed", entity.FeeRule{Fixed: 5}, 1000, entity.FeeBreakdown{Fixed: 5, Fee: 5}},
+		{"percent rounded to the paisa", entity.FeeRule{Percent: 1}, 1234.56, entity.FeeBreakdown{Variable: 12.35, Fee: 12.35}},
+		{"fixed and percent", entity.FeeRule{Fixed: 2, Percent: 0.5}, 1000, entity.FeeBreakdown{Fixed: 2, Variable: 5, Fee: 7}},
+		{"raised to the minimum fee", entity.FeeRule{Percent: 0.1, MinFee: 1}, 100, entity.FeeBreakdown{Variable: 0.1, Fee: 1, Adjustment: 0.9}},
+		{"capped at the maximum fee", entity.FeeRule{Percent: 2, MaxFee: 250}, 100000, entity.FeeBreakdown{Variable: 2000, Fee: 250, Adjustment: -1750}},
+		{"within the first tier", entity.FeeRule{Tiers: tiers}, 500, entity.FeeBreakdown{Variable: 5, Fee: 5}},
+		{"across two tiers", entity.FeeRule{Tiers: tiers}, 3000, entity.FeeBreakdown{Variable: 20, Fee: 20}},
+		{"into the open-ended tier", entity.FeeRule{Tiers: tiers}, 10000, entity.FeeBreakdown{Variable: 42.5, Fee: 42.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Name = "rule"
+			tt.want.Rule = "rule"
+			tt.want.Tier = "standard"
+
+			if got := priceFee(tt.rule, "standard", tt.amount); *got != tt.want {
+				t.Fatalf("priceFee(%v) = %+v, want %+v", tt.amount, *got, tt.want)
+			}
+		})
+	}
+}
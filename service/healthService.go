@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// dependencyCheckTimeout bounds each readiness check so a hung dependency cannot stall the probe.
+const dependencyCheckTimeout = 3 * time.Second
+
+var shuttingDown atomic.Bool
+
+// BeginShutdown marks the service as not ready, so the orchestrator stops routing new traffic to it
+// while in-flight requests drain.
+func BeginShutdown() {
+	shuttingDown.Store(true)
+}
+
+// CheckReadiness checks the dependencies the service needs to handle traffic and returns the state
+// of each one ("ok", "disabled" or the error) and whether the service is ready.
+// RabbitMQ is only checked when the command consumer or the outbox relay is enabled.
+func CheckReadiness(ctx context.Context) (map[string]string, bool) {
+	checks := make(map[string]string)
+	ready := true
+
+	if shuttingDown.Load() {
+		checks["server"] = "shutting down"
+		ready = false
+	}
+
+	if err := checkFirestore(ctx); err != nil {
+		checks["firestore"] = err.Error()
+		ready = false
+	} else {
+		checks["firestore"] = "ok"
+	}
+
+	rabbitMQConfig, err := config.GetRabbitMQYamlConfig()
+	if err != nil {
+		checks["rabbitmq"] = err.Error()
+		return checks, false
+	}
+	outboxConfig, err := config.GetOutboxYamlConfig()
+	if err != nil {
+		checks["rabbitmq"] = err.Error()
+		return checks, false
+	}
+
+	if !rabbitMQConfig.Enabled && !outboxConfig.Enabled {
+		checks["rabbitmq"] = "disabled"
+	} else if err := checkRabbitMQ(rabbitMQConfig.URL); err != nil {
+		checks["rabbitmq"] = err.Error()
+		ready = false
+	} else {
+		checks["rabbitmq"] = "ok"
+	}
+
+	return checks, ready
+}
+
+// checkFirestore performs a minimal read to confirm Firestore is reachable with the configured credentials.
+func checkFirestore(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dependencyCheckTimeout)
+	defer cancel()
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Firestore client: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Collection("BankDetails").Limit(1).Documents(ctx).GetAll(); err != nil {
+		return fmt.Errorf("firestore unreachable: %v", err)
+	}
+	return nil
+}
+
+// checkRabbitMQ opens and closes a connection to the broker.
+func checkRabbitMQ(url string) error {
+	conn, err := amqp.DialConfig(url, amqp.Config{Dial: amqp.DefaultDial(dependencyCheckTimeout)})
+	if err != nil {
+		return fmt.Errorf("rabbitmq unreachable: %v", err)
+	}
+	return conn.Close()
+}
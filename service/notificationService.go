@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go-transaction/entity"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+)
+
+// notify records a notification event for a user.
+// Delivery is best effort: a failure is logged and does not fail the calling operation.
+func notify(ctx context.Context, client *firestore.Client, notification entity.Notification) {
+	docRef := client.Collection("Notification").NewDoc()
+	notification.ID = docRef.ID
+	notification.CreatedAt = time.Now().Unix()
+
+	if _, err := docRef.Create(ctx, notification); err != nil {
+		log.Ctx(ctx).Error().
+			Err(err).
+			Str("user_id", notification.UserID).
+			Str("type", notification.Type).
+			Msg("Failed to record notification")
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/entity"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// OutboxCollection is the Firestore collection holding transaction events until the relay publishes them.
+const OutboxCollection = "Outbox"
+
+// transactionEventTypes maps a transaction status to the type of the event announcing it.
+var transactionEventTypes = map[string]string{
+	"pending":  "transaction.created",
+	"held":     "transaction.held",
+	"success":  "transaction.succeeded",
+	"fail":     "transaction.failed",
+	"cancel":   "transaction.cancelled",
+	"declined": "transaction.declined",
+	"expired":  "transaction.expired",
+	"rejected": "transaction.rejected",
+}
+
+// newTransactionEvent builds the outbox event for the current state of a transaction,
+// using its EventSeq as the event's sequence number.
+func newTransactionEvent(transaction *entity.Transaction) entity.TransactionEvent {
+	eventType, ok := transactionEventTypes[transaction.Status]
+	if !ok {
+		eventType = "transaction." + transaction.Status
+	}
+
+	return entity.TransactionEvent{
+		ID:            fmt.Sprintf("%s-%06d", transaction.ID, transaction.EventSeq),
+		SchemaVersion: entity.EventSchemaVersion,
+		Type:          eventType,
+		TransactionID: transaction.ID,
+		Sequence:      transaction.EventSeq,
+		Status:        transaction.Status,
+		OccurredAt:    time.Now().Unix(),
+		Data: entity.TransactionEventData{
+			SenderID:        transaction.SenderID,
+			ReceiverID:      transaction.ReceiverID,
+			Amount:          transaction.Amount,
+			PaymentMethod:   transaction.PaymentMethod,
+			RecievingMethod: transaction.RecievingMethod,
+			TransactionType: transaction.TransactionType,
+			ActionBy:        transaction.ActionBy,
+		},
+	}
+}
+
+// stageNewTransaction creates a transaction document together with its first outbox event inside tx.
+// It only writes, so it can be called at any point of the Firestore transaction.
+func stageNewTransaction(tx *firestore.Transaction, client *firestore.Client, ref *firestore.DocumentRef, transaction *entity.Transaction) error {
+	transaction.ID = ref.ID
+	transaction.EventSeq = 1
+
+	if err := tx.Create(ref, transaction); err != nil {
+		return fmt.Errorf("failed to store transaction: %v", err)
+	}
+
+	event := newTransactionEvent(transaction)
+	return tx.Create(client.Collection(OutboxCollection).Doc(event.ID), event)
+}
+
+// createTransaction stores a new transaction and its first outbox event atomically and returns the transaction ID.
+func createTransaction(ctx context.Context, client *firestore.Client, transaction *entity.Transaction) (string, error) {
+	ref := client.Collection("transaction").NewDoc()
+
+	err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		return stageNewTransaction(tx, client, ref, transaction)
+	})
+	if err != nil {
+		return "", err
+	}
+	return ref.ID, nil
+}
+
+// stageTransactionStatus moves a transaction to a new status inside tx and writes the matching outbox event
+// with the next sequence number. Extra updates (e.g. ActionBy) are applied in the same write.
+//
+// It reads the transaction document, so it must be called before any write in tx.
+func stageTransactionStatus(tx *firestore.Transaction, client *firestore.Client, transactionID, status string, updates ...firestore.Update) error {
+	ref := client.Collection("transaction").Doc(transactionID)
+
+	docSnap, err := tx.Get(ref)
+	if err != nil {
+		return fmt.Errorf("failed to fetch transaction document: %v", err)
+	}
+
+	var transaction entity.Transaction
+	if err := docSnap.DataTo(&transaction); err != nil {
+		return fmt.Errorf("failed to map Firestore document: %v", err)
+	}
+	transaction.ID = transactionID
+	transaction.Status = status
+	transaction.EventSeq++
+	for _, update := range updates {
+		if actionBy, ok := update.Value.(string); ok && update.Path == "ActionBy" {
+			transaction.ActionBy = actionBy
+		}
+	}
+
+	updates = append(updates,
+		firestore.Update{Path: "Status", Value: status},
+		firestore.Update{Path: "EventSeq", Value: transaction.EventSeq},
+	)
+	if err := tx.Update(ref, updates); err != nil {
+		return fmt.Errorf("failed to update transaction status: %v", err)
+	}
+
+	event := newTransactionEvent(&transaction)
+	return tx.Create(client.Collection(OutboxCollection).Doc(event.ID), event)
+}
+
+// updateTransactionStatus moves a transaction to a new status and records the outbox event atomically.
+func updateTransactionStatus(ctx context.Context, client *firestore.Client, transactionID, status string, updates ...firestore.Update) error {
+	return client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		return stageTransactionStatus(tx, client, transactionID, status, updates...)
+	})
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"go-transaction/metrics"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/iterator"
+)
+
+// requestExpiry returns the lifetime of a new payment request.
+// A requested lifetime of 0 seconds falls back to the configured default.
+func requestExpiry(expiresIn int64) (time.Duration, error) {
+	paymentRequestConfig, err := config.GetPaymentRequestYamlConfig()
+	if err != nil {
+		return 0, fmt.Errorf("unable to load payment request configuration: %w", err)
+	}
+
+	if expiresIn == 0 {
+		return paymentRequestConfig.Expiry, nil
+	}
+
+	expiry := time.Duration(expiresIn) * time.Second
+	if paymentRequestConfig.MaxExpiry > 0 && expiry > paymentRequestConfig.MaxExpiry {
+		return 0, fmt.Errorf("request expiry exceeds the maximum allowed of %v", paymentRequestConfig.MaxExpiry)
+	}
+	return expiry, nil
+}
+
+// ListPaymentRequests returns the payment requests addressed to ("incoming") or created by ("outgoing")
+// the given user, newest first. An empty status returns requests in every status.
+func ListPaymentRequests(ctx context.Context, userID, direction, status string, pageSize, pageNumber int) ([]*entity.PaymentRequestDetails, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	var field string
+	switch direction {
+	case "incoming":
+		field = "To"
+	case "outgoing":
+		field = "From"
+	default:
+		return nil, fmt.Errorf("invalid request direction: %s", direction)
+	}
+
+	query := client.Collection("TransactionRequest").Where(field, "==", userID)
+	if status != "" {
+		query = query.Where("Status", "==", strings.ToLower(status))
+	}
+
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	requests := []*entity.PaymentRequestDetails{}
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Error fetching payment requests")
+			return nil, fmt.Errorf("failed to fetch payment requests: %v", err)
+		}
+
+		var request entity.TransactionRequest
+		if err := docSnap.DataTo(&request); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Failed to map Firestore document to struct")
+			return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		request.ID = docSnap.Ref.ID
+		requests = append(requests, paymentRequestDetails(&request))
+	}
+
+	sort.Slice(requests, func(i, j int) bool { return requests[i].CreatedAt > requests[j].CreatedAt })
+
+	startIndex := (pageNumber - 1) * pageSize
+	endIndex := startIndex + pageSize
+	if startIndex > len(requests) {
+		return []*entity.PaymentRequestDetails{}, nil
+	}
+	if endIndex > len(requests) {
+		endIndex = len(requests)
+	}
+
+	return requests[startIndex:endIndex], nil
+}
+
+// GetPaymentRequest returns a payment request to its requester, its payer or an admin.
+func GetPaymentRequest(ctx context.Context, requestID, role, userID string) (*entity.PaymentRequestDetails, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	docSnap, err := client.Collection("TransactionRequest").Doc(requestID).Get(ctx)
+	if err != nil {
+		if err.Error() == "rpc error: code = NotFound desc = " {
+			return nil, fmt.Errorf("no payment request found with ID: %s", requestID)
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching request document")
+		return nil, fmt.Errorf("failed to fetch request document: %v", err)
+	}
+
+	var request entity.TransactionRequest
+	if err := docSnap.DataTo(&request); err != nil {
+		return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+	}
+	request.ID = docSnap.Ref.ID
+
+	if !strings.EqualFold(role, "ADMIN") && !strings.EqualFold(request.From, userID) && !strings.EqualFold(request.To, userID) {
+		return nil, fmt.Errorf("Invalid User : %s %s", userID, role)
+	}
+
+	return paymentRequestDetails(&request), nil
+}
+
+// paymentRequestDetails converts a stored request into its public view.
+// Requests created before statuses were tracked are reported as pending.
+func paymentRequestDetails(request *entity.TransactionRequest) *entity.PaymentRequestDetails {
+	status := request.Status
+	if status == "" {
+		status = "pending"
+	}
+
+	return &entity.PaymentRequestDetails{
+		ID:            request.ID,
+		RequesterID:   request.From,
+		PayerID:       request.To,
+		Amount:        request.Amount,
+		PaymentMethod: request.PaymentMethod,
+		TransactionID: request.TransactionID,
+		Status:        status,
+		CreatedAt:     request.CreatedAt,
+		ExpiresAt:     request.ExpiresAt,
+		DeclineReason: request.DeclineReason,
+		GroupID:       request.GroupID,
+	}
+}
+
+// SweepPaymentRequests reminds payers about pending requests that are about to expire
+// and expires those whose deadline has passed, together with their linked transactions.
+// It returns the number of reminders sent and requests expired.
+func SweepPaymentRequests(ctx context.Context) (int, int, error) {
+	paymentRequestConfig, err := config.GetPaymentRequestYamlConfig()
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to load payment request configuration: %w", err)
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return 0, 0, err
+	}
+	defer client.Close()
+
+	iter := client.Collection("TransactionRequest").Where("Status", "==", "pending").Documents(ctx)
+	defer iter.Stop()
+
+	now := time.Now()
+	reminded, expired := 0, 0
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return reminded, expired, fmt.Errorf("failed to fetch payment requests: %v", err)
+		}
+
+		var request entity.TransactionRequest
+		if err := docSnap.DataTo(&request); err != nil {
+			return reminded, expired, fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		request.ID = docSnap.Ref.ID
+
+		if request.ExpiresAt == 0 {
+			continue
+		}
+
+		if now.Unix() > request.ExpiresAt {
+			if err := expirePaymentRequest(ctx, client, request); err != nil {
+				log.Ctx(ctx).Error().Err(err).Str("request_id", request.ID).Msg("Failed to expire payment request")
+				continue
+			}
+			expired++
+			continue
+		}
+
+		remindAt := time.Unix(request.ExpiresAt, 0).Add(-paymentRequestConfig.ReminderBefore)
+		if paymentRequestConfig.ReminderBefore > 0 && request.ReminderSentAt == 0 && !now.Before(remindAt) {
+			if _, err := docSnap.Ref.Update(ctx, []firestore.Update{
+				{Path: "ReminderSentAt", Value: now.Unix()},
+			}); err != nil {
+				log.Ctx(ctx).Error().Err(err).Str("request_id", request.ID).Msg("Failed to mark reminder as sent")
+				continue
+			}
+
+			metrics.RecordPaymentRequest("reminded")
+			notify(ctx, client, entity.Notification{
+				UserID:        request.To,
+				Type:          "payment_request.reminder",
+				RequestID:     request.ID,
+				TransactionID: request.TransactionID,
+				Message:       fmt.Sprintf("Payment request of %v expires at %s", request.Amount, time.Unix(request.ExpiresAt, 0).UTC().Format(time.RFC3339)),
+			})
+			reminded++
+		}
+	}
+
+	return reminded, expired, nil
+}
+
+// expirePaymentRequest marks a still-pending request and its linked transaction as expired in one Firestore transaction.
+func expirePaymentRequest(ctx context.Context, client *firestore.Client, request entity.TransactionRequest) error {
+	requestRef := client.Collection("TransactionRequest").Doc(request.ID)
+
+	err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docSnap, err := tx.Get(requestRef)
+		if err != nil {
+			return err
+		}
+
+		// The payer may have acted on the request since it was read
+		if requestStatus, _ := docSnap.DataAt("Status"); requestStatus != "pending" {
+			return fmt.Errorf("request is no longer pending")
+		}
+
+		if request.TransactionID != "" {
+			if err := stageTransactionStatus(tx, client, request.TransactionID, "expired"); err != nil {
+				return err
+			}
+		}
+		return tx.Update(requestRef, []firestore.Update{
+			{Path: "Status", Value: "expired"},
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to expire payment request: %v", err)
+	}
+	metrics.RecordPaymentRequest("expired")
+	if request.GroupID != "" {
+		closeSettledGroup(ctx, client, request.GroupID)
+	}
+
+	notify(ctx, client, entity.Notification{
+		UserID:        request.From,
+		Type:          "payment_request.expired",
+		RequestID:     request.ID,
+		TransactionID: request.TransactionID,
+		Message:       fmt.Sprintf("Your payment request of %v expired without a response", request.Amount),
+	})
+
+	request.Status = "expired"
+	enqueueWebhookEvent(ctx, client, request.From, "payment_request.expired", paymentRequestDetails(&request))
+	return nil
+}
+
+// RunPaymentRequestSweeper periodically sends reminders and expires payment requests until the context is cancelled.
+// Cancelling ctx does not interrupt a sweep that has already started.
+func RunPaymentRequestSweeper(ctx context.Context) {
+	paymentRequestConfig, err := config.GetPaymentRequestYamlConfig()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Payment request sweeper not started")
+		return
+	}
+
+	interval := paymentRequestConfig.SweepInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reminded, expired, err := SweepPaymentRequests(context.WithoutCancel(ctx))
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("Failed to sweep payment requests")
+			}
+			if reminded > 0 || expired > 0 {
+				log.Ctx(ctx).Info().Int("reminded", reminded).Int("expired", expired).Msg("Swept payment requests")
+			}
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"go-transaction/repository"
+	"go-transaction/utils"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+)
+
+// QuoteError is returned when a transfer cannot be executed against the quote it names: the quote is
+// unknown, expired or already used, or the transfer differs from the one quoted.
+type QuoteError struct {
+	Reason string
+}
+
+func (e *QuoteError) Error() string {
+	return e.Reason
+}
+
+// QuoteTransfer runs a transfer through the checks InitiateTransaction would (receiver resolution, payment
+// limits, fees, account status and funds) without moving money, and reports whether it would be held for
+// review or need a TOTP code. The quote is stored and returned with a signed ID that executes the transfer
+// at the quoted fee until the quote expires. Transfers are single-currency, so there is no FX rate to lock.
+func QuoteTransfer(ctx context.Context, requestBody entity.RequestBody) (*entity.TransferQuote, error) {
+	quoteConfig, err := config.GetQuoteYamlConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load quote configuration: %w", err)
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	if requestBody.BeneficiaryID != "" {
+		if err := resolveBeneficiary(ctx, client, &requestBody); err != nil {
+			return nil, err
+		}
+	}
+	paymentMethod := strings.ToUpper(requestBody.PaymentMethod)
+	receivingMethod := strings.ToUpper(requestBody.RecievingMethod)
+
+	senderAccNo, receiverAccNo, err := repository.GetUserAccNo(ctx, client, paymentMethod, receivingMethod,
+		requestBody.SenderPaymentDetails, requestBody.ReceiverPaymentDetails)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSenderHolder(ctx, client, senderAccNo, requestBody.SenderID); err != nil {
+		return nil, err
+	}
+
+	if err := checkPaymentLimit(requestBody.Amount, paymentMethod); err != nil {
+		return nil, err
+	}
+
+	fee, err := transferFee(ctx, client, requestBody.SenderID, paymentMethod, receivingMethod, requestBody.Amount)
+	if err != nil {
+		return nil, err
+	}
+	total := requestBody.Amount
+	if fee != nil {
+		total += fee.Fee
+	}
+
+	senderDoc, err := repository.GetAccount(ctx, client, senderAccNo)
+	if err != nil {
+		return nil, err
+	}
+	receiverDoc, err := repository.GetAccount(ctx, client, receiverAccNo)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccountStatus(senderAccNo, senderDoc.Data(), true); err != nil {
+		return nil, err
+	}
+	if err := checkAccountStatus(receiverAccNo, receiverDoc.Data(), false); err != nil {
+		return nil, err
+	}
+	available, err := availableFunds(senderAccNo, senderDoc.Data(), paymentMethod)
+	if err != nil {
+		return nil, err
+	}
+	if available < total {
+		return nil, fmt.Errorf("insufficient balance in sender's account")
+	}
+
+	review, err := reviewReason(requestBody.Amount, paymentMethod)
+	if err != nil {
+		return nil, err
+	}
+	stepUp, err := stepUpReason(ctx, client, "transfer", requestBody.SenderID, receiverAccNo, requestBody.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	docRef := client.Collection("TransferQuote").NewDoc()
+	quote := &entity.TransferQuote{
+		ID:              docRef.ID,
+		UserID:          requestBody.SenderID,
+		SenderAccNo:     senderAccNo,
+		ReceiverAccNo:   receiverAccNo,
+		Amount:          requestBody.Amount,
+		PaymentMethod:   paymentMethod,
+		RecievingMethod: receivingMethod,
+		Fee:             fee,
+		Total:           total,
+		Review:          review,
+		StepUp:          stepUp,
+		Status:          "open",
+		CreatedAt:       now.Unix(),
+		ExpiresAt:       now.Add(quoteConfig.TTL).Unix(),
+	}
+	if _, err := docRef.Create(ctx, quote); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to store quote in Firestore")
+		return nil, fmt.Errorf("failed to store quote: %v", err)
+	}
+
+	quote.QuoteID = utils.SignQuoteID(config.GetQuoteKey(), quote.ID, quote.UserID)
+	return quote, nil
+}
+
+// redeemQuote marks the quote named by a transfer as used and returns it, once it has checked that the quote
+// belongs to the sender, is still open and describes the same transfer, resolved to the same accounts.
+// If the transfer then fails before any money moves, the quote is given back with releaseQuote.
+func redeemQuote(ctx context.Context, client *firestore.Client, requestBody entity.RequestBody, senderAccNo, receiverAccNo string) (*entity.TransferQuote, error) {
+	id, err := utils.VerifyQuoteID(config.GetQuoteKey(), requestBody.QuoteID, requestBody.SenderID)
+	if err != nil {
+		return nil, &QuoteError{Reason: err.Error()}
+	}
+	quoteRef := client.Collection("TransferQuote").Doc(id)
+
+	var quote entity.TransferQuote
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docSnap, err := tx.Get(quoteRef)
+		if err != nil {
+			if err.Error() == "rpc error: code = NotFound desc = " {
+				return &QuoteError{Reason: fmt.Sprintf("no quote found with ID: %s", id)}
+			}
+			return fmt.Errorf("failed to fetch quote document: %v", err)
+		}
+
+		quote = entity.TransferQuote{}
+		if err := docSnap.DataTo(&quote); err != nil {
+			return fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+
+		now := time.Now().Unix()
+		switch {
+		case quote.Status != "open":
+			return &QuoteError{Reason: fmt.Sprintf("quote %s was already used", id)}
+		case now > quote.ExpiresAt:
+			return &QuoteError{Reason: fmt.Sprintf("quote %s expired at %s", id, time.Unix(quote.ExpiresAt, 0).UTC().Format(time.RFC3339))}
+		case quote.Amount != requestBody.Amount ||
+			!strings.EqualFold(quote.PaymentMethod, requestBody.PaymentMethod) ||
+			!strings.EqualFold(quote.RecievingMethod, requestBody.RecievingMethod) ||
+			quote.SenderAccNo != senderAccNo || quote.ReceiverAccNo != receiverAccNo:
+			return &QuoteError{Reason: fmt.Sprintf("the transfer does not match quote %s", id)}
+		}
+
+		quote.Status = "used"
+		quote.UsedAt = now
+		return tx.Update(quoteRef, []firestore.Update{
+			{Path: "Status", Value: quote.Status},
+			{Path: "UsedAt", Value: quote.UsedAt},
+		})
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("quote_id", id).Msg("Failed to redeem quote")
+		return nil, err
+	}
+
+	return &quote, nil
+}
+
+// releaseQuote reopens a quote redeemed by a transfer that failed, so it can be used again until it expires.
+// Failures are only logged; the quote then stays used and a new one has to be requested.
+func releaseQuote(ctx context.Context, client *firestore.Client, quoteID string) {
+	quoteRef := client.Collection("TransferQuote").Doc(quoteID)
+	err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docSnap, err := tx.Get(quoteRef)
+		if err != nil {
+			return fmt.Errorf("failed to fetch quote document: %v", err)
+		}
+		if status, _ := docSnap.DataAt("Status"); status != "used" {
+			return nil
+		}
+		return tx.Update(quoteRef, []firestore.Update{
+			{Path: "Status", Value: "open"},
+			{Path: "UsedAt", Value: firestore.Delete},
+		})
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("quote_id", quoteID).Msg("Failed to release quote")
+	}
+}
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"go-transaction/metrics"
+	"go-transaction/repository"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/iterator"
+)
+
+// reviewReason runs the review checks for a transfer and returns why it should be held,
+// or an empty string if it can be executed immediately.
+func reviewReason(amount float64, paymentMethod string) (string, error) {
+	reviewConfig, err := config.GetReviewYamlConfig()
+	if err != nil {
+		return "", fmt.Errorf("unable to load review configuration: %w", err)
+	}
+
+	switch paymentMethod {
+	case "UPI":
+		if reviewConfig.UpiThreshold > 0 && amount > reviewConfig.UpiThreshold {
+			return fmt.Sprintf("UPI amount exceeds the review threshold of %v", reviewConfig.UpiThreshold), nil
+		}
+	case "CREDIT_CARD":
+		if reviewConfig.CreditThreshold > 0 && amount > reviewConfig.CreditThreshold {
+			return fmt.Sprintf("Credit card amount exceeds the review threshold of %v", reviewConfig.CreditThreshold), nil
+		}
+	}
+
+	return "", nil
+}
+
+// holdTransaction reserves the transfer amount and fee on the sender's account, marks the
+// transaction as held and adds it to the review queue, all in one Firestore transaction.
+func holdTransaction(ctx context.Context, client *firestore.Client, item entity.ReviewItem) error {
+	if err := checkPaymentLimit(item.Amount, item.PaymentMethod); err != nil {
+		return err
+	}
+
+	reviewConfig, err := config.GetReviewYamlConfig()
+	if err != nil {
+		return fmt.Errorf("unable to load review configuration: %w", err)
+	}
+
+	now := time.Now()
+	item.ID = item.TransactionID
+	item.Status = "pending"
+	item.CreatedAt = now.Unix()
+	item.ExpiresAt = now.Add(reviewConfig.Timeout).Unix()
+
+	return client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		senderDoc, err := repository.GetAccountInTx(tx, client, item.SenderAccNo)
+		if err != nil {
+			return err
+		}
+
+		senderData := senderDoc.Data()
+		if err := checkAccountStatus(item.SenderAccNo, senderData, true); err != nil {
+			return err
+		}
+		balance := repository.FloatField(senderData, "balance")
+		reserved := repository.FloatField(senderData, "reserved")
+		available, err := availableFunds(item.SenderAccNo, senderData, item.PaymentMethod)
+		if err != nil {
+			return err
+		}
+		total := item.Amount + item.Fee
+		if available < total {
+			log.Ctx(ctx).Error().
+				Float64("balance", balance).
+				Float64("reserved", reserved).
+				Float64("available", available).
+				Float64("amount", item.Amount).
+				Float64("fee", item.Fee).
+				Msg("Insufficient balance in sender's account")
+			return fmt.Errorf("insufficient balance in sender's account")
+		}
+
+		if err := stageTransactionStatus(tx, client, item.TransactionID, "held"); err != nil {
+			return err
+		}
+
+		if err := tx.Update(senderDoc.Ref, []firestore.Update{
+			{Path: "reserved", Value: reserved + total},
+			{Path: "updatedAt", Value: firestore.ServerTimestamp},
+		}); err != nil {
+			return fmt.Errorf("failed to reserve sender's funds: %v", err)
+		}
+		if err := stageAudit(tx, client, balanceAudit(ctx, "funds.reserved", item.SenderAccNo, total, balance, reserved+total, item.TransactionID, item.ID)); err != nil {
+			return fmt.Errorf("failed to write audit entry: %v", err)
+		}
+
+		return tx.Create(client.Collection("ReviewQueue").Doc(item.ID), item)
+	})
+}
+
+// ListReviewQueue returns the review items with the given status, oldest first.
+// An empty status lists pending items.
+func ListReviewQueue(ctx context.Context, status string, pageSize, pageNumber int) ([]*entity.ReviewItem, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	if status == "" {
+		status = "pending"
+	}
+
+	iter := client.Collection("ReviewQueue").Where("Status", "==", strings.ToLower(status)).Documents(ctx)
+	defer iter.Stop()
+
+	var items []*entity.ReviewItem
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Error fetching review queue")
+			return nil, fmt.Errorf("failed to fetch review queue: %v", err)
+		}
+
+		var item entity.ReviewItem
+		if err := docSnap.DataTo(&item); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Failed to map Firestore document to struct")
+			return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		items = append(items, &item)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].CreatedAt < items[j].CreatedAt })
+
+	startIndex := (pageNumber - 1) * pageSize
+	endIndex := startIndex + pageSize
+	if startIndex > len(items) {
+		return []*entity.ReviewItem{}, nil
+	}
+	if endIndex > len(items) {
+		endIndex = len(items)
+	}
+
+	return items[startIndex:endIndex], nil
+}
+
+// GetReviewItem returns a review item together with the transaction it holds.
+func GetReviewItem(ctx context.Context, reviewID string) (*entity.ReviewDetails, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	docSnap, err := client.Collection("ReviewQueue").Doc(reviewID).Get(ctx)
+	if err != nil {
+		if err.Error() == "rpc error: code = NotFound desc = " {
+			return nil, fmt.Errorf("no review item found with ID: %s", reviewID)
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching review document")
+		return nil, fmt.Errorf("failed to fetch review document: %v", err)
+	}
+
+	var item entity.ReviewItem
+	if err := docSnap.DataTo(&item); err != nil {
+		return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+	}
+
+	transactionSnap, err := client.Collection("transaction").Doc(item.TransactionID).Get(ctx)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching held transaction document")
+		return nil, fmt.Errorf("failed to fetch transaction document: %v", err)
+	}
+
+	var transaction entity.Transaction
+	if err := transactionSnap.DataTo(&transaction); err != nil {
+		return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+	}
+	transaction.ID = transactionSnap.Ref.ID
+
+	return &entity.ReviewDetails{Review: &item, Transaction: &transaction}, nil
+}
+
+// ApproveReview executes a held transfer: the reserved amount is debited from the sender,
+// credited to the receiver, its fee to the fee-revenue account, and the transaction is marked successful.
+func ApproveReview(ctx context.Context, reviewID, adminID, note string) error {
+	return decideReview(ctx, reviewID, adminID, note, "approved")
+}
+
+// RejectReview releases the reservation of a held transfer and marks the transaction rejected.
+func RejectReview(ctx context.Context, reviewID, adminID, note string) error {
+	return decideReview(ctx, reviewID, adminID, note, "rejected")
+}
+
+func decideReview(ctx context.Context, reviewID, adminID, note, decision string) error {
+	if strings.TrimSpace(note) == "" {
+		return fmt.Errorf("a note is required for a review decision")
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return err
+	}
+	defer client.Close()
+
+	return resolveReview(ctx, client, reviewID, adminID, note, decision)
+}
+
+// resolveReview applies a decision to a pending review item inside one Firestore transaction.
+// For 'approved' the funds are moved; for 'rejected' and 'expired' the reservation is released.
+func resolveReview(ctx context.Context, client *firestore.Client, reviewID, actor, note, decision string) error {
+	feeConfig, err := config.GetFeeYamlConfig()
+	if err != nil {
+		return fmt.Errorf("unable to load fee configuration: %w", err)
+	}
+	reviewRef := client.Collection("ReviewQueue").Doc(reviewID)
+
+	var item entity.ReviewItem
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		reviewSnap, err := tx.Get(reviewRef)
+		if err != nil {
+			return fmt.Errorf("failed to fetch review document: %v", err)
+		}
+
+		item = entity.ReviewItem{}
+		if err := reviewSnap.DataTo(&item); err != nil {
+			return fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		if item.Status != "pending" {
+			return fmt.Errorf("review item %s is already %s", reviewID, item.Status)
+		}
+
+		senderDoc, err := repository.GetAccountInTx(tx, client, item.SenderAccNo)
+		if err != nil {
+			return err
+		}
+		senderData := senderDoc.Data()
+		senderBalance := repository.FloatField(senderData, "balance")
+		total := item.Amount + item.Fee
+		senderReserved := repository.FloatField(senderData, "reserved") - total
+		senderUpdates := []firestore.Update{
+			{Path: "reserved", Value: senderReserved},
+			{Path: "updatedAt", Value: firestore.ServerTimestamp},
+		}
+
+		var receiverDoc, revenueDoc *firestore.DocumentSnapshot
+		if decision == "approved" {
+			receiverDoc, err = repository.GetAccountInTx(tx, client, item.ReceiverAccNo)
+			if err != nil {
+				return err
+			}
+			if item.Fee > 0 {
+				if revenueDoc, err = repository.GetAccountInTx(tx, client, feeConfig.RevenueAccount); err != nil {
+					return err
+				}
+			}
+			if err := checkAccountStatus(item.SenderAccNo, senderData, true); err != nil {
+				return err
+			}
+			if err := checkAccountStatus(item.ReceiverAccNo, receiverDoc.Data(), false); err != nil {
+				return err
+			}
+		}
+
+		// All reads are done; the status change and its outbox event are the first write
+		transactionStatus := decision
+		if decision == "approved" {
+			transactionStatus = "success"
+		}
+		if err := stageTransactionStatus(tx, client, item.TransactionID, transactionStatus, firestore.Update{Path: "ActionBy", Value: actor}); err != nil {
+			return err
+		}
+
+		var audit []entity.AuditEntry
+		if decision == "approved" {
+			senderBalance -= total
+			receiverBalance := repository.FloatField(receiverDoc.Data(), "balance") + item.Amount
+			now := time.Now().Unix()
+			senderUpdates = append(senderUpdates, repository.BalanceUpdates(senderData, senderBalance)...)
+			senderUpdates = append(senderUpdates, firestore.Update{Path: "last_activity_at", Value: now})
+			if err := tx.Update(receiverDoc.Ref, append(repository.BalanceUpdates(receiverDoc.Data(), receiverBalance),
+				firestore.Update{Path: "last_activity_at", Value: now},
+				firestore.Update{Path: "updatedAt", Value: firestore.ServerTimestamp},
+			)); err != nil {
+				return fmt.Errorf("failed to update receiver's balance: %v", err)
+			}
+			audit = append(audit,
+				balanceAudit(ctx, "balance.debit", item.SenderAccNo, total, senderBalance, senderReserved, item.TransactionID, reviewID),
+				balanceAudit(ctx, "balance.credit", item.ReceiverAccNo, item.Amount, receiverBalance, repository.FloatField(receiverDoc.Data(), "reserved"), item.TransactionID, reviewID),
+			)
+			if revenueDoc != nil {
+				revenueBalance, err := creditFee(tx, revenueDoc, item.Fee)
+				if err != nil {
+					return err
+				}
+				audit = append(audit, balanceAudit(ctx, "fee.credit", feeConfig.RevenueAccount, item.Fee, revenueBalance, repository.FloatField(revenueDoc.Data(), "reserved"), item.TransactionID, reviewID))
+			}
+		} else {
+			audit = append(audit, balanceAudit(ctx, "funds.released", item.SenderAccNo, total, senderBalance, senderReserved, item.TransactionID, reviewID))
+		}
+		if decision != "expired" {
+			audit = append(audit, adminAudit(ctx, "review."+decision, reviewID, note))
+		}
+
+		if err := tx.Update(senderDoc.Ref, senderUpdates); err != nil {
+			return fmt.Errorf("failed to update sender's account: %v", err)
+		}
+		if err := stageAudit(tx, client, audit...); err != nil {
+			return fmt.Errorf("failed to write audit entry: %v", err)
+		}
+
+		return tx.Update(reviewRef, []firestore.Update{
+			{Path: "Status", Value: decision},
+			{Path: "ReviewedBy", Value: actor},
+			{Path: "Note", Value: note},
+			{Path: "ReviewedAt", Value: time.Now().Unix()},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	outcome := decision
+	if decision == "approved" {
+		outcome = "success"
+	}
+	metrics.RecordTransfer(item.PaymentMethod, item.ReceivingMethod, outcome, item.Amount)
+	return nil
+}
+
+// ExpireReviewItems releases every pending review item whose timeout has passed.
+// It returns the number of items that were expired.
+func ExpireReviewItems(ctx context.Context) (int, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return 0, err
+	}
+	defer client.Close()
+
+	iter := client.Collection("ReviewQueue").Where("Status", "==", "pending").Documents(ctx)
+	defer iter.Stop()
+
+	now := time.Now().Unix()
+	expired := 0
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return expired, fmt.Errorf("failed to fetch review queue: %v", err)
+		}
+
+		var item entity.ReviewItem
+		if err := docSnap.DataTo(&item); err != nil {
+			return expired, fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		if item.ExpiresAt > now {
+			continue
+		}
+
+		if err := resolveReview(ctx, client, docSnap.Ref.ID, "system", "review timeout elapsed", "expired"); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("review_id", docSnap.Ref.ID).Msg("Failed to expire review item")
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// RunReviewExpiry periodically expires timed-out review items until the context is cancelled.
+// An expiry pass already in progress is not interrupted by cancellation.
+func RunReviewExpiry(ctx context.Context) {
+	reviewConfig, err := config.GetReviewYamlConfig()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Review expiry worker not started")
+		return
+	}
+
+	interval := reviewConfig.SweepInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := ExpireReviewItems(context.WithoutCancel(ctx))
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("Failed to expire review items")
+			}
+			if expired > 0 {
+				log.Ctx(ctx).Info().Int("expired", expired).Msg("Expired held transactions")
+			}
+		}
+	}
+}
@@ -0,0 +1,591 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"go-transaction/repository"
+	"go-transaction/utils"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/iterator"
+)
+
+// CreateSchedule stores a new schedule for the given user.
+// The transfer must be sent from the user's own account.
+func CreateSchedule(ctx context.Context, requestBody entity.ScheduleRequest, userID string) (*entity.Schedule, error) {
+	if !strings.EqualFold(requestBody.Transfer.SenderID, userID) {
+		return nil, fmt.Errorf("scheduled transfers must be sent by the authenticated user")
+	}
+
+	schedule := &entity.Schedule{
+		OwnerID:   userID,
+		Transfer:  requestBody.Transfer,
+		Frequency: strings.ToLower(requestBody.Frequency),
+		Cron:      requestBody.Cron,
+		StartAt:   requestBody.StartAt,
+		EndAt:     requestBody.EndAt,
+		MaxRuns:   requestBody.MaxRuns,
+		Status:    "active",
+		CreatedAt: time.Now().Unix(),
+	}
+	schedule.Transfer.Pin = ""
+
+	// The first run happens at StartAt, or at the first cron match from then on
+	schedule.NextRunAt = schedule.StartAt
+	if schedule.Frequency == "cron" {
+		next, err := nextOccurrence(schedule, time.Unix(schedule.StartAt-1, 0))
+		if err != nil {
+			return nil, err
+		}
+		schedule.NextRunAt = next
+	}
+	if schedule.NextRunAt == 0 {
+		return nil, fmt.Errorf("schedule has no run before its end date")
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	transfer := requestBody.Transfer
+	if transfer.BeneficiaryID != "" {
+		if err := resolveBeneficiary(ctx, client, &transfer); err != nil {
+			return nil, err
+		}
+	}
+
+	// Transfers can only be scheduled from an account the user holds
+	senderAccNo, receiverAccNo, err := repository.GetUserAccNo(ctx, client, strings.ToUpper(transfer.PaymentMethod), strings.ToUpper(transfer.RecievingMethod),
+		transfer.SenderPaymentDetails, transfer.ReceiverPaymentDetails)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSenderHolder(ctx, client, senderAccNo, userID); err != nil {
+		return nil, err
+	}
+
+	// Runs do not ask for the PIN, so a UPI schedule is only created with the sender's PIN
+	if strings.EqualFold(transfer.PaymentMethod, "UPI") {
+		if err := verifyUPIPin(ctx, client, userID, transfer.SenderPaymentDetails.UPI.UpiId, transfer.Pin); err != nil {
+			return nil, err
+		}
+	}
+
+	// Nor do they ask for a TOTP code, so a transfer that needs one is only scheduled with the sender's code
+	stepUp, err := stepUpReason(ctx, client, "transfer", userID, receiverAccNo, transfer.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if stepUp != "" {
+		if requestBody.Code == "" {
+			return nil, fmt.Errorf("step-up authentication required (%s), a TOTP code is needed to schedule this transfer", stepUp)
+		}
+		if err := verifyTOTPCode(ctx, client, userID, requestBody.Code); err != nil {
+			return nil, err
+		}
+	}
+
+	docRef := client.Collection("Schedule").NewDoc()
+	schedule.ID = docRef.ID
+	if _, err := docRef.Create(ctx, schedule); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to store schedule in Firestore")
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// ListSchedules returns the schedules owned by the given user, newest first.
+func ListSchedules(ctx context.Context, userID string) ([]*entity.Schedule, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	iter := client.Collection("Schedule").Where("OwnerID", "==", userID).Documents(ctx)
+	defer iter.Stop()
+
+	schedules := []*entity.Schedule{}
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Error fetching schedules")
+			return nil, fmt.Errorf("failed to fetch schedules: %v", err)
+		}
+
+		var schedule entity.Schedule
+		if err := docSnap.DataTo(&schedule); err != nil {
+			return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		schedules = append(schedules, &schedule)
+	}
+
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].CreatedAt > schedules[j].CreatedAt })
+
+	return schedules, nil
+}
+
+// GetSchedule returns a schedule to its owner or an admin.
+func GetSchedule(ctx context.Context, scheduleID, role, userID string) (*entity.Schedule, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	return getOwnedSchedule(ctx, client, scheduleID, role, userID)
+}
+
+func getOwnedSchedule(ctx context.Context, client *firestore.Client, scheduleID, role, userID string) (*entity.Schedule, error) {
+	docSnap, err := client.Collection("Schedule").Doc(scheduleID).Get(ctx)
+	if err != nil {
+		if err.Error() == "rpc error: code = NotFound desc = " {
+			return nil, fmt.Errorf("no schedule found with ID: %s", scheduleID)
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching schedule document")
+		return nil, fmt.Errorf("failed to fetch schedule document: %v", err)
+	}
+
+	var schedule entity.Schedule
+	if err := docSnap.DataTo(&schedule); err != nil {
+		return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+	}
+
+	if !strings.EqualFold(role, "ADMIN") && !strings.EqualFold(schedule.OwnerID, userID) {
+		return nil, fmt.Errorf("Invalid User : %s %s", userID, role)
+	}
+
+	return &schedule, nil
+}
+
+// ListScheduleRuns returns the recorded runs of a schedule, most recent first.
+func ListScheduleRuns(ctx context.Context, scheduleID, role, userID string) ([]*entity.ScheduleRun, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	if _, err := getOwnedSchedule(ctx, client, scheduleID, role, userID); err != nil {
+		return nil, err
+	}
+
+	iter := client.Collection("ScheduleRun").Where("ScheduleID", "==", scheduleID).Documents(ctx)
+	defer iter.Stop()
+
+	runs := []*entity.ScheduleRun{}
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Error fetching schedule runs")
+			return nil, fmt.Errorf("failed to fetch schedule runs: %v", err)
+		}
+
+		var run entity.ScheduleRun
+		if err := docSnap.DataTo(&run); err != nil {
+			return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		runs = append(runs, &run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].ScheduledFor > runs[j].ScheduledFor })
+
+	return runs, nil
+}
+
+// UpdateScheduleStatus pauses, resumes or cancels a schedule.
+// A resumed schedule skips any runs that fell due while it was paused.
+func UpdateScheduleStatus(ctx context.Context, scheduleID, action, role, userID string) (*entity.Schedule, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	scheduleRef := client.Collection("Schedule").Doc(scheduleID)
+	var schedule entity.Schedule
+
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docSnap, err := tx.Get(scheduleRef)
+		if err != nil {
+			return fmt.Errorf("failed to fetch schedule document: %v", err)
+		}
+		schedule = entity.Schedule{}
+		if err := docSnap.DataTo(&schedule); err != nil {
+			return fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+
+		if !strings.EqualFold(role, "ADMIN") && !strings.EqualFold(schedule.OwnerID, userID) {
+			return fmt.Errorf("Invalid User : %s %s", userID, role)
+		}
+
+		updates := []firestore.Update{}
+		switch strings.ToLower(action) {
+		case "pause":
+			if schedule.Status != "active" {
+				return fmt.Errorf("only active schedules can be paused, schedule is %s", schedule.Status)
+			}
+			schedule.Status = "paused"
+		case "resume":
+			if schedule.Status != "paused" {
+				return fmt.Errorf("only paused schedules can be resumed, schedule is %s", schedule.Status)
+			}
+			schedule.Status = "active"
+			if schedule.NextRunAt <= time.Now().Unix() {
+				next, err := nextOccurrence(&schedule, time.Now())
+				if err != nil {
+					return err
+				}
+				schedule.NextRunAt = next
+				if next == 0 {
+					schedule.Status = "completed"
+				}
+				updates = append(updates, firestore.Update{Path: "NextRunAt", Value: next})
+			}
+		case "cancel":
+			if schedule.Status != "active" && schedule.Status != "paused" {
+				return fmt.Errorf("schedule is already %s", schedule.Status)
+			}
+			schedule.Status = "cancelled"
+		default:
+			return fmt.Errorf("invalid schedule action: %s", action)
+		}
+
+		updates = append(updates, firestore.Update{Path: "Status", Value: schedule.Status})
+		return tx.Update(scheduleRef, updates)
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("schedule_id", scheduleID).Msg("Failed to update schedule status")
+		return nil, err
+	}
+	if strings.EqualFold(role, "ADMIN") && !strings.EqualFold(schedule.OwnerID, userID) {
+		recordAudit(ctx, client, adminAudit(ctx, "schedule."+strings.ToLower(action), scheduleID, ""))
+	}
+
+	return &schedule, nil
+}
+
+// nextOccurrence returns the Unix time of the first run strictly after the given time,
+// or 0 if the schedule has no further runs before its end date.
+func nextOccurrence(schedule *entity.Schedule, after time.Time) (int64, error) {
+	start := time.Unix(schedule.StartAt, 0)
+	var next time.Time
+
+	switch schedule.Frequency {
+	case "once":
+		return 0, nil
+	case "daily", "weekly", "monthly":
+		// Walk forward from the start so monthly runs keep the original day of the month
+		for n := 1; !next.After(after); n++ {
+			switch schedule.Frequency {
+			case "daily":
+				next = start.AddDate(0, 0, n)
+			case "weekly":
+				next = start.AddDate(0, 0, 7*n)
+			case "monthly":
+				next = addMonths(start, n)
+			}
+		}
+	case "cron":
+		cron, err := utils.ParseCron(schedule.Cron)
+		if err != nil {
+			return 0, err
+		}
+		next = cron.Next(after)
+		if next.IsZero() {
+			return 0, nil
+		}
+	default:
+		return 0, fmt.Errorf("invalid schedule frequency: %s", schedule.Frequency)
+	}
+
+	if schedule.EndAt != 0 && next.Unix() > schedule.EndAt {
+		return 0, nil
+	}
+	return next.Unix(), nil
+}
+
+// addMonths adds n months to t, clamping to the last day of the month (e.g. Jan 31 -> Feb 28).
+func addMonths(t time.Time, n int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
+
+// claimScheduleRun atomically records a run for a due schedule and advances it to its next occurrence.
+// The run document ID is derived from the scheduled time, so a run can never be claimed twice,
+// even across restarts. It returns nil if the schedule is no longer due.
+func claimScheduleRun(ctx context.Context, client *firestore.Client, scheduleID string, now time.Time) (*entity.Schedule, *entity.ScheduleRun, error) {
+	scheduleRef := client.Collection("Schedule").Doc(scheduleID)
+	var schedule entity.Schedule
+	var run *entity.ScheduleRun
+
+	err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		run = nil
+		docSnap, err := tx.Get(scheduleRef)
+		if err != nil {
+			return fmt.Errorf("failed to fetch schedule document: %v", err)
+		}
+		schedule = entity.Schedule{}
+		if err := docSnap.DataTo(&schedule); err != nil {
+			return fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+
+		if schedule.Status != "active" || schedule.NextRunAt == 0 || schedule.NextRunAt > now.Unix() {
+			return nil
+		}
+
+		runRef := client.Collection("ScheduleRun").Doc(fmt.Sprintf("%s_%d", scheduleID, schedule.NextRunAt))
+		run = &entity.ScheduleRun{
+			ID:           runRef.ID,
+			ScheduleID:   scheduleID,
+			ScheduledFor: schedule.NextRunAt,
+			StartedAt:    now.Unix(),
+			Status:       "running",
+		}
+
+		// Runs missed while the service was down are skipped rather than replayed in a burst
+		next, err := nextOccurrence(&schedule, now)
+		if err != nil {
+			return err
+		}
+
+		schedule.RunCount++
+		schedule.LastRunAt = now.Unix()
+		schedule.NextRunAt = next
+		if next == 0 || (schedule.MaxRuns > 0 && schedule.RunCount >= schedule.MaxRuns) {
+			schedule.NextRunAt = 0
+			schedule.Status = "completed"
+		}
+
+		if err := tx.Create(runRef, run); err != nil {
+			return err
+		}
+		return tx.Update(scheduleRef, []firestore.Update{
+			{Path: "RunCount", Value: schedule.RunCount},
+			{Path: "LastRunAt", Value: schedule.LastRunAt},
+			{Path: "NextRunAt", Value: schedule.NextRunAt},
+			{Path: "Status", Value: schedule.Status},
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &schedule, run, nil
+}
+
+// ExecuteDueSchedules runs every active schedule whose next run time has passed.
+// It returns the number of runs that were executed.
+func ExecuteDueSchedules(ctx context.Context) (int, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return 0, err
+	}
+	defer client.Close()
+
+	iter := client.Collection("Schedule").Where("Status", "==", "active").Documents(ctx)
+	defer iter.Stop()
+
+	now := time.Now()
+	executed := 0
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return executed, fmt.Errorf("failed to fetch schedules: %v", err)
+		}
+
+		nextRunAt, _ := docSnap.DataAt("NextRunAt")
+		if due, ok := nextRunAt.(int64); !ok || due == 0 || due > now.Unix() {
+			continue
+		}
+
+		schedule, run, err := claimScheduleRun(ctx, client, docSnap.Ref.ID, now)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("schedule_id", docSnap.Ref.ID).Msg("Failed to claim schedule run")
+			continue
+		}
+		if run == nil {
+			continue
+		}
+
+		result, err := initiateTransaction(ctx, schedule.Transfer, transferScheduled)
+		runUpdates := []firestore.Update{{Path: "FinishedAt", Value: time.Now().Unix()}}
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("schedule_id", schedule.ID).Msg("Scheduled transfer failed")
+			runUpdates = append(runUpdates,
+				firestore.Update{Path: "Status", Value: "fail"},
+				firestore.Update{Path: "Error", Value: err.Error()},
+			)
+		} else {
+			runUpdates = append(runUpdates,
+				firestore.Update{Path: "Status", Value: result.Status},
+				firestore.Update{Path: "TransactionID", Value: result.TransactionID},
+			)
+		}
+
+		if _, err := client.Collection("ScheduleRun").Doc(run.ID).Update(ctx, runUpdates); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("run_id", run.ID).Msg("Failed to record schedule run result")
+		}
+		executed++
+	}
+
+	return executed, nil
+}
+
+// defaultScheduleRunTimeout is used when scheduler.runtimeout is not configured.
+const defaultScheduleRunTimeout = 15 * time.Minute
+
+// FailStaleScheduleRuns marks runs that have been running for longer than the configured run timeout
+// as failed, and tells the schedule's owner. A run is only left running when the scheduler stopped
+// half way, so whether its transfer was made is unknown; the error says so and the owner can check
+// their transactions. It returns the number of runs that were failed.
+func FailStaleScheduleRuns(ctx context.Context) (int, error) {
+	schedulerConfig, err := config.GetSchedulerYamlConfig()
+	if err != nil {
+		return 0, err
+	}
+	timeout := schedulerConfig.RunTimeout
+	if timeout <= 0 {
+		timeout = defaultScheduleRunTimeout
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return 0, err
+	}
+	defer client.Close()
+
+	iter := client.Collection("ScheduleRun").Where("Status", "==", "running").Documents(ctx)
+	defer iter.Stop()
+
+	now := time.Now()
+	failed := 0
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return failed, fmt.Errorf("failed to fetch schedule runs: %v", err)
+		}
+
+		var run entity.ScheduleRun
+		if err := docSnap.DataTo(&run); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("run_id", docSnap.Ref.ID).Msg("Failed to map Firestore document")
+			continue
+		}
+		if now.Sub(time.Unix(run.StartedAt, 0)) < timeout {
+			continue
+		}
+
+		runError := fmt.Sprintf("run did not finish within %s, the transfer may or may not have been made", timeout)
+		err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			current, err := tx.Get(docSnap.Ref)
+			if err != nil {
+				return err
+			}
+			// The run may have finished since it was read
+			if status, _ := current.DataAt("Status"); status != "running" {
+				return errors.New("run is no longer running")
+			}
+			return tx.Update(docSnap.Ref, []firestore.Update{
+				{Path: "Status", Value: "fail"},
+				{Path: "Error", Value: runError},
+				{Path: "FinishedAt", Value: now.Unix()},
+			})
+		})
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("run_id", docSnap.Ref.ID).Msg("Stale schedule run not failed")
+			continue
+		}
+		failed++
+		log.Ctx(ctx).Warn().Str("run_id", run.ID).Str("schedule_id", run.ScheduleID).Msg("Failed stale schedule run")
+
+		scheduleSnap, err := client.Collection("Schedule").Doc(run.ScheduleID).Get(ctx)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("schedule_id", run.ScheduleID).Msg("Error fetching schedule document")
+			continue
+		}
+		if ownerID, _ := scheduleSnap.DataAt("OwnerID"); ownerID != nil {
+			notify(ctx, client, entity.Notification{
+				UserID:  fmt.Sprint(ownerID),
+				Type:    "schedule.run_failed",
+				Message: fmt.Sprintf("A scheduled transfer due at %s did not finish and was marked failed; check your transactions to see whether it was made", time.Unix(run.ScheduledFor, 0).UTC().Format(time.RFC3339)),
+			})
+		}
+	}
+
+	return failed, nil
+}
+
+// RunScheduler periodically executes due schedules until the context is cancelled.
+// Cancellation stops the polling; a transfer that has already been started runs to completion.
+func RunScheduler(ctx context.Context) {
+	schedulerConfig, err := config.GetSchedulerYamlConfig()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Scheduler not started")
+		return
+	}
+
+	interval := schedulerConfig.PollInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			failed, err := FailStaleScheduleRuns(context.WithoutCancel(ctx))
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("Failed to sweep stale schedule runs")
+			}
+			if failed > 0 {
+				log.Ctx(ctx).Warn().Int("failed", failed).Msg("Failed stale schedule runs")
+			}
+
+			executed, err := ExecuteDueSchedules(context.WithoutCancel(ctx))
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("Failed to execute due schedules")
+			}
+			if executed > 0 {
+				log.Ctx(ctx).Info().Int("executed", executed).Msg("Executed scheduled transfers")
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"go-transaction/entity"
+	"testing"
+	"time"
+)
+
+func TestAddMonthsClampsToLastDay(t *testing.T) {
+	tests := []struct {
+		start time.Time
+		n     int
+		want  time.Time
+	}{
+		{time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC), 1, time.Date(2024, time.February, 15, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC), 1, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2023, time.January, 31, 9, 0, 0, 0, time.UTC), 1, time.Date(2023, time.February, 28, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC), 2, time.Date(2024, time.March, 31, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2024, time.March, 31, 9, 0, 0, 0, time.UTC), 1, time.Date(2024, time.April, 30, 9, 0, 0, 0, time.UTC)},
+		{time.Date(2024, time.November, 30, 9, 0, 0, 0, time.UTC), 3, time.Date(2025, time.February, 28, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got := addMonths(tt.start, tt.n); !got.Equal(tt.want) {
+			t.Errorf("addMonths(%s, %d) = %s, want %s", tt.start, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	// nextOccurrence works in local time, so the expectations are built in local time too
+	at := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.Local)
+	}
+	start := at(2024, time.January, 31, 9)
+
+	tests := []struct {
+		name     string
+		schedule entity.Schedule
+		after    time.Time
+		want     time.Time
+	}{
+		{"once has no further runs", entity.Schedule{Frequency: "once"}, start, time.Time{}},
+		{"daily", entity.Schedule{Frequency: "daily"}, start, at(2024, time.February, 1, 9)},
+		{"daily skips missed runs", entity.Schedule{Frequency: "daily"}, at(2024, time.February, 5, 12), at(2024, time.February, 6, 9)},
+		{"weekly", entity.Schedule{Frequency: "weekly"}, start, at(2024, time.February, 7, 9)},
+		{"monthly clamps to the end of the month", entity.Schedule{Frequency: "monthly"}, start, at(2024, time.February, 29, 9)},
+		{"monthly keeps the original day", entity.Schedule{Frequency: "monthly"}, at(2024, time.February, 29, 9), at(2024, time.March, 31, 9)},
+		{"cron", entity.Schedule{Frequency: "cron", Cron: "0 9 1 * *"}, start, at(2024, time.February, 1, 9)},
+		{"past the end date", entity.Schedule{Frequency: "weekly", EndAt: at(2024, time.February, 6, 9).Unix()}, start, time.Time{}},
+		{"on the end date", entity.Schedule{Frequency: "weekly", EndAt: at(2024, time.February, 7, 9).Unix()}, start, at(2024, time.February, 7, 9)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.schedule.StartAt = start.Unix()
+			got, err := nextOccurrence(&tt.schedule, tt.after)
+			if err != nil {
+				t.Fatalf("nextOccurrence returned %v", err)
+			}
+			want := int64(0)
+			if !tt.want.IsZero() {
+				want = tt.want.Unix()
+			}
+			if got != want {
+				t.Fatalf("nextOccurrence = %d, want %d (%s)", got, want, tt.want)
+			}
+		})
+	}
+
+	if _, err := nextOccurrence(&entity.Schedule{Frequency: "yearly", StartAt: start.Unix()}, start); err == nil {
+		t.Fatal("nextOccurrence accepted the unknown frequency yearly")
+	}
+	if _, err := nextOccurrence(&entity.Schedule{Frequency: "cron", Cron: "0 9 * *", StartAt: start.Unix()}, start); err == nil {
+		t.Fatal("nextOccurrence accepted an invalid cron expression")
+	}
+}
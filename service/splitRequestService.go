@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"go-transaction/metrics"
+	"go-transaction/repository"
+	"math"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+)
+
+// splitShares divides a split request between its payers and returns each payer's share and the total.
+// Shares are rounded to the paisa; any rounding remainder is added to the first payer so the shares
+// always add up to the total.
+func splitShares(requestBody entity.SplitRequest) ([]float64, float64, error) {
+	shares := make([]float64, len(requestBody.Payers))
+	total := requestBody.TotalAmount
+
+	switch strings.ToLower(requestBody.SplitType) {
+	case "equal":
+		for i := range shares {
+			shares[i] = math.Floor(total/float64(len(shares))*100) / 100
+		}
+	case "percentage":
+		percentTotal := 0.0
+		for i, payer := range requestBody.Payers {
+			percentTotal += payer.Percentage
+			shares[i] = math.Floor(total*payer.Percentage) / 100
+		}
+		if math.Abs(percentTotal-100) > 0.0001 {
+			return nil, 0, fmt.Errorf("percentages must add up to 100, got %v", percentTotal)
+		}
+	case "exact":
+		total = 0
+		for i, payer := range requestBody.Payers {
+			shares[i] = payer.Amount
+			total += payer.Amount
+		}
+		total = math.Round(total*100) / 100
+		if requestBody.TotalAmount > 0 && math.Abs(total-requestBody.TotalAmount) > 0.001 {
+			return nil, 0, fmt.Errorf("amounts add up to %v, expected %v", total, requestBody.TotalAmount)
+		}
+	default:
+		return nil, 0, fmt.Errorf("invalid split type: %s", requestBody.SplitType)
+	}
+
+	sum := 0.0
+	for _, share := range shares {
+		sum += share
+	}
+	shares[0] = math.Round((shares[0]+total-sum)*100) / 100
+
+	for i, share := range shares {
+		if share <= 0 {
+			return nil, 0, fmt.Errorf("share of payer %s must be greater than zero", requestBody.Payers[i].PayerID)
+		}
+	}
+
+	return shares, total, nil
+}
+
+// CreateSplitRequest splits a bill between several payers: one payment request is created for each payer
+// and all of them are linked to a new PaymentRequestGroup, which is returned.
+func CreateSplitRequest(ctx context.Context, requestBody entity.SplitRequest, userID string) (*entity.PaymentRequestGroup, error) {
+	if !strings.EqualFold(requestBody.RequesterID, userID) {
+		return nil, fmt.Errorf("Invalid User : %s", userID)
+	}
+
+	shares, total, err := splitShares(requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresIn, err := requestExpiry(requestBody.ExpiresIn)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	// Resolve every payer's account before writing anything, so an unknown payer fails the whole split
+	payerAccNos := make([]string, len(requestBody.Payers))
+	var requesterAccNo string
+	for i, payer := range requestBody.Payers {
+		payerAccNos[i], requesterAccNo, err = repository.GetUserAccNo(ctx, client,
+			strings.ToUpper(payer.PayerPaymentMethod),
+			strings.ToUpper(requestBody.RequesterPaymentMethod),
+			payer.PayerPaymentDetails,
+			requestBody.RequesterPaymentDetails,
+		)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("payer_id", payer.PayerID).Msg("Failed to fetch account numbers")
+			return nil, err
+		}
+	}
+
+	groupRef := client.Collection("PaymentRequestGroup").NewDoc()
+	group := &entity.PaymentRequestGroup{
+		ID:          groupRef.ID,
+		RequesterID: requestBody.RequesterID,
+		TotalAmount: total,
+		SplitType:   strings.ToLower(requestBody.SplitType),
+		Status:      "open",
+		CreatedAt:   time.Now().Unix(),
+	}
+
+	// The group and every payer's request are written together, so a failed split leaves nothing behind
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		group.Members = nil
+		for i, payer := range requestBody.Payers {
+			requestID, err := stagePaymentRequest(tx, client, entity.MakePaymentRequest{
+				PayerID:                 payer.PayerID,
+				RequesterID:             requestBody.RequesterID,
+				Amount:                  shares[i],
+				PayerPaymentMethod:      payer.PayerPaymentMethod,
+				RequesterPaymentMethod:  requestBody.RequesterPaymentMethod,
+				PayerPaymentDetails:     payer.PayerPaymentDetails,
+				RequesterPaymentDetails: requestBody.RequesterPaymentDetails,
+			}, payerAccNos[i], requesterAccNo, group.ID, expiresIn)
+			if err != nil {
+				return fmt.Errorf("failed to create payment request for %s: %v", payer.PayerID, err)
+			}
+
+			group.Members = append(group.Members, entity.GroupMember{
+				PayerID:   payer.PayerID,
+				RequestID: requestID,
+				Amount:    shares[i],
+			})
+		}
+		return tx.Create(groupRef, group)
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to store split request")
+		return nil, fmt.Errorf("failed to store split request: %v", err)
+	}
+	for range group.Members {
+		metrics.RecordPaymentRequest("created")
+	}
+
+	return group, nil
+}
+
+// GetSplitRequest returns a split group with the status of each payer's request and the amounts
+// collected and outstanding. It is visible to the requester, the group's payers and admins.
+func GetSplitRequest(ctx context.Context, groupID, role, userID string) (*entity.PaymentRequestGroupDetails, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	group, err := getPaymentRequestGroup(ctx, client, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := strings.EqualFold(role, "ADMIN") || strings.EqualFold(group.RequesterID, userID)
+	for _, member := range group.Members {
+		if strings.EqualFold(member.PayerID, userID) {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("Invalid User : %s %s", userID, role)
+	}
+
+	details := &entity.PaymentRequestGroupDetails{Group: group, Requests: []*entity.PaymentRequestDetails{}}
+	for _, member := range group.Members {
+		docSnap, err := client.Collection("TransactionRequest").Doc(member.RequestID).Get(ctx)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("request_id", member.RequestID).Msg("Error fetching request document")
+			return nil, fmt.Errorf("failed to fetch request document: %v", err)
+		}
+
+		var request entity.TransactionRequest
+		if err := docSnap.DataTo(&request); err != nil {
+			return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		request.ID = docSnap.Ref.ID
+
+		requestDetails := paymentRequestDetails(&request)
+		switch requestDetails.Status {
+		case "accepted":
+			details.Collected += requestDetails.Amount
+		case "pending":
+			details.Outstanding += requestDetails.Amount
+		}
+		details.Requests = append(details.Requests, requestDetails)
+	}
+
+	// Groups settled before closing was saved are closed the first time they are read
+	if group.Status == "open" && details.Outstanding == 0 {
+		group.Status = "closed"
+		closeSettledGroup(ctx, client, group.ID)
+	}
+
+	return details, nil
+}
+
+// CancelSplitRequest cancels every still-pending request of a split group, together with the linked
+// transactions, and marks the group cancelled. Requests that were already paid are left untouched.
+func CancelSplitRequest(ctx context.Context, groupID, role, userID string) error {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return err
+	}
+	defer client.Close()
+
+	group, err := getPaymentRequestGroup(ctx, client, groupID)
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(role, "ADMIN") && !strings.EqualFold(group.RequesterID, userID) {
+		return fmt.Errorf("Invalid User : %s %s", userID, role)
+	}
+	if group.Status == "cancelled" {
+		return fmt.Errorf("split request %s is already cancelled", groupID)
+	}
+
+	for _, member := range group.Members {
+		request, err := cancelGroupRequest(ctx, client, member.RequestID, userID)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("request_id", member.RequestID).Msg("Split request not cancelled")
+			continue
+		}
+		enqueueWebhookEvent(ctx, client, request.From, "payment_request.cancelled", paymentRequestDetails(request))
+
+		notify(ctx, client, entity.Notification{
+			UserID:    member.PayerID,
+			Type:      "payment_request.cancelled",
+			RequestID: member.RequestID,
+			Message:   fmt.Sprintf("Payment request of %v was cancelled by the requester", member.Amount),
+		})
+	}
+
+	if _, err := client.Collection("PaymentRequestGroup").Doc(groupID).Update(ctx, []firestore.Update{
+		{Path: "Status", Value: "cancelled"},
+	}); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to update payment request group")
+		return fmt.Errorf("failed to update payment request group: %v", err)
+	}
+	if strings.EqualFold(role, "ADMIN") && !strings.EqualFold(group.RequesterID, userID) {
+		recordAudit(ctx, client, adminAudit(ctx, "split_request.cancelled", groupID, ""))
+	}
+
+	return nil
+}
+
+// getPaymentRequestGroup fetches a split group by ID.
+func getPaymentRequestGroup(ctx context.Context, client *firestore.Client, groupID string) (*entity.PaymentRequestGroup, error) {
+	docSnap, err := client.Collection("PaymentRequestGroup").Doc(groupID).Get(ctx)
+	if err != nil {
+		if err.Error() == "rpc error: code = NotFound desc = " {
+			return nil, fmt.Errorf("no split request found with ID: %s", groupID)
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching payment request group")
+		return nil, fmt.Errorf("failed to fetch payment request group: %v", err)
+	}
+
+	var group entity.PaymentRequestGroup
+	if err := docSnap.DataTo(&group); err != nil {
+		return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+	}
+	group.ID = docSnap.Ref.ID
+	return &group, nil
+}
+
+// closeSettledGroup marks an open split group closed once none of its requests is pending any more.
+// It is called whenever a member request settles; failures are logged, as the request itself has settled.
+func closeSettledGroup(ctx context.Context, client *firestore.Client, groupID string) {
+	groupRef := client.Collection("PaymentRequestGroup").Doc(groupID)
+
+	err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docSnap, err := tx.Get(groupRef)
+		if err != nil {
+			return err
+		}
+		var group entity.PaymentRequestGroup
+		if err := docSnap.DataTo(&group); err != nil {
+			return fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		if group.Status != "open" {
+			return nil
+		}
+
+		requestRefs := make([]*firestore.DocumentRef, len(group.Members))
+		for i, member := range group.Members {
+			requestRefs[i] = client.Collection("TransactionRequest").Doc(member.RequestID)
+		}
+		requestSnaps, err := tx.GetAll(requestRefs)
+		if err != nil {
+			return err
+		}
+		for _, requestSnap := range requestSnaps {
+			// Requests created before statuses were tracked have no Status field and are still pending
+			status, _ := requestSnap.DataAt("Status")
+			if status, _ := status.(string); status == "pending" || status == "" {
+				return nil
+			}
+		}
+
+		return tx.Update(groupRef, []firestore.Update{{Path: "Status", Value: "closed"}})
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("group_id", groupID).Msg("Failed to close payment request group")
+	}
+}
+
+// cancelGroupRequest cancels a single pending request of a group and its linked transaction,
+// and returns the cancelled request.
+// It holds the same per-request lock as PaymentRequestAction so it cannot interleave with an Accept.
+func cancelGroupRequest(ctx context.Context, client *firestore.Client, requestID, userID string) (*entity.TransactionRequest, error) {
+	transactionLock := GetTransactionLock(requestID)
+	transactionLock.Lock()
+	defer transactionLock.Unlock()
+
+	requestRef := client.Collection("TransactionRequest").Doc(requestID)
+
+	var request entity.TransactionRequest
+	err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docSnap, err := tx.Get(requestRef)
+		if err != nil {
+			return err
+		}
+
+		request = entity.TransactionRequest{}
+		if err := docSnap.DataTo(&request); err != nil {
+			return fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		if request.Status != "pending" && request.Status != "" {
+			return fmt.Errorf("request is already %s", request.Status)
+		}
+
+		if request.TransactionID != "" {
+			if err := stageTransactionStatus(tx, client, request.TransactionID, "cancel", firestore.Update{Path: "ActionBy", Value: userID}); err != nil {
+				return err
+			}
+		}
+		return tx.Update(requestRef, []firestore.Update{
+			{Path: "Status", Value: "cancelled"},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.RecordPaymentRequest("cancelled")
+
+	request.ID = requestID
+	request.Status = "cancelled"
+	return &request, nil
+}
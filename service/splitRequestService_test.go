@@ -0,0 +1,128 @@
+package service
+
+import (
+	"go-transaction/entity"
+	"math"
+	"testing"
+)
+
+func TestSplitShares(t *testing.T) {
+	payers := func(n int) []entity.SplitPayer {
+		return make([]entity.SplitPayer, n)
+	}
+	withPercentages := func(percentages ...float64) []entity.SplitPayer {
+		split := make([]entity.SplitPayer, len(percentages))
+		for i, percentage := range percentages {
+			split[i].Percentage = percentage
+		}
+		return split
+	}
+	withAmounts := func(amounts ...float64) []entity.SplitPayer {
+		split := make([]entity.SplitPayer, len(amounts))
+		for i, amount := range amounts {
+			split[i].Amount = amount
+		}
+		return split
+	}
+
+	tests := []struct {
+		name       string
+		request    entity.SplitRequest
+		wantShares []float64
+		wantTotal  float64
+		wantErr    bool
+	}{
+		{
+			name:       "equal split evenly",
+			request:    entity.SplitRequest{SplitType: "equal", TotalAmount: 90, Payers: payers(3)},
+			wantShares: []float64{30, 30, 30},
+			wantTotal:  90,
+		},
+		{
+			name:       "equal remainder goes to the first payer",
+			request:    entity.SplitRequest{SplitType: "EQUAL", TotalAmount: 100, Payers: payers(3)},
+			wantShares: []float64{33.34, 33.33, 33.33},
+			wantTotal:  100,
+		},
+		{
+			name:    "equal share rounding to zero",
+			request: entity.SplitRequest{SplitType: "equal", TotalAmount: 0.01, Payers: payers(2)},
+			wantErr: true,
+		},
+		{
+			name:       "percentage",
+			request:    entity.SplitRequest{SplitType: "percentage", TotalAmount: 200, Payers: withPercentages(50, 30, 20)},
+			wantShares: []float64{100, 60, 40},
+			wantTotal:  200,
+		},
+		{
+			name:       "percentage remainder goes to the first payer",
+			request:    entity.SplitRequest{SplitType: "percentage", TotalAmount: 10.01, Payers: withPercentages(33.33, 33.33, 33.34)},
+			wantShares: []float64{3.35, 3.33, 3.33},
+			wantTotal:  10.01,
+		},
+		{
+			name:    "percentages not adding up to 100",
+			request: entity.SplitRequest{SplitType: "percentage", TotalAmount: 100, Payers: withPercentages(50, 40)},
+			wantErr: true,
+		},
+		{
+			name:       "exact amounts set the total",
+			request:    entity.SplitRequest{SplitType: "exact", Payers: withAmounts(10.5, 20.25)},
+			wantShares: []float64{10.5, 20.25},
+			wantTotal:  30.75,
+		},
+		{
+			name:       "exact amounts matching the total",
+			request:    entity.SplitRequest{SplitType: "exact", TotalAmount: 30.75, Payers: withAmounts(10.5, 20.25)},
+			wantShares: []float64{10.5, 20.25},
+			wantTotal:  30.75,
+		},
+		{
+			name:    "exact amounts not matching the total",
+			request: entity.SplitRequest{SplitType: "exact", TotalAmount: 40, Payers: withAmounts(10.5, 20.25)},
+			wantErr: true,
+		},
+		{
+			name:    "exact zero share",
+			request: entity.SplitRequest{SplitType: "exact", Payers: withAmounts(10, 0)},
+			wantErr: true,
+		},
+		{
+			name:    "unknown split type",
+			request: entity.SplitRequest{SplitType: "weighted", TotalAmount: 100, Payers: payers(2)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares, total, err := splitShares(tt.request)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("splitShares returned %v, %v; want an error", shares, total)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitShares returned %v", err)
+			}
+			if math.Abs(total-tt.wantTotal) > 1e-9 {
+				t.Fatalf("total is %v, want %v", total, tt.wantTotal)
+			}
+			if len(shares) != len(tt.wantShares) {
+				t.Fatalf("shares are %v, want %v", shares, tt.wantShares)
+			}
+			sum := 0.0
+			for i, share := range shares {
+				if math.Abs(share-tt.wantShares[i]) > 1e-9 {
+					t.Fatalf("shares are %v, want %v", shares, tt.wantShares)
+				}
+				sum += share
+			}
+			if math.Abs(sum-total) > 1e-9 {
+				t.Fatalf("shares %v add up to %v, not the total %v", shares, sum, total)
+			}
+		})
+	}
+}
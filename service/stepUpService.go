@@ -0,0 +1,448 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"go-transaction/metrics"
+	"go-transaction/utils"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/iterator"
+)
+
+// StepUpRequiredError is returned when a payment request acceptance must be confirmed with a TOTP code.
+// The challenge has been issued; confirming it with ConfirmChallenge completes the acceptance.
+type StepUpRequiredError struct {
+	Challenge *entity.StepUpChallenge
+}
+
+func (e *StepUpRequiredError) Error() string {
+	return fmt.Sprintf("step-up authentication required (%s), confirm challenge %s", e.Challenge.Reason, e.Challenge.ID)
+}
+
+// EnrollTOTP starts TOTP enrollment for a user and returns the secret for the authenticator app.
+// Starting again replaces an unconfirmed secret; a confirmed enrollment cannot be replaced this way.
+func EnrollTOTP(ctx context.Context, userID string) (*entity.TOTPSetup, error) {
+	stepUpConfig, err := config.GetStepUpYamlConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load step-up configuration: %w", err)
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	secret, url, err := utils.NewTOTPSecret(stepUpConfig.Issuer, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %v", err)
+	}
+
+	enrollmentRef := client.Collection("TOTPEnrollment").Doc(userID)
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docSnap, err := tx.Get(enrollmentRef)
+		if err != nil && err.Error() != "rpc error: code = NotFound desc = " {
+			return fmt.Errorf("failed to fetch TOTP enrollment: %v", err)
+		}
+		if err == nil {
+			if confirmed, _ := docSnap.DataAt("Confirmed"); confirmed == true {
+				return fmt.Errorf("two-factor authentication is already enrolled for %s", userID)
+			}
+		}
+
+		return tx.Set(enrollmentRef, entity.TOTPEnrollment{
+			UserID:    userID,
+			Secret:    secret,
+			CreatedAt: time.Now().Unix(),
+		})
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to store TOTP enrollment")
+		return nil, err
+	}
+
+	return &entity.TOTPSetup{Secret: secret, URL: url}, nil
+}
+
+// ConfirmTOTPEnrollment completes enrollment once the user proves the authenticator app produces valid codes.
+func ConfirmTOTPEnrollment(ctx context.Context, userID, code string) error {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return err
+	}
+	defer client.Close()
+
+	enrollmentRef := client.Collection("TOTPEnrollment").Doc(userID)
+	return client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		enrollment, err := getEnrollment(tx, enrollmentRef, userID)
+		if err != nil {
+			return err
+		}
+		if enrollment.Confirmed {
+			return fmt.Errorf("two-factor authentication is already enrolled for %s", userID)
+		}
+
+		step, ok := utils.MatchTOTP(enrollment.Secret, code, time.Now())
+		if !ok {
+			return fmt.Errorf("invalid code")
+		}
+
+		return tx.Update(enrollmentRef, []firestore.Update{
+			{Path: "Confirmed", Value: true},
+			{Path: "ConfirmedAt", Value: time.Now().Unix()},
+			{Path: "LastUsedStep", Value: step},
+		})
+	})
+}
+
+// ConfirmChallenge checks a TOTP code against a pending challenge of the user and, if it matches,
+// completes the transfer, hold or payment request acceptance the challenge was issued for.
+// Wrong codes count towards the challenge's attempt limit; a code can only be used once.
+func ConfirmChallenge(ctx context.Context, challengeID, code, userID string) (*entity.TransactionResult, error) {
+	stepUpConfig, err := config.GetStepUpYamlConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load step-up configuration: %w", err)
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	challengeRef := client.Collection("StepUpChallenge").Doc(challengeID)
+	enrollmentRef := client.Collection("TOTPEnrollment").Doc(userID)
+
+	// A wrong code must still be counted, so it is reported through rejected rather than by
+	// failing the Firestore transaction, which would roll the count back
+	var challenge entity.StepUpChallenge
+	var rejected error
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		rejected = nil
+
+		docSnap, err := tx.Get(challengeRef)
+		if err != nil {
+			if err.Error() == "rpc error: code = NotFound desc = " {
+				return fmt.Errorf("no challenge found with ID: %s", challengeID)
+			}
+			return fmt.Errorf("failed to fetch challenge: %v", err)
+		}
+		challenge = entity.StepUpChallenge{}
+		if err := docSnap.DataTo(&challenge); err != nil {
+			return fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		if !strings.EqualFold(challenge.UserID, userID) {
+			return fmt.Errorf("Invalid User : %s", userID)
+		}
+		if challenge.Status != "pending" {
+			return fmt.Errorf("challenge %s is already %s", challengeID, challenge.Status)
+		}
+
+		enrollment, err := getEnrollment(tx, enrollmentRef, userID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if now.Unix() > challenge.ExpiresAt {
+			rejected = fmt.Errorf("challenge %s has expired", challengeID)
+			challenge.Status = "expired"
+			return tx.Update(challengeRef, []firestore.Update{{Path: "Status", Value: challenge.Status}})
+		}
+
+		step, ok := utils.MatchTOTP(enrollment.Secret, code, now)
+		if !ok || step <= enrollment.LastUsedStep {
+			rejected = fmt.Errorf("invalid code")
+			challenge.Attempts++
+			if challenge.Attempts >= stepUpConfig.MaxAttempts {
+				rejected = fmt.Errorf("invalid code, challenge %s has failed after %d attempts", challengeID, challenge.Attempts)
+				challenge.Status = "failed"
+			}
+			return tx.Update(challengeRef, []firestore.Update{
+				{Path: "Attempts", Value: challenge.Attempts},
+				{Path: "Status", Value: challenge.Status},
+			})
+		}
+
+		challenge.Status = "confirmed"
+		challenge.ConfirmedAt = now.Unix()
+		if err := tx.Update(enrollmentRef, []firestore.Update{{Path: "LastUsedStep", Value: step}}); err != nil {
+			return err
+		}
+		return tx.Update(challengeRef, []firestore.Update{
+			{Path: "Status", Value: challenge.Status},
+			{Path: "ConfirmedAt", Value: challenge.ConfirmedAt},
+		})
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("challenge_id", challengeID).Msg("Failed to confirm challenge")
+		return nil, err
+	}
+	if rejected != nil {
+		if challenge.Status != "pending" {
+			closeChallengeTransaction(ctx, client, &challenge)
+		}
+		return nil, rejected
+	}
+
+	ctx = utils.WithLogField(ctx, "transaction_id", challenge.TransactionID)
+	switch challenge.Kind {
+	case "transfer":
+		return executeTransfer(context.WithoutCancel(ctx), client, challenge.TransactionID, *challenge.Transfer, challenge.SenderAccNo, challenge.ReceiverAccNo, challenge.Fee)
+	case "authorization":
+		// The hold's ID is reported as the transaction ID, as holds have no transaction until captured
+		authorization, err := createAuthorization(context.WithoutCancel(ctx), client, *challenge.Transfer, challenge.SenderAccNo, challenge.ReceiverAccNo)
+		if err != nil {
+			return nil, err
+		}
+		return &entity.TransactionResult{TransactionID: authorization.ID, Status: authorization.Status}, nil
+	case "request_acceptance":
+		if err := paymentRequestAction(ctx, entity.PaymentRequestAction{RequestID: challenge.RequestID, Action: "Accept"}, userID, true); err != nil {
+			return nil, err
+		}
+		return &entity.TransactionResult{TransactionID: challenge.TransactionID, Status: "success"}, nil
+	default:
+		return nil, fmt.Errorf("unknown challenge kind: %s", challenge.Kind)
+	}
+}
+
+// stepUpReason decides whether a transfer or request acceptance from senderID to the account receiverAccNo
+// needs a TOTP code, and returns the reason ('amount', 'new_payee' or 'request_acceptance'), or "" if it does not.
+// Payees are told apart by the account they resolve to, so transfers without a receiver ID are covered too.
+func stepUpReason(ctx context.Context, client *firestore.Client, kind, senderID, receiverAccNo string, amount float64) (string, error) {
+	stepUpConfig, err := config.GetStepUpYamlConfig()
+	if err != nil {
+		return "", fmt.Errorf("unable to load step-up configuration: %w", err)
+	}
+	if !stepUpConfig.Enabled {
+		return "", nil
+	}
+
+	if kind == "request_acceptance" && stepUpConfig.RequestAcceptance {
+		return "request_acceptance", nil
+	}
+	if stepUpConfig.Amount > 0 && amount > stepUpConfig.Amount {
+		return "amount", nil
+	}
+	if stepUpConfig.NewPayee {
+		paid, err := hasPaid(ctx, client, senderID, receiverAccNo)
+		if err != nil {
+			return "", err
+		}
+		if !paid {
+			return "new_payee", nil
+		}
+	}
+	return "", nil
+}
+
+// hasPaid reports whether senderID has completed at least one transfer to the account receiverAccNo.
+func hasPaid(ctx context.Context, client *firestore.Client, senderID, receiverAccNo string) (bool, error) {
+	iter := client.Collection("transaction").
+		Where("SenderID", "==", senderID).
+		Where("ReceiverAccNo", "==", receiverAccNo).
+		Where("Status", "==", "success").
+		Limit(1).
+		Documents(ctx)
+	defer iter.Stop()
+
+	_, err := iter.Next()
+	if err == iterator.Done {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch previous transfers: %v", err)
+	}
+	return true, nil
+}
+
+// issueChallenge stores a new pending challenge and notifies the user, who must have a confirmed TOTP enrollment.
+func issueChallenge(ctx context.Context, client *firestore.Client, challenge entity.StepUpChallenge) (*entity.StepUpChallenge, error) {
+	stepUpConfig, err := config.GetStepUpYamlConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load step-up configuration: %w", err)
+	}
+
+	docSnap, err := client.Collection("TOTPEnrollment").Doc(challenge.UserID).Get(ctx)
+	if err != nil && err.Error() != "rpc error: code = NotFound desc = " {
+		return nil, fmt.Errorf("failed to fetch TOTP enrollment: %v", err)
+	}
+	if confirmed, _ := docSnap.DataAt("Confirmed"); err != nil || confirmed != true {
+		return nil, fmt.Errorf("two-factor authentication must be enrolled for this payment")
+	}
+
+	now := time.Now()
+	challengeRef := client.Collection("StepUpChallenge").NewDoc()
+	challenge.ID = challengeRef.ID
+	challenge.Status = "pending"
+	challenge.CreatedAt = now.Unix()
+	challenge.ExpiresAt = now.Add(stepUpConfig.ChallengeTTL).Unix()
+
+	if _, err := challengeRef.Create(ctx, challenge); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to store step-up challenge")
+		return nil, fmt.Errorf("failed to store step-up challenge: %v", err)
+	}
+
+	notify(ctx, client, entity.Notification{
+		UserID:        challenge.UserID,
+		Type:          "stepup.challenge",
+		RequestID:     challenge.RequestID,
+		TransactionID: challenge.TransactionID,
+		Message:       fmt.Sprintf("Confirm the payment of %v with your authenticator code (challenge %s)", challenge.Amount, challenge.ID),
+	})
+	return &challenge, nil
+}
+
+// closeChallengeTransaction ends the pending transfer of a challenge that expired or failed.
+// Request acceptances have nothing to undo: the payment request simply stays pending.
+func closeChallengeTransaction(ctx context.Context, client *firestore.Client, challenge *entity.StepUpChallenge) {
+	if challenge.Kind != "transfer" {
+		return
+	}
+
+	status := "expired"
+	if challenge.Status == "failed" {
+		status = "fail"
+	}
+	if err := updateTransactionStatus(ctx, client, challenge.TransactionID, status); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("challenge_id", challenge.ID).Msg("Failed to close transaction of step-up challenge")
+		return
+	}
+	metrics.RecordTransfer(challenge.Transfer.PaymentMethod, challenge.Transfer.RecievingMethod, "failed", challenge.Amount)
+}
+
+// verifyTOTPCode checks a TOTP code of the user outside of a challenge, for actions that are authorized once
+// up front. Like challenge codes, a code can only be used once.
+func verifyTOTPCode(ctx context.Context, client *firestore.Client, userID, code string) error {
+	enrollmentRef := client.Collection("TOTPEnrollment").Doc(userID)
+	return client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		enrollment, err := getEnrollment(tx, enrollmentRef, userID)
+		if err != nil {
+			return err
+		}
+		if !enrollment.Confirmed {
+			return fmt.Errorf("two-factor authentication must be enrolled for this payment")
+		}
+
+		step, ok := utils.MatchTOTP(enrollment.Secret, code, time.Now())
+		if !ok || step <= enrollment.LastUsedStep {
+			return fmt.Errorf("invalid code")
+		}
+		return tx.Update(enrollmentRef, []firestore.Update{{Path: "LastUsedStep", Value: step}})
+	})
+}
+
+func getEnrollment(tx *firestore.Transaction, enrollmentRef *firestore.DocumentRef, userID string) (*entity.TOTPEnrollment, error) {
+	docSnap, err := tx.Get(enrollmentRef)
+	if err != nil {
+		if err.Error() == "rpc error: code = NotFound desc = " {
+			return nil, fmt.Errorf("two-factor authentication is not enrolled for %s", userID)
+		}
+		return nil, fmt.Errorf("failed to fetch TOTP enrollment: %v", err)
+	}
+
+	var enrollment entity.TOTPEnrollment
+	if err := docSnap.DataTo(&enrollment); err != nil {
+		return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+	}
+	return &enrollment, nil
+}
+
+// ExpireStepUpChallenges expires every pending challenge past its TTL and closes its pending transfer.
+// It returns the number of challenges that were expired.
+func ExpireStepUpChallenges(ctx context.Context) (int, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return 0, err
+	}
+	defer client.Close()
+
+	iter := client.Collection("StepUpChallenge").Where("Status", "==", "pending").Documents(ctx)
+	defer iter.Stop()
+
+	now := time.Now().Unix()
+	expired := 0
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return expired, fmt.Errorf("failed to fetch challenges: %v", err)
+		}
+
+		var challenge entity.StepUpChallenge
+		if err := docSnap.DataTo(&challenge); err != nil {
+			return expired, fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		if challenge.ExpiresAt > now {
+			continue
+		}
+
+		// Only expire the challenge if it is still pending, so a confirmation racing the sweep wins or loses cleanly
+		err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			current, err := tx.Get(docSnap.Ref)
+			if err != nil {
+				return err
+			}
+			if status, _ := current.DataAt("Status"); status != "pending" {
+				return fmt.Errorf("challenge is already %v", status)
+			}
+			return tx.Update(docSnap.Ref, []firestore.Update{{Path: "Status", Value: "expired"}})
+		})
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("challenge_id", docSnap.Ref.ID).Msg("Failed to expire challenge")
+			continue
+		}
+
+		challenge.ID = docSnap.Ref.ID
+		challenge.Status = "expired"
+		closeChallengeTransaction(ctx, client, &challenge)
+		expired++
+	}
+
+	return expired, nil
+}
+
+// RunStepUpExpiry periodically expires unconfirmed step-up challenges until the context is cancelled.
+// A running pass finishes before the worker returns.
+func RunStepUpExpiry(ctx context.Context) {
+	stepUpConfig, err := config.GetStepUpYamlConfig()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Step-up expiry worker not started")
+		return
+	}
+
+	interval := stepUpConfig.SweepInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := ExpireStepUpChallenges(context.WithoutCancel(ctx))
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("Failed to expire step-up challenges")
+			}
+			if expired > 0 {
+				log.Ctx(ctx).Info().Int("expired", expired).Msg("Expired step-up challenges")
+			}
+		}
+	}
+}
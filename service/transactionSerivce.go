@@ -5,13 +5,17 @@ import (
 	"fmt"
 	"go-transaction/config"
 	"go-transaction/entity"
+	"go-transaction/metrics"
 	"go-transaction/repository"
+	"go-transaction/tracing"
+	"go-transaction/utils"
 	"strings"
 	"sync"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/api/iterator"
 )
 
@@ -32,6 +36,8 @@ func resetTransaction(t *entity.Transaction) {
 	t.SenderID = ""
 	t.ReceiverID = ""
 	t.ActionBy = ""
+	t.SenderAccNo = ""
+	t.ReceiverAccNo = ""
 	t.Amount = 0
 	t.PaymentMethod = ""
 	t.RecievingMethod = ""
@@ -40,6 +46,8 @@ func resetTransaction(t *entity.Transaction) {
 	t.Status = ""
 	t.Timestamp = 0
 	t.TransactionType = ""
+	t.EventSeq = 0
+	t.Fee = nil
 }
 
 func resetTransactionRequest(t *entity.TransactionRequest) {
@@ -51,9 +59,39 @@ func resetTransactionRequest(t *entity.TransactionRequest) {
 	t.TransactionID = ""
 	t.From = ""
 	t.To = ""
+	t.Status = ""
+	t.CreatedAt = 0
+	t.ExpiresAt = 0
+	t.ReminderSentAt = 0
+	t.DeclineReason = ""
+	t.GroupID = ""
+}
+
+// transferMode is how a transfer was requested, which decides the checks it goes through.
+type transferMode int
+
+const (
+	// transferInteractive transfers come over HTTP: they must carry the sender's UPI PIN and may wait for a TOTP code.
+	transferInteractive transferMode = iota
+	// transferQueued transfers come from the command queue: they must carry the sender's UPI PIN, but nobody
+	// is there to answer a TOTP challenge, so transfers that need one fail instead.
+	transferQueued
+	// transferScheduled transfers are runs of a schedule, whose PIN and step-up were checked when it was created.
+	transferScheduled
+)
+
+func InitiateTransaction(ctx context.Context, requestBody entity.RequestBody) (*entity.TransactionResult, error) {
+	return initiateTransaction(ctx, requestBody, transferInteractive)
+}
+
+// InitiateQueuedTransaction runs a transfer received from the command queue. It is checked like a
+// transfer made over HTTP, except that a transfer needing a TOTP code fails rather than waiting for one.
+func InitiateQueuedTransaction(ctx context.Context, requestBody entity.RequestBody) (*entity.TransactionResult, error) {
+	return initiateTransaction(ctx, requestBody, transferQueued)
 }
 
-func InitiateTransaction(ctx context.Context, requestBody entity.RequestBody) error {
+// initiateTransaction stores and runs a transfer, checking the PIN and step-up as its mode requires.
+func initiateTransaction(ctx context.Context, requestBody entity.RequestBody, mode transferMode) (result *entity.TransactionResult, err error) {
 	transaction := transactionPool.Get().(*entity.Transaction)
 	defer func() {
 		resetTransaction(transaction)
@@ -73,17 +111,32 @@ func InitiateTransaction(ctx context.Context, requestBody entity.RequestBody) er
 
 	app, err := config.InitFirebase()
 	if err != nil {
-		log.Logger.Error().Err(err).Msg("Failed to initialize Firebase app")
-		return err
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firebase app")
+		return nil, err
 	}
 
 	client, err := config.GetFirestoreClient(app)
 	if err != nil {
-		log.Logger.Error().Err(err).Msg("Failed to get Firestore client")
-		return err
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to get Firestore client")
+		return nil, err
 	}
 	defer client.Close()
 
+	if requestBody.BeneficiaryID != "" {
+		if err := resolveBeneficiary(ctx, client, &requestBody); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Failed to resolve beneficiary")
+			return nil, err
+		}
+		transaction.RecievingMethod = strings.ToUpper(requestBody.RecievingMethod)
+		transaction.RecieverPaymentDetails = requestBody.ReceiverPaymentDetails
+	}
+
+	if mode != transferScheduled && strings.EqualFold(requestBody.PaymentMethod, "UPI") {
+		if err := verifyUPIPin(ctx, client, requestBody.SenderID, requestBody.SenderPaymentDetails.UPI.UpiId, requestBody.Pin); err != nil {
+			return nil, err
+		}
+	}
+
 	senderAccNo, receiverAccNo, err := repository.GetUserAccNo(ctx, client,
 		strings.ToUpper(requestBody.PaymentMethod),
 		strings.ToUpper(requestBody.RecievingMethod),
@@ -92,65 +145,153 @@ func InitiateTransaction(ctx context.Context, requestBody entity.RequestBody) er
 	)
 
 	if err != nil {
-		log.Logger.Error().Err(err).Msg("Failed to fetch account numbers")
-		return err
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to fetch account numbers")
+		return nil, err
+	}
+	if err := checkSenderHolder(ctx, client, senderAccNo, requestBody.SenderID); err != nil {
+		return nil, err
 	}
 
-	ref := client.Collection("transaction")
-	docRef, _, err := ref.Add(ctx, transaction)
-	if err != nil {
-		log.Logger.Error().Err(err).Msg("Failed to store transaction in Firestore")
-		return err
+	transaction.ReceiverID = requestBody.ReceiverID
+	transaction.SenderAccNo = senderAccNo
+	transaction.ReceiverAccNo = receiverAccNo
+
+	// A quoted transfer is charged the fee it was quoted at. The quote is used up by the transfer, so
+	// it is given back if the transfer fails
+	if requestBody.QuoteID != "" {
+		quote, redeemErr := redeemQuote(ctx, client, requestBody, senderAccNo, receiverAccNo)
+		if redeemErr != nil {
+			return nil, redeemErr
+		}
+		defer func() {
+			if err != nil {
+				releaseQuote(context.WithoutCancel(ctx), client, quote.ID)
+			}
+		}()
+		transaction.Fee = quote.Fee
+	} else {
+		transaction.Fee, err = transferFee(ctx, client, requestBody.SenderID, transaction.PaymentMethod, transaction.RecievingMethod, requestBody.Amount)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Failed to price transfer fee")
+			return nil, err
+		}
+	}
+	fee := 0.0
+	if transaction.Fee != nil {
+		fee = transaction.Fee.Fee
 	}
 
-	_, errUpdate := ref.Doc(docRef.ID).Update(ctx, []firestore.Update{
-		{Path: "ID", Value: docRef.ID},
-	})
-	if errUpdate != nil {
-		log.Logger.Error().Err(errUpdate).Msg("Failed to update transaction ID")
-		return errUpdate
+	transactionID, err := createTransaction(ctx, client, transaction)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to store transaction in Firestore")
+		return nil, err
 	}
 
-	if requestBody.ReceiverID != "" {
-		_, errUpdate = ref.Doc(docRef.ID).Update(ctx, []firestore.Update{
-			{Path: "ReceiverID", Value: requestBody.ReceiverID},
+	// The transaction exists now; see it through to a final status even if the caller goes away
+	ctx = utils.WithLogField(context.WithoutCancel(ctx), "transaction_id", transactionID)
+
+	// Transfers that need a TOTP code wait, still pending, for the sender to confirm the challenge
+	stepUp := ""
+	if mode != transferScheduled {
+		stepUp, err = stepUpReason(ctx, client, "transfer", requestBody.SenderID, receiverAccNo, requestBody.Amount)
+	}
+	if err == nil && stepUp != "" && mode == transferQueued {
+		err = fmt.Errorf("transfer needs step-up authentication (%s), which queued transfers cannot provide", stepUp)
+	}
+	if err == nil && stepUp != "" {
+		var challenge *entity.StepUpChallenge
+		challenge, err = issueChallenge(ctx, client, entity.StepUpChallenge{
+			UserID:        requestBody.SenderID,
+			Kind:          "transfer",
+			Reason:        stepUp,
+			TransactionID: transactionID,
+			Amount:        requestBody.Amount,
+			Fee:           fee,
+			Transfer:      &requestBody,
+			SenderAccNo:   senderAccNo,
+			ReceiverAccNo: receiverAccNo,
 		})
+		if err == nil {
+			return &entity.TransactionResult{TransactionID: transactionID, Status: "challenge_required", ChallengeID: challenge.ID, Fee: fee}, nil
+		}
+	}
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to run step-up checks, updating status to failed")
+
+		errUpdate := updateTransactionStatus(ctx, client, transactionID, "fail")
 		if errUpdate != nil {
-			log.Logger.Error().Err(errUpdate).Msg("Failed to update transaction status")
-			return errUpdate
+			log.Ctx(ctx).Error().Err(errUpdate).Msg("Failed to update transaction status")
 		}
+		metrics.RecordTransfer(requestBody.PaymentMethod, requestBody.RecievingMethod, "failed", requestBody.Amount)
+		return nil, err
 	}
 
-	if err := processTransaction(ctx, client, senderAccNo, receiverAccNo, requestBody.Amount, strings.ToUpper(requestBody.PaymentMethod)); err != nil {
-		log.Logger.Error().Err(err).Msg("Payment processing failed, updating status to failed")
+	return executeTransfer(ctx, client, transactionID, requestBody, senderAccNo, receiverAccNo, fee)
+}
 
-		_, errUpdate := ref.Doc(docRef.ID).Update(ctx, []firestore.Update{
-			{Path: "Status", Value: "fail"},
-		})
-		if errUpdate != nil {
-			log.Logger.Error().Err(errUpdate).Msg("Failed to update transaction status")
+// executeTransfer runs a stored, pending transfer: it is either held for review or executed right away,
+// and the transaction is moved to its resulting status. The fee it was priced at is charged on top of the amount.
+func executeTransfer(ctx context.Context, client *firestore.Client, transactionID string, requestBody entity.RequestBody, senderAccNo, receiverAccNo string, fee float64) (*entity.TransactionResult, error) {
+	reason, err := reviewReason(requestBody.Amount, strings.ToUpper(requestBody.PaymentMethod))
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to run review checks")
+		return nil, err
+	}
+
+	if reason != "" {
+		item := entity.ReviewItem{
+			TransactionID:   transactionID,
+			SenderID:        requestBody.SenderID,
+			ReceiverID:      requestBody.ReceiverID,
+			SenderAccNo:     senderAccNo,
+			ReceiverAccNo:   receiverAccNo,
+			Amount:          requestBody.Amount,
+			Fee:             fee,
+			PaymentMethod:   strings.ToUpper(requestBody.PaymentMethod),
+			ReceivingMethod: strings.ToUpper(requestBody.RecievingMethod),
+			Reason:          reason,
 		}
-		return err
+		if err := holdTransaction(ctx, client, item); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Failed to hold transaction for review, updating status to failed")
+
+			errUpdate := updateTransactionStatus(ctx, client, transactionID, "fail")
+			if errUpdate != nil {
+				log.Ctx(ctx).Error().Err(errUpdate).Msg("Failed to update transaction status")
+			}
+			metrics.RecordTransfer(requestBody.PaymentMethod, requestBody.RecievingMethod, "failed", requestBody.Amount)
+			return nil, err
+		}
+		metrics.RecordTransfer(requestBody.PaymentMethod, requestBody.RecievingMethod, "held", requestBody.Amount)
+		return &entity.TransactionResult{TransactionID: transactionID, Status: "held", Fee: fee}, nil
 	}
 
-	_, errUpdate = ref.Doc(docRef.ID).Update(ctx, []firestore.Update{
-		{Path: "Status", Value: "success"},
-	})
-	if errUpdate != nil {
-		log.Logger.Error().Err(errUpdate).Msg("Failed to update transaction status")
-		return errUpdate
+	if err := processTransaction(ctx, client, transactionID, senderAccNo, receiverAccNo, requestBody.Amount, fee, strings.ToUpper(requestBody.PaymentMethod)); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Payment processing failed, updating status to failed")
+
+		errUpdate := updateTransactionStatus(ctx, client, transactionID, "fail")
+		if errUpdate != nil {
+			log.Ctx(ctx).Error().Err(errUpdate).Msg("Failed to update transaction status")
+		}
+		metrics.RecordTransfer(requestBody.PaymentMethod, requestBody.RecievingMethod, "failed", requestBody.Amount)
+		return nil, err
 	}
+	metrics.RecordTransfer(requestBody.PaymentMethod, requestBody.RecievingMethod, "success", requestBody.Amount)
 
-	return nil
+	return &entity.TransactionResult{TransactionID: transactionID, Status: "success", Fee: fee}, nil
 }
 
-func processTransaction(ctx context.Context, client *firestore.Client, senderAccNo, recipientAccNo string, amount float64, paymentMethod string) error {
-
+// checkPaymentLimit rejects amounts that are not positive or above the configured maximum for the payment method.
+func checkPaymentLimit(amount float64, paymentMethod string) error {
 	MapPaymentAmount, err := config.GetPaymentAmountYamlConfig()
 	if err != nil {
 		return fmt.Errorf("unable to load payment configuration: %w", err)
 	}
 
+	// A negative amount would move money the other way
+	if amount <= 0 {
+		return fmt.Errorf("payment amount must be greater than 0")
+	}
+
 	switch paymentMethod {
 	case "UPI":
 		if amount > MapPaymentAmount.MaxUpiAmount {
@@ -160,7 +301,7 @@ func processTransaction(ctx context.Context, client *firestore.Client, senderAcc
 				Msg("UPI payment amount exceeds the maximum allowed limit")
 			return fmt.Errorf("UPI payment amount exceeds the maximum allowed limit of %v", MapPaymentAmount.MaxUpiAmount)
 		}
-	case "CREDIT":
+	case "CREDIT_CARD":
 		if amount > MapPaymentAmount.MaxCreditAmount {
 			log.Logger.Error().
 				Str("paymentMethod", paymentMethod).
@@ -176,105 +317,146 @@ func processTransaction(ctx context.Context, client *firestore.Client, senderAcc
 		return fmt.Errorf("invalid payment method: %s", paymentMethod)
 	}
 
-	bankDetailsRef := client.Collection("BankDetails")
+	return nil
+}
 
-	senderQuery := bankDetailsRef.Where("account_number", "==", senderAccNo).Documents(ctx)
-	senderDoc, err := senderQuery.Next()
-	if err != nil {
-		if err == iterator.Done {
-			log.Logger.Error().
-				Str("senderAccNo", senderAccNo).
-				Msg("No matching document found for sender")
-			return fmt.Errorf("no matching document found for sender with account number: %s", senderAccNo)
+// processTransaction debits amount plus fee from the sender, credits amount to the receiver and the fee to the
+// configured fee-revenue account, and marks the transaction successful with its outbox event, all in one
+// Firestore transaction. statusUpdates are applied to the transaction along with the status.
+func processTransaction(ctx context.Context, client *firestore.Client, transactionID, senderAccNo, recipientAccNo string, amount, fee float64, paymentMethod string, statusUpdates ...firestore.Update) (err error) {
+	ctx, span := tracing.Start(ctx, "service.processTransaction",
+		attribute.String("payment.method", paymentMethod),
+		attribute.Float64("payment.amount", amount),
+		attribute.Float64("payment.fee", fee),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if err := checkPaymentLimit(amount, paymentMethod); err != nil {
+		return err
+	}
+
+	revenueAccNo := ""
+	if fee > 0 {
+		feeConfig, err := config.GetFeeYamlConfig()
+		if err != nil {
+			return fmt.Errorf("unable to load fee configuration: %w", err)
 		}
-		log.Logger.Error().Err(err).Msg("Error fetching sender document")
-		return fmt.Errorf("failed to fetch sender document: %v", err)
+		revenueAccNo = feeConfig.RevenueAccount
 	}
 
-	senderData := senderDoc.Data()
-	senderBalance := senderData["balance"].(float64)
-	senderDocRef := senderDoc.Ref
+	return client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		senderDoc, err := repository.GetAccountInTx(tx, client, senderAccNo)
+		if err != nil {
+			return err
+		}
+		receiverDoc, err := repository.GetAccountInTx(tx, client, recipientAccNo)
+		if err != nil {
+			return err
+		}
+		var revenueDoc *firestore.DocumentSnapshot
+		if fee > 0 {
+			if revenueDoc, err = repository.GetAccountInTx(tx, client, revenueAccNo); err != nil {
+				return err
+			}
+		}
 
-	if senderBalance < amount {
-		log.Logger.Error().
-			Float64("balance", senderBalance).
-			Float64("amount", amount).
-			Msg("Insufficient balance in sender's account")
-		return fmt.Errorf("insufficient balance in sender's account")
-	}
+		senderData := senderDoc.Data()
+		receiverData := receiverDoc.Data()
+		receiverBalance := repository.FloatField(receiverData, "balance")
 
-	newSenderBalance := senderBalance - amount
-	_, err = senderDocRef.Update(ctx, []firestore.Update{
-		{Path: "balance", Value: newSenderBalance},
-		{Path: "updatedAt", Value: firestore.ServerTimestamp},
-	})
-	if err != nil {
-		log.Logger.Error().Err(err).Msg("Failed to update sender's balance")
-		return fmt.Errorf("failed to update sender's balance: %v", err)
-	}
+		// Both accounts must allow the transfer before any money moves
+		if err := checkAccountStatus(senderAccNo, senderData, true); err != nil {
+			return err
+		}
+		if err := checkAccountStatus(recipientAccNo, receiverData, false); err != nil {
+			return err
+		}
 
-	receiverQuery := bankDetailsRef.Where("account_number", "==", recipientAccNo).Documents(ctx)
-	receiverDoc, err := receiverQuery.Next()
-	if err != nil {
-		if err == iterator.Done {
-			log.Logger.Error().
-				Str("recipientAccNo", recipientAccNo).
-				Msg("No matching document found for receiver")
-			return fmt.Errorf("no matching document found for receiver with account number: %s", recipientAccNo)
+		newSenderBalance, senderUpdates, err := debitSender(ctx, senderAccNo, senderData, amount, fee, paymentMethod)
+		if err != nil {
+			return err
 		}
-		log.Logger.Error().Err(err).Msg("Error fetching receiver document")
-		return fmt.Errorf("failed to fetch receiver document: %v", err)
-	}
 
-	receiverData := receiverDoc.Data()
-	receiverBalance := receiverData["balance"].(float64)
-	receiverDocRef := receiverDoc.Ref
+		// All reads are done once the transaction is read; the status change and its outbox event are the first write
+		if err := stageTransactionStatus(tx, client, transactionID, "success", statusUpdates...); err != nil {
+			return err
+		}
 
-	newReceiverBalance := receiverBalance + amount
-	_, err = receiverDocRef.Update(ctx, []firestore.Update{
-		{Path: "balance", Value: newReceiverBalance},
-		{Path: "updatedAt", Value: firestore.ServerTimestamp},
+		now := time.Now().Unix()
+		if err := tx.Update(senderDoc.Ref, append(senderUpdates,
+			firestore.Update{Path: "last_activity_at", Value: now},
+			firestore.Update{Path: "updatedAt", Value: firestore.ServerTimestamp},
+		)); err != nil {
+			return fmt.Errorf("failed to update sender's balance: %v", err)
+		}
+
+		newReceiverBalance := receiverBalance + amount
+		if err := tx.Update(receiverDoc.Ref, append(repository.BalanceUpdates(receiverData, newReceiverBalance),
+			firestore.Update{Path: "last_activity_at", Value: now},
+			firestore.Update{Path: "updatedAt", Value: firestore.ServerTimestamp},
+		)); err != nil {
+			return fmt.Errorf("failed to update receiver's balance: %v", err)
+		}
+
+		audit := []entity.AuditEntry{
+			balanceAudit(ctx, "balance.debit", senderAccNo, amount+fee, newSenderBalance, repository.FloatField(senderData, "reserved"), transactionID, ""),
+			balanceAudit(ctx, "balance.credit", recipientAccNo, amount, newReceiverBalance, repository.FloatField(receiverData, "reserved"), transactionID, ""),
+		}
+		if fee > 0 {
+			revenueBalance, err := creditFee(tx, revenueDoc, fee)
+			if err != nil {
+				return err
+			}
+			audit = append(audit, balanceAudit(ctx, "fee.credit", revenueAccNo, fee, revenueBalance, repository.FloatField(revenueDoc.Data(), "reserved"), transactionID, ""))
+		}
+		return stageAudit(tx, client, audit...)
 	})
+}
+
+// debitSender checks that an account can pay amount plus fee by paymentMethod and returns its new balance
+// with the updates recording it. Funds reserved for held transactions are not available for new transfers,
+// while card payments may also draw on the account's credit line.
+func debitSender(ctx context.Context, accNo string, data map[string]interface{}, amount, fee float64, paymentMethod string) (float64, []firestore.Update, error) {
+	available, err := availableFunds(accNo, data, paymentMethod)
 	if err != nil {
-		log.Logger.Error().Err(err).Msg("Failed to update receiver's balance")
-		return fmt.Errorf("failed to update receiver's balance: %v", err)
+		return 0, nil, err
+	}
+	balance := repository.FloatField(data, "balance")
+	if available < amount+fee {
+		log.Ctx(ctx).Error().
+			Float64("balance", balance).
+			Float64("reserved", repository.FloatField(data, "reserved")).
+			Float64("available", available).
+			Float64("amount", amount).
+			Float64("fee", fee).
+			Msg("Insufficient balance in sender's account")
+		return 0, nil, fmt.Errorf("insufficient balance in sender's account")
 	}
 
-	return nil
+	balance -= amount + fee
+	return balance, repository.BalanceUpdates(data, balance), nil
 }
 
 func MakeRequest(ctx context.Context, requestBody entity.MakePaymentRequest) error {
-	transaction := transactionPool.Get().(*entity.Transaction)
-	defer func() {
-		resetTransaction(transaction)
-		transactionPool.Put(transaction)
-	}()
-
-	transaction.SenderID = requestBody.PayerID
-	transaction.ReceiverID = requestBody.RequesterID
-	transaction.Amount = requestBody.Amount
-	transaction.PaymentMethod = strings.ToUpper(requestBody.PayerPaymentMethod)
-	transaction.RecievingMethod = strings.ToUpper(requestBody.RequesterPaymentMethod)
-	transaction.SenderPaymentDetails = requestBody.PayerPaymentDetails
-	transaction.RecieverPaymentDetails = requestBody.RequesterPaymentDetails
-	transaction.TransactionType = "Request"
-	transaction.Status = "pending"
-	transaction.Timestamp = time.Now().Unix()
+	expiresIn, err := requestExpiry(requestBody.ExpiresIn)
+	if err != nil {
+		return err
+	}
 
 	app, err := config.InitFirebase()
 	if err != nil {
-		log.Logger.Error().Err(err).Msg("Failed to initialize Firebase app")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firebase app")
 		return err
 	}
 
 	client, err := config.GetFirestoreClient(app)
 	if err != nil {
-		log.Logger.Error().Err(err).Msg("Failed to get Firestore client")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to get Firestore client")
 		return err
 	}
 	defer client.Close()
 
-	senderAccNo, receiverAccNo, err := repository.GetUserAccNo(ctx, client,
+	payerAccNo, requesterAccNo, err := repository.GetUserAccNo(ctx, client,
 		strings.ToUpper(requestBody.PayerPaymentMethod),
 		strings.ToUpper(requestBody.RequesterPaymentMethod),
 		requestBody.PayerPaymentDetails,
@@ -282,23 +464,57 @@ func MakeRequest(ctx context.Context, requestBody entity.MakePaymentRequest) err
 	)
 
 	if err != nil {
-		log.Logger.Error().Err(err).Msg("Failed to fetch account numbers")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to fetch account numbers")
 		return err
 	}
 
-	ref := client.Collection("transaction")
-	docRef, _, err := ref.Add(ctx, transaction)
-	if err != nil {
-		log.Logger.Error().Err(err).Msg("Failed to store transaction in Firestore")
+	_, err = storePaymentRequest(ctx, client, requestBody, payerAccNo, requesterAccNo, "", expiresIn)
+	return err
+}
+
+// storePaymentRequest writes the pending transaction and the TransactionRequest for a single payer
+// and returns the request ID. The payer's account is debited and the requester's credited on Accept.
+func storePaymentRequest(ctx context.Context, client *firestore.Client, requestBody entity.MakePaymentRequest, payerAccNo, requesterAccNo, groupID string, expiresIn time.Duration) (string, error) {
+	var requestID string
+	err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var err error
+		requestID, err = stagePaymentRequest(tx, client, requestBody, payerAccNo, requesterAccNo, groupID, expiresIn)
 		return err
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to store payment request in Firestore")
+		return "", err
 	}
 
-	_, errUpdate := ref.Doc(docRef.ID).Update(ctx, []firestore.Update{
-		{Path: "ID", Value: docRef.ID},
-	})
-	if errUpdate != nil {
-		log.Logger.Error().Err(errUpdate).Msg("Failed to update transaction ID")
-		return errUpdate
+	metrics.RecordPaymentRequest("created")
+	return requestID, nil
+}
+
+// stagePaymentRequest creates the pending transaction, its outbox event and the TransactionRequest for a
+// single payer inside tx and returns the request ID. It only writes, so it can be called at any point of tx.
+func stagePaymentRequest(tx *firestore.Transaction, client *firestore.Client, requestBody entity.MakePaymentRequest, payerAccNo, requesterAccNo, groupID string, expiresIn time.Duration) (string, error) {
+	transaction := transactionPool.Get().(*entity.Transaction)
+	defer func() {
+		resetTransaction(transaction)
+		transactionPool.Put(transaction)
+	}()
+
+	transaction.SenderID = requestBody.PayerID
+	transaction.ReceiverID = requestBody.RequesterID
+	transaction.Amount = requestBody.Amount
+	transaction.PaymentMethod = strings.ToUpper(requestBody.PayerPaymentMethod)
+	transaction.RecievingMethod = strings.ToUpper(requestBody.RequesterPaymentMethod)
+	transaction.SenderPaymentDetails = requestBody.PayerPaymentDetails
+	transaction.RecieverPaymentDetails = requestBody.RequesterPaymentDetails
+	transaction.SenderAccNo = payerAccNo
+	transaction.ReceiverAccNo = requesterAccNo
+	transaction.TransactionType = "Request"
+	transaction.Status = "pending"
+	transaction.Timestamp = time.Now().Unix()
+
+	transactionRef := client.Collection("transaction").NewDoc()
+	if err := stageNewTransaction(tx, client, transactionRef, transaction); err != nil {
+		return "", err
 	}
 
 	requestTransaction := transactionRequestPool.Get().(*entity.TransactionRequest)
@@ -307,31 +523,24 @@ func MakeRequest(ctx context.Context, requestBody entity.MakePaymentRequest) err
 		transactionRequestPool.Put(requestTransaction)
 	}()
 
-	requestTransaction.RequesterAccNo = senderAccNo
-	requestTransaction.PayerAccNo = receiverAccNo
+	requestRef := client.Collection("TransactionRequest").NewDoc()
+	requestTransaction.ID = requestRef.ID
+	requestTransaction.RequesterAccNo = requesterAccNo
+	requestTransaction.PayerAccNo = payerAccNo
 	requestTransaction.Amount = requestBody.Amount
 	requestTransaction.PaymentMethod = requestBody.RequesterPaymentMethod
-	requestTransaction.TransactionID = docRef.ID
+	requestTransaction.TransactionID = transactionRef.ID
 	requestTransaction.From = requestBody.RequesterID
 	requestTransaction.To = requestBody.PayerID
+	requestTransaction.Status = "pending"
+	requestTransaction.CreatedAt = time.Now().Unix()
+	requestTransaction.ExpiresAt = time.Now().Add(expiresIn).Unix()
+	requestTransaction.GroupID = groupID
 
-	requestRef := client.Collection("TransactionRequest")
-	requestDocRef, _, err := requestRef.Add(ctx, requestTransaction)
-	if err != nil {
-		log.Logger.Error().Err(err).Msg("Failed to store transaction in Firestore")
-		return err
-	}
-
-	_, errUpdate = requestRef.Doc(requestDocRef.ID).Update(ctx, []firestore.Update{
-		{Path: "ID", Value: requestDocRef.ID},
-	})
-	if errUpdate != nil {
-		log.Logger.Error().Err(errUpdate).Msg("Failed to update transaction ID")
-		return errUpdate
+	if err := tx.Create(requestRef, requestTransaction); err != nil {
+		return "", fmt.Errorf("failed to store payment request: %v", err)
 	}
-
-	return nil
-
+	return requestRef.ID, nil
 }
 
 var transactionLocks sync.Map
@@ -346,6 +555,13 @@ func GetTransactionLock(transactionID string) *sync.Mutex {
 }
 
 func PaymentRequestAction(ctx context.Context, requestBody entity.PaymentRequestAction, userID string) error {
+	return paymentRequestAction(ctx, requestBody, userID, false)
+}
+
+// paymentRequestAction applies an action to a payment request. An acceptance must carry the payer's
+// UPI PIN, and one that needs step-up authentication fails with a StepUpRequiredError, unless
+// stepUpConfirmed is set: both were checked before the challenge was issued.
+func paymentRequestAction(ctx context.Context, requestBody entity.PaymentRequestAction, userID string, stepUpConfirmed bool) error {
 
 	transactionLock := GetTransactionLock(requestBody.RequestID)
 	transactionLock.Lock() // Acquire the lock
@@ -354,13 +570,13 @@ func PaymentRequestAction(ctx context.Context, requestBody entity.PaymentRequest
 
 	app, err := config.InitFirebase()
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to initialize Firebase app")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firebase app")
 		return err
 	}
 
 	client, err := config.GetFirestoreClient(app)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get Firestore client")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to get Firestore client")
 		return err
 	}
 	defer client.Close()
@@ -370,88 +586,160 @@ func PaymentRequestAction(ctx context.Context, requestBody entity.PaymentRequest
 	requestDoc, err := requestDocRef.Get(ctx)
 	if err != nil {
 		if err.Error() == "rpc error: code = NotFound desc = " {
-			log.Logger.Error().Msg("No matching document found for Request ID")
+			log.Ctx(ctx).Error().Msg("No matching document found for Request ID")
 			return fmt.Errorf("no matching document found for Request ID : %s", requestBody.RequestID)
 		}
-		log.Logger.Error().Err(err).Msg("Error fetching request document")
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching request document")
 		return fmt.Errorf("failed to fetch sender document: %v", err)
 	}
 
 	requestData := requestDoc.Data()
 
+	// Requests created before statuses were tracked have no Status field and are treated as pending
+	if requestStatus, _ := requestData["Status"].(string); requestStatus != "" && requestStatus != "pending" {
+		return fmt.Errorf("request %s is already %s", requestBody.RequestID, requestStatus)
+	}
+	if expiresAt, _ := requestData["ExpiresAt"].(int64); expiresAt != 0 && time.Now().Unix() > expiresAt {
+		return fmt.Errorf("request %s has expired", requestBody.RequestID)
+	}
+
+	var requestStatus string
+
 	transactionRef := client.Collection("transaction")
 	transactionDocRef := transactionRef.Doc(requestData["TransactionID"].(string))
 	transactionDoc, err := transactionDocRef.Get(ctx)
 	if err != nil {
 		if err.Error() == "rpc error: code = NotFound desc = " {
-			log.Logger.Error().Msg("No matching document found for Transaction ID")
+			log.Ctx(ctx).Error().Msg("No matching document found for Transaction ID")
 			return fmt.Errorf("no matching document found for Transaction ID : %s", requestData["TransactionID"].(string))
 		}
-		log.Logger.Error().Err(err).Msg("Error fetching transaction document")
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching transaction document")
 		return fmt.Errorf("failed to fetch transaction document: %v", err)
 	}
 
 	transactionData := transactionDoc.Data()
 
+	// Settle the request even if the caller disconnects half way through
+	ctx = utils.WithLogField(context.WithoutCancel(ctx), "transaction_id", transactionDocRef.ID)
+
 	if strings.EqualFold(requestBody.Action, "Accept") {
 		// Check if the payer is the same as the requester and ensure they are the user attempting the action
 		if strings.EqualFold(requestData["To"].(string), transactionData["SenderID"].(string)) && strings.EqualFold(requestData["To"].(string), userID) {
 
-			if err := processTransaction(ctx, client, requestData["PayerAccNo"].(string), requestData["RequesterAccNo"].(string), requestData["Amount"].(float64), strings.ToUpper(requestData["PaymentMethod"].(string))); err != nil {
-				log.Logger.Error().Err(err).Msg("Payment processing failed, updating status to failed")
+			if !stepUpConfirmed {
+				// Payment requests are always paid by UPI, from the UPI ID the requester asked
+				payerUPI, _ := transactionDoc.DataAt("SenderPaymentDetails.UPI.UpiId")
+				if err := verifyUPIPin(ctx, client, userID, fmt.Sprint(payerUPI), requestBody.Pin); err != nil {
+					return err
+				}
 
-				_, errUpdate := transactionRef.Doc(transactionDocRef.ID).Update(ctx, []firestore.Update{
-					{Path: "Status", Value: "fail"},
-				})
+				stepUp, err := stepUpReason(ctx, client, "request_acceptance", userID, requestData["RequesterAccNo"].(string), requestData["Amount"].(float64))
+				if err != nil {
+					log.Ctx(ctx).Error().Err(err).Msg("Failed to run step-up checks")
+					return err
+				}
+				if stepUp != "" {
+					challenge, err := issueChallenge(ctx, client, entity.StepUpChallenge{
+						UserID:        userID,
+						Kind:          "request_acceptance",
+						Reason:        stepUp,
+						TransactionID: transactionDocRef.ID,
+						RequestID:     requestBody.RequestID,
+						Amount:        requestData["Amount"].(float64),
+					})
+					if err != nil {
+						return err
+					}
+					return &StepUpRequiredError{Challenge: challenge}
+				}
+			}
+
+			// The payer settles exactly the amount the requester asked for; payment requests carry no fee
+			if err := processTransaction(ctx, client, transactionDocRef.ID, requestData["PayerAccNo"].(string), requestData["RequesterAccNo"].(string), requestData["Amount"].(float64), 0, strings.ToUpper(requestData["PaymentMethod"].(string)), firestore.Update{Path: "ActionBy", Value: userID}); err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("Payment processing failed, updating status to failed")
+
+				errUpdate := updateTransactionStatus(ctx, client, transactionDocRef.ID, "fail")
 				if errUpdate != nil {
-					log.Logger.Error().Err(errUpdate).Msg("Failed to update transaction status")
+					log.Ctx(ctx).Error().Err(errUpdate).Msg("Failed to update transaction status")
 				}
+				metrics.RecordTransfer(fmt.Sprint(transactionData["PaymentMethod"]), fmt.Sprint(transactionData["RecievingMethod"]), "failed", requestData["Amount"].(float64))
 				return err
 			}
+			metrics.RecordTransfer(fmt.Sprint(transactionData["PaymentMethod"]), fmt.Sprint(transactionData["RecievingMethod"]), "success", requestData["Amount"].(float64))
+			requestStatus = "accepted"
+		} else {
+			return fmt.Errorf("Invalid User")
+		}
+	} else if strings.EqualFold(requestBody.Action, "Cancel") {
+		if (strings.EqualFold(userID, requestData["From"].(string)) && strings.EqualFold(userID, transactionData["ReceiverID"].(string))) || (strings.EqualFold(userID, requestData["To"].(string)) && strings.EqualFold(userID, transactionData["SenderID"].(string))) {
 
-			_, errUpdate := transactionRef.Doc(transactionDocRef.ID).Update(ctx, []firestore.Update{
-				{Path: "Status", Value: "success"},
-			})
+			errUpdate := updateTransactionStatus(ctx, client, transactionDocRef.ID, "cancel", firestore.Update{Path: "ActionBy", Value: userID})
 			if errUpdate != nil {
-				log.Logger.Error().Err(errUpdate).Msg("Failed to update transaction status")
+				log.Ctx(ctx).Error().Err(errUpdate).Msg("Failed to update transaction status")
 				return errUpdate
 			}
+			requestStatus = "cancelled"
 		} else {
 			return fmt.Errorf("Invalid User")
 		}
-	} else if strings.EqualFold(requestBody.Action, "Cancel") {
-		if (strings.EqualFold(userID, requestData["From"].(string)) && strings.EqualFold(userID, transactionData["ReceiverID"].(string))) || (strings.EqualFold(userID, requestData["To"].(string)) && strings.EqualFold(userID, transactionData["SenderID"].(string))) {
+	} else if strings.EqualFold(requestBody.Action, "Decline") {
+		// Only the payer can decline a request
+		if strings.EqualFold(requestData["To"].(string), transactionData["SenderID"].(string)) && strings.EqualFold(requestData["To"].(string), userID) {
 
-			_, errUpdate := transactionRef.Doc(transactionDocRef.ID).Update(ctx, []firestore.Update{
-				{Path: "Status", Value: "cancel"},
-			})
+			errUpdate := updateTransactionStatus(ctx, client, transactionDocRef.ID, "declined", firestore.Update{Path: "ActionBy", Value: userID})
 			if errUpdate != nil {
-				log.Logger.Error().Err(errUpdate).Msg("Failed to update transaction status")
+				log.Ctx(ctx).Error().Err(errUpdate).Msg("Failed to update transaction status")
 				return errUpdate
 			}
+			requestStatus = "declined"
+
+			notify(ctx, client, entity.Notification{
+				UserID:        requestData["From"].(string),
+				Type:          "payment_request.declined",
+				RequestID:     requestBody.RequestID,
+				TransactionID: transactionDocRef.ID,
+				Message:       fmt.Sprintf("Your payment request was declined: %s", requestBody.Reason),
+			})
 		} else {
 			return fmt.Errorf("Invalid User")
 		}
 	} else {
-		_, errUpdate := transactionRef.Doc(transactionDocRef.ID).Update(ctx, []firestore.Update{
-			{Path: "Status", Value: "fail"},
-		})
+		errUpdate := updateTransactionStatus(ctx, client, transactionDocRef.ID, "fail", firestore.Update{Path: "ActionBy", Value: userID})
 		if errUpdate != nil {
-			log.Logger.Error().Err(errUpdate).Msg("Failed to update transaction status")
+			log.Ctx(ctx).Error().Err(errUpdate).Msg("Failed to update transaction status")
 			return errUpdate
 		}
+		requestStatus = "fail"
 	}
 
-	_, errUpdate := transactionRef.Doc(transactionDocRef.ID).Update(ctx, []firestore.Update{
-		{Path: "ActionBy", Value: userID},
-	})
-	if errUpdate != nil {
-		return fmt.Errorf("Failed to update transaction ActionBy : %v", errUpdate)
+	requestUpdates := []firestore.Update{
+		{Path: "Status", Value: requestStatus},
+	}
+	if requestStatus == "declined" {
+		requestUpdates = append(requestUpdates, firestore.Update{Path: "DeclineReason", Value: requestBody.Reason})
 	}
 
-	_, err = requestDocRef.Delete(ctx)
+	_, err = requestDocRef.Update(ctx, requestUpdates)
 	if err != nil {
-		return fmt.Errorf("failed to delete request document: %v", err)
+		return fmt.Errorf("failed to update request document: %v", err)
+	}
+	metrics.RecordPaymentRequest(requestStatus)
+
+	if groupID, _ := requestData["GroupID"].(string); groupID != "" {
+		closeSettledGroup(ctx, client, groupID)
+	}
+
+	// Let the requester's webhook subscribers know how the request was settled
+	if requestStatus != "fail" {
+		var request entity.TransactionRequest
+		if err := requestDoc.DataTo(&request); err == nil {
+			request.ID = requestDoc.Ref.ID
+			request.Status = requestStatus
+			if requestStatus == "declined" {
+				request.DeclineReason = requestBody.Reason
+			}
+			enqueueWebhookEvent(ctx, client, request.From, "payment_request."+requestStatus, paymentRequestDetails(&request))
+		}
 	}
 
 	return nil
@@ -459,13 +747,13 @@ func PaymentRequestAction(ctx context.Context, requestBody entity.PaymentRequest
 func GetTransactionByID(ctx context.Context, docID, role, userID string) (*entity.Transaction, error) {
 	app, err := config.InitFirebase()
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to initialize Firebase app")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firebase app")
 		return nil, err
 	}
 
 	client, err := config.GetFirestoreClient(app)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get Firestore client")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to get Firestore client")
 		return nil, err
 	}
 	defer client.Close()
@@ -475,19 +763,19 @@ func GetTransactionByID(ctx context.Context, docID, role, userID string) (*entit
 	docSnap, err := docRef.Get(ctx)
 	if err != nil {
 		if err.Error() == "rpc error: code = NotFound desc = " {
-			log.Error().
+			log.Ctx(ctx).Error().
 				Str("document_id", docID).
 				Msg("Transaction document not found")
 			return nil, fmt.Errorf("no transaction found with document ID: %s", docID)
 		}
-		log.Error().Err(err).Msg("Error fetching transaction document")
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching transaction document")
 		return nil, fmt.Errorf("failed to fetch transaction document: %v", err)
 	}
 
 	var transaction entity.Transaction
 	err = docSnap.DataTo(&transaction)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to map Firestore document to struct")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to map Firestore document to struct")
 		return nil, fmt.Errorf("failed to map Firestore document: %v", err)
 	}
 
@@ -505,13 +793,13 @@ func GetTransactionByID(ctx context.Context, docID, role, userID string) (*entit
 func GetTransactions(ctx context.Context, role, userID string, pageSize, pageNumber int) ([]*entity.Transaction, error) {
 	app, err := config.InitFirebase()
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to initialize Firebase app")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firebase app")
 		return nil, err
 	}
 
 	client, err := config.GetFirestoreClient(app)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get Firestore client")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to get Firestore client")
 		return nil, err
 	}
 	defer client.Close()
@@ -531,14 +819,14 @@ func GetTransactions(ctx context.Context, role, userID string, pageSize, pageNum
 				break
 			}
 			if err != nil {
-				log.Error().Err(err).Msg("Error fetching transactions")
+				log.Ctx(ctx).Error().Err(err).Msg("Error fetching transactions")
 				return nil, fmt.Errorf("failed to fetch transactions: %v", err)
 			}
 
 			var transaction entity.Transaction
 			err = docSnap.DataTo(&transaction)
 			if err != nil {
-				log.Error().Err(err).Msg("Failed to map Firestore document to struct")
+				log.Ctx(ctx).Error().Err(err).Msg("Failed to map Firestore document to struct")
 				return nil, fmt.Errorf("failed to map Firestore document: %v", err)
 			}
 
@@ -555,14 +843,14 @@ func GetTransactions(ctx context.Context, role, userID string, pageSize, pageNum
 				break
 			}
 			if err != nil {
-				log.Error().Err(err).Msg("Error fetching sender transactions")
+				log.Ctx(ctx).Error().Err(err).Msg("Error fetching sender transactions")
 				return nil, fmt.Errorf("failed to fetch sender transactions: %v", err)
 			}
 
 			var transaction entity.Transaction
 			err = docSnap.DataTo(&transaction)
 			if err != nil {
-				log.Error().Err(err).Msg("Failed to map sender Firestore document to struct")
+				log.Ctx(ctx).Error().Err(err).Msg("Failed to map sender Firestore document to struct")
 				return nil, fmt.Errorf("failed to map sender Firestore document: %v", err)
 			}
 
@@ -579,14 +867,14 @@ func GetTransactions(ctx context.Context, role, userID string, pageSize, pageNum
 				break
 			}
 			if err != nil {
-				log.Error().Err(err).Msg("Error fetching receiver transactions")
+				log.Ctx(ctx).Error().Err(err).Msg("Error fetching receiver transactions")
 				return nil, fmt.Errorf("failed to fetch receiver transactions: %v", err)
 			}
 
 			var transaction entity.Transaction
 			err = docSnap.DataTo(&transaction)
 			if err != nil {
-				log.Error().Err(err).Msg("Failed to map receiver Firestore document to struct")
+				log.Ctx(ctx).Error().Err(err).Msg("Failed to map receiver Firestore document to struct")
 				return nil, fmt.Errorf("failed to map receiver Firestore document: %v", err)
 			}
 
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Tests run from the package directory, so the YAML file is named explicitly
+	if _, err := config.Load([]string{
+		"-project", "prod",
+		"-config", "../config/config.prod.yaml",
+		"-set", "secrets.jwtkey=test-jwt-key",
+		"-set", "secrets.quotekey=test-quote-key",
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to load test configuration: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+func TestCreditCardTransferWithinCreditLimit(t *testing.T) {
+	// 1000 in the account and a 5000 credit line cover a 3000 card payment plus its fee
+	account := map[string]interface{}{
+		"account_number": "1234567890",
+		"balance":        1000.0,
+		"credit_limit":   5000.0,
+	}
+	amount, fee := 3000.0, 5.0
+
+	if err := checkPaymentLimit(amount, "CREDIT_CARD"); err != nil {
+		t.Fatalf("checkPaymentLimit rejected a card payment within the limit: %v", err)
+	}
+	if reason, err := reviewReason(amount, "CREDIT_CARD"); err != nil || reason == "" {
+		t.Fatalf("reviewReason(%v, CREDIT_CARD) = %q, %v; want a review above the card threshold", amount, reason, err)
+	}
+
+	balance, updates, err := debitSender(context.Background(), "1234567890", account, amount, fee, "CREDIT_CARD")
+	if err != nil {
+		t.Fatalf("debitSender returned %v", err)
+	}
+	if balance != -2005 {
+		t.Fatalf("balance after the payment is %v, want -2005", balance)
+	}
+
+	fields := map[string]interface{}{}
+	for _, update := range updates {
+		fields[update.Path] = update.Value
+	}
+	if fields["balance"] != -2005.0 || fields["credit_utilized"] != 2005.0 {
+		t.Fatalf("updates are %v, want balance -2005 and credit_utilized 2005", fields)
+	}
+}
+
+func TestCreditCardTransferBeyondCreditLimit(t *testing.T) {
+	account := map[string]interface{}{"balance": 1000.0, "reserved": 500.0, "credit_limit": 2000.0}
+
+	if _, _, err := debitSender(context.Background(), "1234567890", account, 2501, 0, "CREDIT_CARD"); err == nil {
+		t.Fatal("debitSender allowed a card payment beyond the credit limit")
+	}
+	if _, _, err := debitSender(context.Background(), "1234567890", account, 2500, 0, "CREDIT_CARD"); err != nil {
+		t.Fatalf("debitSender rejected a card payment using the whole credit line: %v", err)
+	}
+}
+
+func TestCreditCardTransferNeedsCreditAccount(t *testing.T) {
+	account := map[string]interface{}{"balance": 1000.0}
+
+	if _, _, err := debitSender(context.Background(), "1234567890", account, 100, 0, "CREDIT_CARD"); err == nil {
+		t.Fatal("debitSender allowed a card payment from an account without a credit line")
+	}
+
+	// Other payment methods only draw on the balance and leave credit_utilized alone
+	balance, updates, err := debitSender(context.Background(), "1234567890", account, 100, 0, "UPI")
+	if err != nil || balance != 900 || len(updates) != 1 {
+		t.Fatalf("debitSender for UPI = %v, %v, %v", balance, updates, err)
+	}
+}
+
+func TestCheckPaymentLimitForCreditCards(t *testing.T) {
+	paymentConfig, err := config.GetPaymentAmountYamlConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkPaymentLimit(paymentConfig.MaxCreditAmount+1, "CREDIT_CARD"); err == nil {
+		t.Fatal("checkPaymentLimit allowed a card payment above the maximum")
+	}
+	if err := checkPaymentLimit(100, "CREDIT"); err == nil {
+		t.Fatal("checkPaymentLimit accepted the unknown payment method CREDIT")
+	}
+}
+
+func TestCheckPaymentLimitRejectsNonPositiveAmounts(t *testing.T) {
+	tests := []struct {
+		amount        float64
+		paymentMethod string
+		allowed       bool
+	}{
+		{100, "UPI", true},
+		{0, "UPI", false},
+		{-500, "UPI", false},
+		{-500, "CREDIT_CARD", false},
+		{0.01, "CREDIT_CARD", true},
+	}
+
+	for _, tt := range tests {
+		err := checkPaymentLimit(tt.amount, tt.paymentMethod)
+		if (err == nil) != tt.allowed {
+			t.Errorf("checkPaymentLimit(%v, %s) = %v, allowed %v", tt.amount, tt.paymentMethod, err, tt.allowed)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"go-transaction/repository"
+	"go-transaction/utils"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UPIPinLockedError is returned while a UPI ID is locked after repeated wrong PINs.
+type UPIPinLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *UPIPinLockedError) Error() string {
+	return fmt.Sprintf("too many wrong UPI PINs, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// SetUPIPin sets the transaction PIN of a UPI ID and links the UPI ID to the user.
+// A UPI ID that already has a PIN can only be given a new one with ResetUPIPin.
+func SetUPIPin(ctx context.Context, userID string, setup entity.UPIPinSetup) error {
+	upiPinConfig, err := config.GetUPIPinYamlConfig()
+	if err != nil {
+		return fmt.Errorf("unable to load UPI PIN configuration: %w", err)
+	}
+	if len(setup.Pin) != upiPinConfig.Length {
+		return fmt.Errorf("PIN must be %d digits", upiPinConfig.Length)
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return err
+	}
+	defer client.Close()
+
+	// Only UPI IDs backed by one of the user's own bank accounts can be given a PIN
+	accNo, err := repository.GetAccNo(ctx, client, "upi_id", setup.UpiID)
+	if err != nil {
+		return err
+	}
+	holderID, err := repository.GetAccountHolderID(ctx, client, accNo)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(holderID, userID) {
+		log.Ctx(ctx).Error().Str("upi_id", utils.MaskUPI(setup.UpiID)).Msg("UPI ID belongs to another user's account")
+		return fmt.Errorf("UPI ID %s is not linked to an account of the user", utils.MaskUPI(setup.UpiID))
+	}
+
+	pinRef, err := upiPinRef(client, setup.UpiID)
+	if err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(setup.Pin), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash PIN: %v", err)
+	}
+
+	now := time.Now().Unix()
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docSnap, err := tx.Get(pinRef)
+		if err != nil && err.Error() != "rpc error: code = NotFound desc = " {
+			return fmt.Errorf("failed to fetch UPI PIN: %v", err)
+		}
+		if err == nil {
+			if owner, _ := docSnap.DataAt("UserID"); !strings.EqualFold(fmt.Sprint(owner), userID) {
+				return fmt.Errorf("UPI ID %s is linked to another user", utils.MaskUPI(setup.UpiID))
+			}
+			return fmt.Errorf("a PIN is already set for UPI ID %s, reset it instead", utils.MaskUPI(setup.UpiID))
+		}
+
+		return tx.Create(pinRef, entity.UPIPin{
+			UserID:    userID,
+			UpiID:     setup.UpiID,
+			Hash:      string(hash),
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("upi_id", utils.MaskUPI(setup.UpiID)).Msg("Failed to set UPI PIN")
+		return err
+	}
+
+	notify(ctx, client, entity.Notification{
+		UserID:  userID,
+		Type:    "upi_pin.set",
+		Message: fmt.Sprintf("A PIN was set for UPI ID %s", utils.MaskUPI(setup.UpiID)),
+	})
+	return nil
+}
+
+// ResetUPIPin replaces the PIN of one of the user's UPI IDs once the user has re-authenticated with
+// their password, and lifts any lockout. Wrong passwords count towards the login lockout.
+func ResetUPIPin(ctx context.Context, userID string, reset entity.UPIPinReset) error {
+	upiPinConfig, err := config.GetUPIPinYamlConfig()
+	if err != nil {
+		return fmt.Errorf("unable to load UPI PIN configuration: %w", err)
+	}
+	if len(reset.Pin) != upiPinConfig.Length {
+		return fmt.Errorf("PIN must be %d digits", upiPinConfig.Length)
+	}
+
+	if _, err := LoginUser(ctx, entity.Login{UserID: userID, Password: reset.Password}); err != nil {
+		return err
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return err
+	}
+	defer client.Close()
+
+	pinRef, err := upiPinRef(client, reset.UpiID)
+	if err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(reset.Pin), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash PIN: %v", err)
+	}
+
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := getUPIPin(tx, pinRef, userID, reset.UpiID); err != nil {
+			return err
+		}
+
+		return tx.Update(pinRef, []firestore.Update{
+			{Path: "Hash", Value: string(hash)},
+			{Path: "FailedAttempts", Value: 0},
+			{Path: "LockedUntil", Value: int64(0)},
+			{Path: "UpdatedAt", Value: time.Now().Unix()},
+		})
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("upi_id", utils.MaskUPI(reset.UpiID)).Msg("Failed to reset UPI PIN")
+		return err
+	}
+
+	notify(ctx, client, entity.Notification{
+		UserID:  userID,
+		Type:    "upi_pin.reset",
+		Message: fmt.Sprintf("The PIN of UPI ID %s was reset", utils.MaskUPI(reset.UpiID)),
+	})
+	return nil
+}
+
+// verifyUPIPin checks the PIN a user entered to pay from one of their UPI IDs.
+// Wrong PINs are counted, and once MaxAttempts are reached in a row the UPI ID is locked
+// for the configured lockout; a correct PIN clears the count.
+func verifyUPIPin(ctx context.Context, client *firestore.Client, userID, upiID, pin string) error {
+	upiPinConfig, err := config.GetUPIPinYamlConfig()
+	if err != nil {
+		return fmt.Errorf("unable to load UPI PIN configuration: %w", err)
+	}
+	if !upiPinConfig.Enabled {
+		return nil
+	}
+	if pin == "" {
+		return fmt.Errorf("the UPI PIN of %s is required for this payment", utils.MaskUPI(upiID))
+	}
+
+	pinRef, err := upiPinRef(client, upiID)
+	if err != nil {
+		return err
+	}
+
+	// A wrong PIN must still be counted, so it is reported through rejected rather than by
+	// failing the Firestore transaction
+	var rejected error
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		rejected = nil
+
+		upiPin, err := getUPIPin(tx, pinRef, userID, upiID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if upiPin.LockedUntil > now.Unix() {
+			return &UPIPinLockedError{RetryAfter: time.Unix(upiPin.LockedUntil, 0).Sub(now)}
+		}
+
+		if bcrypt.CompareHashAndPassword([]byte(upiPin.Hash), []byte(pin)) != nil {
+			upiPin.FailedAttempts++
+			rejected = fmt.Errorf("invalid UPI PIN, %d attempts left", upiPinConfig.MaxAttempts-upiPin.FailedAttempts)
+			if upiPin.FailedAttempts >= upiPinConfig.MaxAttempts {
+				rejected = &UPIPinLockedError{RetryAfter: upiPinConfig.Lockout}
+				upiPin.FailedAttempts = 0
+				upiPin.LockedUntil = now.Add(upiPinConfig.Lockout).Unix()
+			}
+			return tx.Update(pinRef, []firestore.Update{
+				{Path: "FailedAttempts", Value: upiPin.FailedAttempts},
+				{Path: "LockedUntil", Value: upiPin.LockedUntil},
+			})
+		}
+
+		if upiPin.FailedAttempts == 0 {
+			return nil
+		}
+		return tx.Update(pinRef, []firestore.Update{{Path: "FailedAttempts", Value: 0}})
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("upi_id", utils.MaskUPI(upiID)).Msg("Failed to verify UPI PIN")
+		return err
+	}
+	if rejected != nil {
+		log.Ctx(ctx).Warn().Err(rejected).Str("upi_id", utils.MaskUPI(upiID)).Msg("Wrong UPI PIN entered")
+	}
+	return rejected
+}
+
+// upiPinRef returns the document holding the PIN of a UPI ID.
+func upiPinRef(client *firestore.Client, upiID string) (*firestore.DocumentRef, error) {
+	id := strings.ToLower(strings.TrimSpace(upiID))
+	if id == "" || strings.Contains(id, "/") {
+		return nil, fmt.Errorf("invalid UPI ID: %s", utils.MaskUPI(upiID))
+	}
+	return client.Collection("UPIPin").Doc(id), nil
+}
+
+func getUPIPin(tx *firestore.Transaction, pinRef *firestore.DocumentRef, userID, upiID string) (*entity.UPIPin, error) {
+	docSnap, err := tx.Get(pinRef)
+	if err != nil {
+		if err.Error() == "rpc error: code = NotFound desc = " {
+			return nil, fmt.Errorf("no PIN is set for UPI ID %s", utils.MaskUPI(upiID))
+		}
+		return nil, fmt.Errorf("failed to fetch UPI PIN: %v", err)
+	}
+
+	var upiPin entity.UPIPin
+	if err := docSnap.DataTo(&upiPin); err != nil {
+		return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+	}
+	if !strings.EqualFold(upiPin.UserID, userID) {
+		return nil, fmt.Errorf("UPI ID %s is linked to another user", utils.MaskUPI(upiID))
+	}
+	return &upiPin, nil
+}
@@ -6,26 +6,39 @@ import (
 	"fmt"
 	"go-transaction/config"
 	"go-transaction/entity"
+	"go-transaction/metrics"
+	"go-transaction/ratelimit"
+	"strings"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/api/iterator"
 )
 
+// LoginLockedError is returned by LoginUser while an account is locked out after repeated failed logins.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed logins, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
 func LoginUser(ctx context.Context, credentials entity.Login) (*entity.User, error) {
 	var user entity.User
 
 	// Initialize Firebase app
 	app, err := config.InitFirebase()
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to initialize Firebase app")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firebase app")
 		return nil, err
 	}
 
 	// Get Firestore client
 	client, err := config.GetFirestoreClient(app)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get Firestore client")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to get Firestore client")
 		return nil, err
 	}
 	defer client.Close()
@@ -44,38 +57,57 @@ func LoginUser(ctx context.Context, credentials entity.Login) (*entity.User, err
 
 	// If using email query, get the first document from the iterator
 	if userQuery != nil {
+		// Emails that match no user are counted on their own, so probing for accounts is throttled too
+		emailKey := "login:" + strings.ToLower(credentials.Email)
+		if err := checkLoginLockout(ctx, emailKey); err != nil {
+			return nil, err
+		}
 		docSnap, err := userQuery.Next()
 		if err == iterator.Done {
-			log.Error().Msg("User with the provided email not found")
+			log.Ctx(ctx).Error().Msg("User with the provided email not found")
+			metrics.RecordLogin("unknown_user")
+			loginFailed(ctx, emailKey)
 			return nil, fmt.Errorf("no user found with the provided email")
 		} else if err != nil {
-			log.Error().Err(err).Msg("Error fetching user document")
+			log.Ctx(ctx).Error().Err(err).Msg("Error fetching user document")
+			metrics.RecordLogin("error")
 			return nil, fmt.Errorf("failed to fetch user document: %v", err)
 		}
 		// Set docRef to the result from the query
 		docRef = docSnap.Ref
 	}
 
+	// Failed logins are counted per user document, whether the account was looked up by ID or by email
+	lockoutKey := "login:" + strings.ToLower(docRef.ID)
+	if err := checkLoginLockout(ctx, lockoutKey); err != nil {
+		return nil, err
+	}
+
 	// Fetch user document by document reference
 	docSnap, err := docRef.Get(ctx)
 	if err != nil {
 		if err.Error() == "rpc error: code = NotFound desc = " {
-			log.Error().Msg("User document not found")
+			log.Ctx(ctx).Error().Msg("User document not found")
+			metrics.RecordLogin("unknown_user")
+			loginFailed(ctx, lockoutKey)
 			return nil, fmt.Errorf("no user found")
 		}
-		log.Error().Err(err).Msg("Error fetching user document")
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching user document")
+		metrics.RecordLogin("error")
 		return nil, fmt.Errorf("failed to fetch user document: %v", err)
 	}
 
 	// Map Firestore document data to User struct
 	err = docSnap.DataTo(&user)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to map Firestore document to struct")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to map Firestore document to struct")
 		return nil, fmt.Errorf("failed to map Firestore document: %v", err)
 	}
 
 	// Check if the password matches
 	if user.Password != credentials.Password {
+		metrics.RecordLogin("invalid_password")
+		loginFailed(ctx, lockoutKey)
 		return nil, fmt.Errorf("invalid password")
 	}
 
@@ -86,11 +118,42 @@ func LoginUser(ctx context.Context, credentials entity.Login) (*entity.User, err
 	user.Password = ""
 
 	// Log success
-	log.Info().Str("user_id", user.UserID).Msg("User fetched successfully")
+	log.Ctx(ctx).Info().Str("user_id", user.UserID).Msg("User fetched successfully")
+	metrics.RecordLogin("success")
+	if err := ratelimit.LoginSucceeded(lockoutKey); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to clear failed logins")
+	}
 
 	return &user, nil
 }
 
+// checkLoginLockout returns a LoginLockedError while logins for lockoutKey are locked.
+// Lockout state that cannot be read does not block the login.
+func checkLoginLockout(ctx context.Context, lockoutKey string) error {
+	retryAfter, err := ratelimit.LoginLockedFor(lockoutKey)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to check login lockout")
+		return nil
+	}
+	if retryAfter > 0 {
+		metrics.RecordLogin("locked")
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// loginFailed counts a failed login towards the account's lockout.
+func loginFailed(ctx context.Context, lockoutKey string) {
+	lockedFor, err := ratelimit.LoginFailed(lockoutKey)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to record failed login")
+		return
+	}
+	if lockedFor > 0 {
+		log.Ctx(ctx).Warn().Dur("locked_for", lockedFor).Msg("Account locked after repeated failed logins")
+	}
+}
+
 
 func GetUserByID(ctx context.Context, userID string) (*entity.User, error) {
 	var user entity.User
@@ -98,14 +161,14 @@ func GetUserByID(ctx context.Context, userID string) (*entity.User, error) {
 	// Initialize Firebase app
 	app, err := config.InitFirebase()
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to initialize Firebase app")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firebase app")
 		return nil, err
 	}
 
 	// Get Firestore client
 	client, err := config.GetFirestoreClient(app)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get Firestore client")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to get Firestore client")
 		return nil, err
 	}
 	defer client.Close()
@@ -115,17 +178,17 @@ func GetUserByID(ctx context.Context, userID string) (*entity.User, error) {
 	docSnap, err := docRef.Get(ctx)
 	if err != nil {
 		if err.Error() == "rpc error: code = NotFound desc = " {
-			log.Error().Msg("User document not found")
+			log.Ctx(ctx).Error().Msg("User document not found")
 			return nil, fmt.Errorf("no user found with the provided ID")
 		}
-		log.Error().Err(err).Msg("Error fetching user document")
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching user document")
 		return nil, fmt.Errorf("failed to fetch user document: %v", err)
 	}
 
 	// Map Firestore document data to User struct
 	err = docSnap.DataTo(&user)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to map Firestore document to struct")
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to map Firestore document to struct")
 		return nil, fmt.Errorf("failed to map Firestore document: %v", err)
 	}
 
@@ -136,7 +199,7 @@ func GetUserByID(ctx context.Context, userID string) (*entity.User, error) {
 	user.Password = ""
 
 	// Log success
-	log.Info().Str("user_id", user.UserID).Msg("User fetched successfully")
+	log.Ctx(ctx).Info().Str("user_id", user.UserID).Msg("User fetched successfully")
 
 	return &user, nil
 }
\ No newline at end of file
@@ -0,0 +1,557 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-transaction/config"
+	"go-transaction/entity"
+	"go-transaction/utils"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/iterator"
+)
+
+// CreateWebhookSubscription registers a webhook endpoint for the given owner.
+// The returned subscription includes the signing secret; it is not returned again afterwards.
+func CreateWebhookSubscription(ctx context.Context, requestBody entity.WebhookSubscriptionRequest, ownerID string) (*entity.WebhookSubscription, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	secret := requestBody.Secret
+	if secret == "" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %v", err)
+		}
+		secret = "whsec_" + hex.EncodeToString(key)
+	}
+
+	docRef := client.Collection("WebhookSubscription").NewDoc()
+	subscription := &entity.WebhookSubscription{
+		ID:         docRef.ID,
+		OwnerID:    ownerID,
+		URL:        requestBody.URL,
+		EventTypes: requestBody.EventTypes,
+		Secret:     secret,
+		Active:     true,
+		CreatedAt:  time.Now().Unix(),
+	}
+
+	if _, err := docRef.Create(ctx, subscription); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to store webhook subscription")
+		return nil, fmt.Errorf("failed to store webhook subscription: %v", err)
+	}
+
+	return subscription, nil
+}
+
+// ListWebhookSubscriptions returns the owner's active subscriptions, without their secrets.
+func ListWebhookSubscriptions(ctx context.Context, ownerID string) ([]*entity.WebhookSubscription, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	subscriptions, err := activeSubscriptions(ctx, client, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+	return subscriptions, nil
+}
+
+// DeleteWebhookSubscription deactivates a subscription. Its delivery log is kept.
+func DeleteWebhookSubscription(ctx context.Context, subscriptionID, role, userID string) error {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return err
+	}
+	defer client.Close()
+
+	if _, err := getOwnedSubscription(ctx, client, subscriptionID, role, userID); err != nil {
+		return err
+	}
+
+	_, err = client.Collection("WebhookSubscription").Doc(subscriptionID).Update(ctx, []firestore.Update{
+		{Path: "Active", Value: false},
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to deactivate webhook subscription")
+		return fmt.Errorf("failed to deactivate webhook subscription: %v", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns the delivery log of a subscription, newest first.
+func ListWebhookDeliveries(ctx context.Context, subscriptionID, role, userID string, pageSize, pageNumber int) ([]*entity.WebhookDelivery, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	if _, err := getOwnedSubscription(ctx, client, subscriptionID, role, userID); err != nil {
+		return nil, err
+	}
+
+	iter := client.Collection("WebhookDelivery").Where("SubscriptionID", "==", subscriptionID).Documents(ctx)
+	defer iter.Stop()
+
+	deliveries := []*entity.WebhookDelivery{}
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Error fetching webhook deliveries")
+			return nil, fmt.Errorf("failed to fetch webhook deliveries: %v", err)
+		}
+
+		var delivery entity.WebhookDelivery
+		if err := docSnap.DataTo(&delivery); err != nil {
+			return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].CreatedAt > deliveries[j].CreatedAt })
+
+	startIndex := (pageNumber - 1) * pageSize
+	endIndex := startIndex + pageSize
+	if startIndex > len(deliveries) {
+		return []*entity.WebhookDelivery{}, nil
+	}
+	if endIndex > len(deliveries) {
+		endIndex = len(deliveries)
+	}
+
+	return deliveries[startIndex:endIndex], nil
+}
+
+// RedeliverWebhook queues a delivery to be sent again right away, with a fresh set of attempts.
+func RedeliverWebhook(ctx context.Context, deliveryID, role, userID string) (*entity.WebhookDelivery, error) {
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return nil, err
+	}
+	defer client.Close()
+
+	deliveryRef := client.Collection("WebhookDelivery").Doc(deliveryID)
+	docSnap, err := deliveryRef.Get(ctx)
+	if err != nil {
+		if err.Error() == "rpc error: code = NotFound desc = " {
+			return nil, fmt.Errorf("no webhook delivery found with ID: %s", deliveryID)
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching webhook delivery")
+		return nil, fmt.Errorf("failed to fetch webhook delivery: %v", err)
+	}
+
+	var delivery entity.WebhookDelivery
+	if err := docSnap.DataTo(&delivery); err != nil {
+		return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+	}
+
+	if !strings.EqualFold(role, "ADMIN") && !strings.EqualFold(delivery.OwnerID, userID) {
+		return nil, fmt.Errorf("Invalid User : %s %s", userID, role)
+	}
+
+	delivery.Status = "pending"
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now().Unix()
+
+	if _, err := deliveryRef.Update(ctx, []firestore.Update{
+		{Path: "Status", Value: delivery.Status},
+		{Path: "Attempts", Value: delivery.Attempts},
+		{Path: "NextAttemptAt", Value: delivery.NextAttemptAt},
+	}); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to queue webhook redelivery")
+		return nil, fmt.Errorf("failed to queue webhook redelivery: %v", err)
+	}
+	if strings.EqualFold(role, "ADMIN") && !strings.EqualFold(delivery.OwnerID, userID) {
+		recordAudit(ctx, client, adminAudit(ctx, "webhook.redelivered", deliveryID, ""))
+	}
+
+	return &delivery, nil
+}
+
+// enqueueWebhookEvent queues a delivery of the event to each of the owner's active subscriptions
+// that accepts its type. Like notify, it is best effort and never fails the calling operation.
+func enqueueWebhookEvent(ctx context.Context, client *firestore.Client, ownerID, eventType string, data interface{}) {
+	subscriptions, err := activeSubscriptions(ctx, client, ownerID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("owner_id", ownerID).Msg("Failed to load webhook subscriptions")
+		return
+	}
+
+	now := time.Now().Unix()
+	for _, subscription := range subscriptions {
+		if !acceptsEvent(subscription, eventType) {
+			continue
+		}
+
+		docRef := client.Collection("WebhookDelivery").NewDoc()
+		payload, err := json.Marshal(entity.WebhookEvent{
+			ID:        docRef.ID,
+			Type:      eventType,
+			CreatedAt: now,
+			Data:      data,
+		})
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Failed to encode webhook event")
+			return
+		}
+
+		if _, err := docRef.Create(ctx, entity.WebhookDelivery{
+			ID:             docRef.ID,
+			SubscriptionID: subscription.ID,
+			OwnerID:        ownerID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         "pending",
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		}); err != nil {
+			log.Ctx(ctx).Error().
+				Err(err).
+				Str("subscription_id", subscription.ID).
+				Str("type", eventType).
+				Msg("Failed to queue webhook delivery")
+		}
+	}
+}
+
+func acceptsEvent(subscription *entity.WebhookSubscription, eventType string) bool {
+	if len(subscription.EventTypes) == 0 {
+		return true
+	}
+	for _, accepted := range subscription.EventTypes {
+		if accepted == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func activeSubscriptions(ctx context.Context, client *firestore.Client, ownerID string) ([]*entity.WebhookSubscription, error) {
+	iter := client.Collection("WebhookSubscription").
+		Where("OwnerID", "==", ownerID).
+		Where("Active", "==", true).
+		Documents(ctx)
+	defer iter.Stop()
+
+	subscriptions := []*entity.WebhookSubscription{}
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch webhook subscriptions: %v", err)
+		}
+
+		var subscription entity.WebhookSubscription
+		if err := docSnap.DataTo(&subscription); err != nil {
+			return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		subscriptions = append(subscriptions, &subscription)
+	}
+	return subscriptions, nil
+}
+
+// getOwnedSubscription returns a subscription if it belongs to the user or the user is an admin.
+func getOwnedSubscription(ctx context.Context, client *firestore.Client, subscriptionID, role, userID string) (*entity.WebhookSubscription, error) {
+	subscription, err := getWebhookSubscription(ctx, client, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(role, "ADMIN") && !strings.EqualFold(subscription.OwnerID, userID) {
+		return nil, fmt.Errorf("Invalid User : %s %s", userID, role)
+	}
+	return subscription, nil
+}
+
+func getWebhookSubscription(ctx context.Context, client *firestore.Client, subscriptionID string) (*entity.WebhookSubscription, error) {
+	docSnap, err := client.Collection("WebhookSubscription").Doc(subscriptionID).Get(ctx)
+	if err != nil {
+		if err.Error() == "rpc error: code = NotFound desc = " {
+			return nil, fmt.Errorf("no webhook subscription found with ID: %s", subscriptionID)
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("Error fetching webhook subscription")
+		return nil, fmt.Errorf("failed to fetch webhook subscription: %v", err)
+	}
+
+	var subscription entity.WebhookSubscription
+	if err := docSnap.DataTo(&subscription); err != nil {
+		return nil, fmt.Errorf("failed to map Firestore document: %v", err)
+	}
+	return &subscription, nil
+}
+
+// DispatchWebhooks sends every pending delivery that is due and returns the number that
+// succeeded and failed in this pass. Failed attempts are retried with exponential backoff
+// until the configured number of attempts is used up.
+func DispatchWebhooks(ctx context.Context) (int, int, error) {
+	webhookConfig, err := config.GetWebhookYamlConfig()
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to load webhook configuration: %w", err)
+	}
+
+	client, err := config.FirebaseInitialization()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Failed to initialize Firestore client")
+		return 0, 0, err
+	}
+	defer client.Close()
+
+	iter := client.Collection("WebhookDelivery").Where("Status", "==", "pending").Documents(ctx)
+	defer iter.Stop()
+
+	if webhookConfig.Timeout <= 0 {
+		webhookConfig.Timeout = 10 * time.Second
+	}
+	httpClient := webhookHTTPClient(webhookConfig.Timeout)
+	now := time.Now().Unix()
+	succeeded, failed := 0, 0
+	for {
+		docSnap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return succeeded, failed, fmt.Errorf("failed to fetch webhook deliveries: %v", err)
+		}
+
+		var delivery entity.WebhookDelivery
+		if err := docSnap.DataTo(&delivery); err != nil {
+			return succeeded, failed, fmt.Errorf("failed to map Firestore document: %v", err)
+		}
+		if delivery.NextAttemptAt > now {
+			continue
+		}
+
+		if !claimDelivery(ctx, client, docSnap.Ref, webhookConfig.Timeout) {
+			continue
+		}
+
+		ok, err := attemptDelivery(ctx, client, httpClient, webhookConfig, &delivery)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("delivery_id", delivery.ID).Msg("Failed to record webhook delivery attempt")
+			continue
+		}
+		if ok {
+			succeeded++
+		} else {
+			failed++
+		}
+	}
+
+	return succeeded, failed, nil
+}
+
+// webhookHTTPClient returns the client deliveries are sent with. It only dials public addresses and does not
+// follow redirects, so a subscription cannot point the dispatcher at internal services; a redirect counts
+// as a failed attempt.
+func webhookHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         utils.WebhookDialContext(&net.Dialer{Timeout: timeout}),
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// claimDelivery pushes a due delivery's next attempt past the send timeout, so another
+// dispatcher does not pick it up while this one is sending it.
+func claimDelivery(ctx context.Context, client *firestore.Client, ref *firestore.DocumentRef, timeout time.Duration) bool {
+	err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docSnap, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+
+		var delivery entity.WebhookDelivery
+		if err := docSnap.DataTo(&delivery); err != nil {
+			return err
+		}
+		if delivery.Status != "pending" || delivery.NextAttemptAt > time.Now().Unix() {
+			return fmt.Errorf("delivery already claimed")
+		}
+
+		return tx.Update(ref, []firestore.Update{
+			{Path: "NextAttemptAt", Value: time.Now().Add(2 * timeout).Unix()},
+		})
+	})
+	return err == nil
+}
+
+// errSubscriptionInactive is returned for deliveries whose subscription was deactivated after they were
+// queued. Such deliveries are failed immediately rather than retried.
+var errSubscriptionInactive = errors.New("subscription is no longer active")
+
+// errWebhookURLRefused is returned for deliveries to subscriptions registered with a plain http URL before
+// https was required. Like deliveries to inactive subscriptions, they are failed immediately.
+var errWebhookURLRefused = errors.New("subscription URL must use https")
+
+// attemptDelivery POSTs a delivery to its subscription and records the outcome.
+// It reports whether the receiver acknowledged the event with a 2xx response.
+func attemptDelivery(ctx context.Context, client *firestore.Client, httpClient *http.Client, webhookConfig *entity.WebhookConfig, delivery *entity.WebhookDelivery) (bool, error) {
+	now := time.Now()
+	delivery.Attempts++
+
+	statusCode, sendErr := sendDelivery(ctx, client, httpClient, delivery, now.Unix())
+	updates := deliveryUpdates(webhookConfig, delivery, statusCode, sendErr, now)
+
+	delivered := sendErr == nil
+	if _, err := client.Collection("WebhookDelivery").Doc(delivery.ID).Update(ctx, updates); err != nil {
+		return delivered, err
+	}
+	return delivered, nil
+}
+
+// deliveryUpdates returns the changes recording an attempt at a delivery: it succeeds on a 2xx response,
+// is scheduled for a retry after a backoff, or fails once its attempts are used up or its subscription can no
+// longer be delivered to.
+func deliveryUpdates(webhookConfig *entity.WebhookConfig, delivery *entity.WebhookDelivery, statusCode int, sendErr error, now time.Time) []firestore.Update {
+	updates := []firestore.Update{
+		{Path: "Attempts", Value: delivery.Attempts},
+		{Path: "LastAttemptAt", Value: now.Unix()},
+		{Path: "LastStatusCode", Value: statusCode},
+	}
+
+	switch {
+	case sendErr == nil:
+		updates = append(updates,
+			firestore.Update{Path: "Status", Value: "succeeded"},
+			firestore.Update{Path: "DeliveredAt", Value: now.Unix()},
+			firestore.Update{Path: "LastError", Value: ""},
+		)
+	case delivery.Attempts >= webhookConfig.MaxAttempts || errors.Is(sendErr, errSubscriptionInactive) || errors.Is(sendErr, errWebhookURLRefused):
+		updates = append(updates,
+			firestore.Update{Path: "Status", Value: "failed"},
+			firestore.Update{Path: "LastError", Value: sendErr.Error()},
+		)
+	default:
+		updates = append(updates,
+			firestore.Update{Path: "NextAttemptAt", Value: now.Add(webhookBackoff(webhookConfig, delivery.Attempts)).Unix()},
+			firestore.Update{Path: "LastError", Value: sendErr.Error()},
+		)
+	}
+	return updates
+}
+
+// sendDelivery looks up the delivery's subscription and POSTs the payload to it.
+func sendDelivery(ctx context.Context, client *firestore.Client, httpClient *http.Client, delivery *entity.WebhookDelivery, timestamp int64) (int, error) {
+	subscription, err := getWebhookSubscription(ctx, client, delivery.SubscriptionID)
+	if err != nil {
+		return 0, err
+	}
+	if !subscription.Active {
+		return 0, fmt.Errorf("%w: %s", errSubscriptionInactive, subscription.ID)
+	}
+	if !strings.HasPrefix(strings.ToLower(subscription.URL), "https://") {
+		return 0, fmt.Errorf("%w: %s", errWebhookURLRefused, subscription.ID)
+	}
+	return postWebhook(ctx, httpClient, subscription, delivery, timestamp)
+}
+
+// postWebhook signs and POSTs the delivery payload and returns the HTTP status code received.
+func postWebhook(ctx context.Context, httpClient *http.Client, subscription *entity.WebhookSubscription, delivery *entity.WebhookDelivery, timestamp int64) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", utils.SignWebhook(subscription.Secret, timestamp, body))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// webhookBackoff returns the delay before the retry that follows the given attempt:
+// BaseBackoff doubled after every failed attempt, capped at MaxBackoff.
+func webhookBackoff(webhookConfig *entity.WebhookConfig, attempts int) time.Duration {
+	delay := webhookConfig.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if webhookConfig.MaxBackoff > 0 && delay >= webhookConfig.MaxBackoff {
+			return webhookConfig.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// RunWebhookDispatcher periodically sends pending webhook deliveries until the context is cancelled.
+// Deliveries already being sent are not cut off by cancellation.
+func RunWebhookDispatcher(ctx context.Context) {
+	webhookConfig, err := config.GetWebhookYamlConfig()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Webhook dispatcher not started")
+		return
+	}
+
+	interval := webhookConfig.PollInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			succeeded, failed, err := DispatchWebhooks(context.WithoutCancel(ctx))
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("Failed to dispatch webhooks")
+			}
+			if succeeded > 0 || failed > 0 {
+				log.Ctx(ctx).Info().Int("succeeded", succeeded).Int("failed", failed).Msg("Dispatched webhooks")
+			}
+		}
+	}
+}
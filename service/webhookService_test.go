@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-transaction/entity"
+	"go-transaction/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+func testWebhookConfig() *entity.WebhookConfig {
+	return &entity.WebhookConfig{
+		Timeout:     time.Second,
+		MaxAttempts: 4,
+		BaseBackoff: 10 * time.Second,
+		MaxBackoff:  30 * time.Second,
+	}
+}
+
+// applyUpdates records the updates of an attempt on the delivery, as Firestore would.
+func applyUpdates(delivery *entity.WebhookDelivery, updates []firestore.Update) {
+	for _, update := range updates {
+		switch update.Path {
+		case "Status":
+			delivery.Status = update.Value.(string)
+		case "NextAttemptAt":
+			delivery.NextAttemptAt = update.Value.(int64)
+		case "LastStatusCode":
+			delivery.LastStatusCode = update.Value.(int)
+		case "LastError":
+			delivery.LastError = update.Value.(string)
+		case "DeliveredAt":
+			delivery.DeliveredAt = update.Value.(int64)
+		}
+	}
+}
+
+func TestPostWebhookSignsPayload(t *testing.T) {
+	subscription := &entity.WebhookSubscription{ID: "sub-1", Secret: "whsec_test"}
+	delivery := &entity.WebhookDelivery{ID: "del-1", EventType: "transaction.success", Payload: `{"id":"tx-1"}`}
+
+	var verified bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get("X-Webhook-Timestamp"), 10, 64)
+		verified = utils.VerifyWebhook(subscription.Secret, timestamp, body, r.Header.Get("X-Webhook-Signature")) &&
+			r.Header.Get("X-Webhook-Event") == delivery.EventType &&
+			r.Header.Get("X-Webhook-Delivery") == delivery.ID
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	subscription.URL = server.URL
+
+	statusCode, err := postWebhook(context.Background(), server.Client(), subscription, delivery, 1700000000)
+	if err != nil {
+		t.Fatalf("postWebhook returned %v", err)
+	}
+	if statusCode != http.StatusNoContent {
+		t.Fatalf("status code %d, want %d", statusCode, http.StatusNoContent)
+	}
+	if !verified {
+		t.Fatal("receiver could not verify the webhook signature and headers")
+	}
+	if utils.VerifyWebhook("whsec_other", 1700000000, []byte(delivery.Payload), utils.SignWebhook(subscription.Secret, 1700000000, []byte(delivery.Payload))) {
+		t.Fatal("signature verified with the wrong secret")
+	}
+}
+
+func TestPostWebhookStatusCodes(t *testing.T) {
+	for _, statusCode := range []int{200, 202, 299, 301, 400, 410, 500, 503} {
+		t.Run(strconv.Itoa(statusCode), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(statusCode)
+			}))
+			defer server.Close()
+
+			subscription := &entity.WebhookSubscription{URL: server.URL, Secret: "whsec_test"}
+			// The dispatcher's client, dialling the loopback test server through its own transport
+			client := webhookHTTPClient(time.Second)
+			client.Transport = server.Client().Transport
+
+			got, err := postWebhook(context.Background(), client, subscription, &entity.WebhookDelivery{Payload: "{}"}, 1700000000)
+			if got != statusCode {
+				t.Fatalf("status code %d, want %d", got, statusCode)
+			}
+			if want := statusCode >= 200 && statusCode <= 299; (err == nil) != want {
+				t.Fatalf("postWebhook returned %v for status %d", err, statusCode)
+			}
+		})
+	}
+}
+
+func TestWebhookBackoffDoublesUpToCap(t *testing.T) {
+	webhookConfig := testWebhookConfig()
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second, 30 * time.Second, 30 * time.Second}
+	for i, delay := range want {
+		if got := webhookBackoff(webhookConfig, i+1); got != delay {
+			t.Fatalf("backoff after attempt %d is %v, want %v", i+1, got, delay)
+		}
+	}
+
+	webhookConfig.MaxBackoff = 0
+	if got := webhookBackoff(webhookConfig, 5); got != 160*time.Second {
+		t.Fatalf("uncapped backoff after attempt 5 is %v, want %v", got, 160*time.Second)
+	}
+}
+
+func TestDeliveryRetriesUntilMaxAttemptsThenFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	webhookConfig := testWebhookConfig()
+	subscription := &entity.WebhookSubscription{URL: server.URL, Secret: "whsec_test", Active: true}
+	delivery := &entity.WebhookDelivery{ID: "del-1", Payload: "{}", Status: "pending"}
+	now := time.Unix(1700000000, 0)
+
+	for attempt := 1; attempt <= webhookConfig.MaxAttempts; attempt++ {
+		delivery.Attempts++
+		statusCode, sendErr := postWebhook(context.Background(), server.Client(), subscription, delivery, now.Unix())
+		applyUpdates(delivery, deliveryUpdates(webhookConfig, delivery, statusCode, sendErr, now))
+
+		if delivery.LastStatusCode != http.StatusInternalServerError || delivery.LastError == "" {
+			t.Fatalf("attempt %d recorded status %d and error %q", attempt, delivery.LastStatusCode, delivery.LastError)
+		}
+		if attempt < webhookConfig.MaxAttempts {
+			if delivery.Status != "pending" {
+				t.Fatalf("delivery is %s after attempt %d, want pending", delivery.Status, attempt)
+			}
+			if want := now.Add(webhookBackoff(webhookConfig, attempt)).Unix(); delivery.NextAttemptAt != want {
+				t.Fatalf("next attempt at %d after attempt %d, want %d", delivery.NextAttemptAt, attempt, want)
+			}
+		}
+	}
+	if delivery.Status != "failed" {
+		t.Fatalf("delivery is %s after %d attempts, want failed", delivery.Status, webhookConfig.MaxAttempts)
+	}
+}
+
+func TestDeliverySucceedsOn2xx(t *testing.T) {
+	delivery := &entity.WebhookDelivery{ID: "del-1", Status: "pending", Attempts: 2, LastError: "receiver responded with status 500"}
+	now := time.Unix(1700000000, 0)
+
+	applyUpdates(delivery, deliveryUpdates(testWebhookConfig(), delivery, http.StatusOK, nil, now))
+	if delivery.Status != "succeeded" || delivery.DeliveredAt != now.Unix() || delivery.LastError != "" {
+		t.Fatalf("delivery after a 2xx is %+v", delivery)
+	}
+}
+
+func TestDeliveryToInactiveSubscriptionFailsImmediately(t *testing.T) {
+	delivery := &entity.WebhookDelivery{ID: "del-1", Status: "pending", Attempts: 1}
+	sendErr := fmt.Errorf("%w: %s", errSubscriptionInactive, "sub-1")
+
+	applyUpdates(delivery, deliveryUpdates(testWebhookConfig(), delivery, 0, sendErr, time.Unix(1700000000, 0)))
+	if delivery.Status != "failed" {
+		t.Fatalf("delivery to an inactive subscription is %s, want failed", delivery.Status)
+	}
+	if !errors.Is(sendErr, errSubscriptionInactive) || delivery.LastError != sendErr.Error() {
+		t.Fatalf("delivery recorded error %q", delivery.LastError)
+	}
+}
+
+func TestWebhookHTTPClientRefusesInternalAddresses(t *testing.T) {
+	var reached bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer server.Close()
+
+	subscription := &entity.WebhookSubscription{URL: server.URL, Secret: "whsec_test"}
+	if _, err := postWebhook(context.Background(), webhookHTTPClient(time.Second), subscription, &entity.WebhookDelivery{Payload: "{}"}, 1700000000); err == nil {
+		t.Fatal("postWebhook delivered to a loopback address")
+	}
+	if reached {
+		t.Fatal("the dispatcher's client connected to a loopback address")
+	}
+}
+
+func TestWebhookHTTPClientDoesNotFollowRedirects(t *testing.T) {
+	var followed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			followed = true
+			return
+		}
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := webhookHTTPClient(time.Second)
+	client.Transport = server.Client().Transport
+
+	subscription := &entity.WebhookSubscription{URL: server.URL + "/hook", Secret: "whsec_test"}
+	statusCode, err := postWebhook(context.Background(), client, subscription, &entity.WebhookDelivery{Payload: "{}"}, 1700000000)
+	if followed {
+		t.Fatal("the dispatcher's client followed a redirect")
+	}
+	if statusCode != http.StatusFound || err == nil {
+		t.Fatalf("postWebhook = %d, %v; want a failed 302", statusCode, err)
+	}
+}
+
+func TestDeliveryToPlainHTTPSubscriptionFailsImmediately(t *testing.T) {
+	delivery := &entity.WebhookDelivery{ID: "del-1", Status: "pending", Attempts: 1}
+	sendErr := fmt.Errorf("%w: %s", errWebhookURLRefused, "sub-1")
+
+	applyUpdates(delivery, deliveryUpdates(testWebhookConfig(), delivery, 0, sendErr, time.Unix(1700000000, 0)))
+	if delivery.Status != "failed" {
+		t.Fatalf("delivery to a plain http subscription is %s, want failed", delivery.Status)
+	}
+}
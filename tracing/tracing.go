@@ -0,0 +1,115 @@
+// Package tracing configures OpenTelemetry tracing and provides the helpers used to create spans
+// and to carry trace context across HTTP and RabbitMQ.
+//
+// The Firestore client library creates spans for its operations on the global tracer provider,
+// so installing the provider in Setup is enough for them to be exported as children of the
+// request spans, as long as the request context is passed down.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go-transaction/config"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "go-transaction"
+
+// Setup installs the global tracer provider and propagator from the tracing section of the configuration.
+// The returned function flushes buffered spans and must be called on shutdown.
+// When tracing is disabled, spans are still created (and trace context still propagated) but never exported.
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	tracingConfig, err := config.GetTracingYamlConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !tracingConfig.Enabled || tracingConfig.Exporter == "none" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var (
+		exporter sdktrace.SpanExporter
+		output   io.WriteCloser
+	)
+	switch tracingConfig.Exporter {
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "file":
+		output, err = os.OpenFile(tracingConfig.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %v", err)
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(output))
+	case "otlp":
+		opts := []otlptracehttp.Option{}
+		if tracingConfig.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(tracingConfig.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter: %s", tracingConfig.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %v", tracingConfig.Exporter, err)
+	}
+
+	serviceName := tracingConfig.ServiceName
+	if serviceName == "" {
+		serviceName = tracerName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.DeploymentEnvironment(config.ReadEnvConfig()),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(tracingConfig.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	log.Info().Str("exporter", tracingConfig.Exporter).Float64("sample_ratio", tracingConfig.SampleRatio).Msg("Tracing enabled")
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if output != nil {
+			output.Close()
+		}
+		return err
+	}, nil
+}
+
+// Start starts a span as a child of the span in ctx, if any.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on the span, if any, and ends it. It is meant to be deferred with a named error result:
+//
+//	ctx, span := tracing.Start(ctx, "name")
+//	defer func() { tracing.End(span, err) }()
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
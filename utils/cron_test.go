@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronRejectsInvalidExpressions(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"too few fields", "0 9 * *"},
+		{"too many fields", "0 9 * * * *"},
+		{"minute out of range", "60 9 * * *"},
+		{"hour out of range", "0 24 * * *"},
+		{"day of month zero", "0 9 0 * *"},
+		{"month out of range", "0 9 * 13 *"},
+		{"day of week out of range", "0 9 * * 8"},
+		{"reversed range", "0 9 * * 5-1"},
+		{"zero step", "*/0 * * * *"},
+		{"not a number", "a 9 * * *"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCron(tt.expr); err == nil {
+				t.Fatalf("ParseCron(%q) accepted an invalid expression", tt.expr)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// Monday 15 January 2024, 10:30 UTC
+	from := time.Date(2024, time.January, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{"every minute", "* * * * *", time.Date(2024, time.January, 15, 10, 31, 0, 0, time.UTC)},
+		{"strictly after the given time", "30 10 * * *", time.Date(2024, time.January, 16, 10, 30, 0, 0, time.UTC)},
+		{"later the same day", "0 18 * * *", time.Date(2024, time.January, 15, 18, 0, 0, 0, time.UTC)},
+		{"step", "*/20 * * * *", time.Date(2024, time.January, 15, 10, 40, 0, 0, time.UTC)},
+		{"range with step", "0-30/10 11 * * *", time.Date(2024, time.January, 15, 11, 0, 0, 0, time.UTC)},
+		{"list", "0 8,12 * * *", time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)},
+		{"weekdays", "0 9 * * 1-5", time.Date(2024, time.January, 16, 9, 0, 0, 0, time.UTC)},
+		{"7 is Sunday", "0 9 * * 7", time.Date(2024, time.January, 21, 9, 0, 0, 0, time.UTC)},
+		{"day of month", "0 0 1 * *", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"either day field matches", "0 9 20 * 3", time.Date(2024, time.January, 17, 9, 0, 0, 0, time.UTC)},
+		{"no match", "0 0 31 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) returned %v", tt.expr, err)
+			}
+			if got := cron.Next(from); !got.Equal(tt.want) {
+				t.Fatalf("Next(%s) for %q = %s, want %s", from, tt.expr, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package utils
+
+import "strings"
+
+// MaskAccountNumber hides all but the last four characters of an account or card number.
+func MaskAccountNumber(accNo string) string {
+	if len(accNo) <= 4 {
+		return strings.Repeat("*", len(accNo))
+	}
+	return strings.Repeat("*", len(accNo)-4) + accNo[len(accNo)-4:]
+}
+
+// MaskUPI hides the handle of a UPI ID except its first two characters, keeping the bank suffix
+// (e.g. "johndoe@okbank" becomes "jo*****@okbank").
+func MaskUPI(upiID string) string {
+	handle, bank, found := strings.Cut(upiID, "@")
+	if !found {
+		return MaskAccountNumber(upiID)
+	}
+	if len(handle) <= 2 {
+		return strings.Repeat("*", len(handle)) + "@" + bank
+	}
+	return handle[:2] + strings.Repeat("*", len(handle)-2) + "@" + bank
+}
+
+// MaskPaymentIdentifier masks a value used to look up an account, picking the masking by field
+// ('upi_id' or 'account_number' / 'card_id').
+func MaskPaymentIdentifier(field, value string) string {
+	if field == "upi_id" {
+		return MaskUPI(value)
+	}
+	return MaskAccountNumber(value)
+}
+
+// MaskName hides most of a person's name, keeping the first two letters of the first word and the
+// first letter of the others (e.g. "John Doe" becomes "Jo** D**").
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		keep := 1
+		if i == 0 {
+			keep = 2
+		}
+		runes := []rune(word)
+		if len(runes) <= keep {
+			keep = len(runes) - 1
+		}
+		words[i] = string(runes[:keep]) + strings.Repeat("*", len(runes)-keep)
+	}
+	return strings.Join(words, " ")
+}
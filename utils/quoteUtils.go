@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// SignQuoteID returns the quote ID handed to clients: "<id>.<signature>", where the signature is the hex
+// HMAC-SHA256 of "quote.<id>.<userID>". It ties the quote to the user it was given to, so IDs cannot be
+// guessed or redeemed by anyone else.
+func SignQuoteID(secret, id, userID string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("quote." + id + "." + userID))
+	return id + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyQuoteID checks the signature of a quote ID for the user and returns the quote document ID.
+func VerifyQuoteID(secret, quoteID, userID string) (string, error) {
+	id, _, found := strings.Cut(quoteID, ".")
+	if !found || id == "" || !hmac.Equal([]byte(SignQuoteID(secret, id, userID)), []byte(quoteID)) {
+		return "", errors.New("invalid quote ID")
+	}
+	return id, nil
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyQuoteID(t *testing.T) {
+	const secret = "test-quote-key"
+	signed := SignQuoteID(secret, "q1", "alice")
+	id, signature, _ := strings.Cut(signed, ".")
+
+	tests := []struct {
+		name    string
+		secret  string
+		quoteID string
+		userID  string
+		wantID  string
+	}{
+		{"signed for the user", secret, signed, "alice", "q1"},
+		{"another user", secret, signed, "bob", ""},
+		{"another secret", "other-key", signed, "alice", ""},
+		{"another document ID", secret, "q2." + signature, "alice", ""},
+		{"tampered signature", secret, id + "." + strings.Repeat("0", len(signature)), "alice", ""},
+		{"truncated signature", secret, signed[:len(signed)-1], "alice", ""},
+		{"no signature", secret, "q1", "alice", ""},
+		{"empty document ID", secret, SignQuoteID(secret, "", "alice"), "alice", ""},
+		{"empty quote ID", secret, "", "alice", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyQuoteID(tt.secret, tt.quoteID, tt.userID)
+			if tt.wantID == "" {
+				if err == nil {
+					t.Fatalf("VerifyQuoteID(%q, %s) = %q, want an error", tt.quoteID, tt.userID, got)
+				}
+				return
+			}
+			if err != nil || got != tt.wantID {
+				t.Fatalf("VerifyQuoteID(%q, %s) = %q, %v; want %q", tt.quoteID, tt.userID, got, err, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestSignQuoteIDDependsOnUser(t *testing.T) {
+	if SignQuoteID("test-quote-key", "q1", "alice") == SignQuoteID("test-quote-key", "q1", "bob") {
+		t.Fatal("quote IDs signed for different users are equal")
+	}
+	if !strings.HasPrefix(SignQuoteID("test-quote-key", "q1", "alice"), "q1.") {
+		t.Fatal("signed quote ID does not start with the document ID")
+	}
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type contextKey string
+
+const (
+	requestIDKey contextKey = "request_id"
+	userIDKey    contextKey = "user_id"
+)
+
+func init() {
+	// Code running outside a request (workers, the consumer) has no logger in its context;
+	// log.Ctx falls back to the global logger instead of discarding the event.
+	zerolog.DefaultContextLogger = &log.Logger
+}
+
+// WithRequestID returns ctx carrying the request's correlation ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the correlation ID of the request, or "" outside a request.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithUserID returns ctx carrying the ID of the authenticated user.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext returns the ID of the authenticated user, or "" if there is none.
+func UserIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDKey).(string)
+	return userID
+}
+
+// WithLogField returns ctx with a logger that adds the field to every event logged through log.Ctx(ctx).
+func WithLogField(ctx context.Context, key, value string) context.Context {
+	logger := log.Ctx(ctx).With().Str(key, value).Logger()
+	return logger.WithContext(ctx)
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+// rfc6238Secret is the SHA-1 test secret of RFC 6238 ("12345678901234567890"), base32 encoded.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestMatchTOTP(t *testing.T) {
+	// The RFC 6238 test vectors, truncated to the six digits authenticator apps show
+	tests := []struct {
+		name      string
+		code      string
+		now       int64
+		wantStep  int64
+		wantMatch bool
+	}{
+		{"current step", "287082", 59, 1, true},
+		{"current step, later vector", "081804", 1111111109, 37037036, true},
+		{"current step, third vector", "005924", 1234567890, 41152263, true},
+		{"previous step", "287082", 89, 1, true},
+		{"next step", "287082", 29, 1, true},
+		{"two steps old", "287082", 119, 0, false},
+		{"wrong code", "123456", 59, 0, false},
+		{"empty code", "", 59, 0, false},
+		{"eight digit code", "94287082", 59, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := MatchTOTP(rfc6238Secret, tt.code, time.Unix(tt.now, 0))
+			if ok != tt.wantMatch || step != tt.wantStep {
+				t.Fatalf("MatchTOTP(%q, %d) = %d, %v; want %d, %v", tt.code, tt.now, step, ok, tt.wantStep, tt.wantMatch)
+			}
+		})
+	}
+
+	if _, ok := MatchTOTP("not base32!", "287082", time.Unix(59, 0)); ok {
+		t.Fatal("MatchTOTP matched a code against an invalid secret")
+	}
+}
+
+func TestNewTOTPSecretCodesMatch(t *testing.T) {
+	secret, url, err := NewTOTPSecret("go-transaction", "alice@example.com")
+	if err != nil {
+		t.Fatalf("NewTOTPSecret returned %v", err)
+	}
+	if url == "" {
+		t.Fatal("NewTOTPSecret returned no otpauth URL")
+	}
+
+	now := time.Unix(1700000000, 0)
+	code, err := totp.GenerateCode(secret, now)
+	if err != nil {
+		t.Fatalf("unable to generate a code for the secret: %v", err)
+	}
+	if step, ok := MatchTOTP(secret, code, now); !ok || step != now.Unix()/totpPeriod {
+		t.Fatalf("MatchTOTP of a fresh code = %d, %v; want step %d", step, ok, now.Unix()/totpPeriod)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"go-transaction/entity"
+	"io"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 )
 
 // ReadRequestBody decodes the request body into a RequestBody object
@@ -17,13 +20,21 @@ func ReadRequestBody(req *http.Request, data *entity.RequestBody) error {
 		return err
 	}
 
+	return ValidateRequestBody(data)
+}
+
+// ValidateRequestBody validates the required fields and payment details of a RequestBody.
+func ValidateRequestBody(data *entity.RequestBody) error {
 	if strings.EqualFold(data.TransactionType, "Payment") && data.TransactionType != ""{
 		return errors.New("Transaction Type must be Payment")
 	}
 
 	// Validate required fields
-	if data.SenderID == "" || data.PaymentMethod == "" || data.RecievingMethod == "" {
-		return errors.New("SenderID, PaymentMethod, and RecievingMethod are required")
+	if data.SenderID == "" || data.PaymentMethod == "" || (data.RecievingMethod == "" && data.BeneficiaryID == "") {
+		return errors.New("SenderID, PaymentMethod, and RecievingMethod or BeneficiaryID are required")
+	}
+	if data.Amount <= 0 {
+		return errors.New("Amount must be greater than 0")
 	}
 
 	// Validate Sender Payment Details
@@ -31,6 +42,11 @@ func ReadRequestBody(req *http.Request, data *entity.RequestBody) error {
 		return err
 	}
 
+	// The receiver of a transfer to a beneficiary is filled in from the saved beneficiary
+	if data.BeneficiaryID != "" {
+		return nil
+	}
+
 	// Validate Receiver Payment Details
 	if err := validatePaymentDetails(data.RecievingMethod, data.ReceiverPaymentDetails); err != nil {
 		return err
@@ -68,6 +84,11 @@ func ReadMakePaymentRequest(req *http.Request, data *entity.MakePaymentRequest)
 		return err
 	}
 
+	return ValidateMakePaymentRequest(data)
+}
+
+// ValidateMakePaymentRequest validates the required fields, amount and UPI details of a MakePaymentRequest.
+func ValidateMakePaymentRequest(data *entity.MakePaymentRequest) error {
 
 	if strings.EqualFold(data.TransactionType, "Request") && data.TransactionType != "" {
 		return errors.New("Transaction Type must be Request")
@@ -81,6 +102,10 @@ func ReadMakePaymentRequest(req *http.Request, data *entity.MakePaymentRequest)
 		return errors.New("Amount must be greater than 0")
 	}
 
+	if data.ExpiresIn < 0 {
+		return errors.New("ExpiresIn must not be negative")
+	}
+
 	if !strings.EqualFold(data.RequesterPaymentMethod, "upi") || !strings.EqualFold(data.PayerPaymentMethod, "upi") {
 		return errors.New("Only UPI is supported for PaymentMethod and RecievingMethod")
 	}
@@ -105,12 +130,21 @@ func ReadPaymentRequestAction(req *http.Request, data *entity.PaymentRequestActi
 		return err
 	}
 
+	return ValidatePaymentRequestAction(data)
+}
+
+// ValidatePaymentRequestAction validates the required fields and action type of a PaymentRequestAction.
+func ValidatePaymentRequestAction(data *entity.PaymentRequestAction) error {
 	if data.RequestID == "" || data.Action == "" {
 		return errors.New("TransactionID and UserID are required")
 	}
 
-	if !strings.EqualFold(data.Action, "Accept") && !strings.EqualFold(data.Action, "Cancel") {
-		return errors.New("Invalid action. Action must be either 'Accept' or 'Reject'")
+	if !strings.EqualFold(data.Action, "Accept") && !strings.EqualFold(data.Action, "Cancel") && !strings.EqualFold(data.Action, "Decline") {
+		return errors.New("Invalid action. Action must be either 'Accept', 'Cancel' or 'Decline'")
+	}
+
+	if strings.EqualFold(data.Action, "Decline") && strings.TrimSpace(data.Reason) == "" {
+		return errors.New("Reason is required to decline a request")
 	}
 
 	return nil
@@ -123,3 +157,343 @@ func validateUPIDetails(upi entity.UPIDetails) error {
 	}
 	return nil
 }
+
+// ReadReviewDecision decodes the request body into a ReviewDecision object
+// and validates that a note explaining the decision is present.
+func ReadReviewDecision(req *http.Request, data *entity.ReviewDecision) error {
+	err := json.NewDecoder(req.Body).Decode(data)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(data.Note) == "" {
+		return errors.New("Note is required for a review decision")
+	}
+
+	return nil
+}
+
+// ReadCaptureRequest decodes the request body into a CaptureRequest object.
+// An empty body is accepted and captures the full remaining amount.
+func ReadCaptureRequest(req *http.Request, data *entity.CaptureRequest) error {
+	err := json.NewDecoder(req.Body).Decode(data)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	if data.Amount < 0 {
+		return errors.New("Amount must not be negative")
+	}
+
+	return nil
+}
+
+// ReadScheduleRequest decodes the request body into a ScheduleRequest object
+// and validates the transfer, the frequency and the end conditions.
+func ReadScheduleRequest(req *http.Request, data *entity.ScheduleRequest) error {
+	err := json.NewDecoder(req.Body).Decode(data)
+	if err != nil {
+		return err
+	}
+
+	if err := ValidateRequestBody(&data.Transfer); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(data.Frequency) {
+	case "once", "daily", "weekly", "monthly":
+	case "cron":
+		if _, err := ParseCron(data.Cron); err != nil {
+			return err
+		}
+	default:
+		return errors.New("Invalid frequency. Frequency must be one of 'once', 'daily', 'weekly', 'monthly' or 'cron'")
+	}
+
+	if data.StartAt <= time.Now().Unix() {
+		return errors.New("StartAt must be in the future")
+	}
+
+	if data.EndAt != 0 && data.EndAt < data.StartAt {
+		return errors.New("EndAt must not be before StartAt")
+	}
+
+	if data.MaxRuns < 0 {
+		return errors.New("MaxRuns must not be negative")
+	}
+
+	return nil
+}
+
+// ReadSplitRequest decodes the request body into a SplitRequest object
+// and validates the requester, the split type and every payer.
+func ReadSplitRequest(req *http.Request, data *entity.SplitRequest) error {
+	err := json.NewDecoder(req.Body).Decode(data)
+	if err != nil {
+		return err
+	}
+
+	if data.RequesterID == "" || data.RequesterPaymentMethod == "" {
+		return errors.New("RequesterID and RequesterPaymentMethod are required")
+	}
+
+	if !strings.EqualFold(data.RequesterPaymentMethod, "upi") {
+		return errors.New("Only UPI is supported for RequesterPaymentMethod")
+	}
+
+	if err := validateUPIDetails(data.RequesterPaymentDetails.UPI); err != nil {
+		return errors.New("Invalid requester UPI details: " + err.Error())
+	}
+
+	if data.ExpiresIn < 0 {
+		return errors.New("ExpiresIn must not be negative")
+	}
+
+	switch strings.ToLower(data.SplitType) {
+	case "equal", "percentage":
+		if data.TotalAmount <= 0 {
+			return errors.New("TotalAmount must be greater than 0")
+		}
+	case "exact":
+	default:
+		return errors.New("Invalid split type. SplitType must be one of 'equal', 'percentage' or 'exact'")
+	}
+
+	if len(data.Payers) < 2 {
+		return errors.New("A split request needs at least 2 payers")
+	}
+
+	seen := make(map[string]bool)
+	for _, payer := range data.Payers {
+		if payer.PayerID == "" || payer.PayerPaymentMethod == "" {
+			return errors.New("PayerID and PayerPaymentMethod are required for every payer")
+		}
+		if strings.EqualFold(payer.PayerID, data.RequesterID) {
+			return errors.New("The requester cannot be one of the payers")
+		}
+		if seen[strings.ToLower(payer.PayerID)] {
+			return errors.New("Duplicate payer: " + payer.PayerID)
+		}
+		seen[strings.ToLower(payer.PayerID)] = true
+
+		if !strings.EqualFold(payer.PayerPaymentMethod, "upi") {
+			return errors.New("Only UPI is supported for PayerPaymentMethod")
+		}
+		if err := validateUPIDetails(payer.PayerPaymentDetails.UPI); err != nil {
+			return errors.New("Invalid payer UPI details: " + err.Error())
+		}
+
+		switch strings.ToLower(data.SplitType) {
+		case "percentage":
+			if payer.Percentage <= 0 {
+				return errors.New("Percentage must be greater than 0 for every payer")
+			}
+		case "exact":
+			if payer.Amount <= 0 {
+				return errors.New("Amount must be greater than 0 for every payer")
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReadWebhookSubscriptionRequest decodes the request body into a WebhookSubscriptionRequest object
+// and validates the URL, the event types and the secret. The URL must be https and its host must
+// resolve to public addresses only.
+func ReadWebhookSubscriptionRequest(req *http.Request, data *entity.WebhookSubscriptionRequest) error {
+	err := json.NewDecoder(req.Body).Decode(data)
+	if err != nil {
+		return err
+	}
+
+	target, err := url.Parse(data.URL)
+	if err != nil || target.Scheme != "https" || target.Hostname() == "" {
+		return errors.New("URL must be an absolute https URL")
+	}
+	if _, err := ResolveWebhookHost(req.Context(), target.Hostname()); err != nil {
+		return err
+	}
+
+	for _, eventType := range data.EventTypes {
+		switch eventType {
+		case "payment_request.accepted", "payment_request.cancelled", "payment_request.declined", "payment_request.expired":
+		default:
+			return errors.New("Unsupported event type: " + eventType)
+		}
+	}
+
+	if data.Secret != "" && len(data.Secret) < 16 {
+		return errors.New("Secret must be at least 16 characters long")
+	}
+
+	return nil
+}
+
+// ReadTOTPCode decodes the request body into a TOTPCode object and checks the code is six digits.
+func ReadTOTPCode(req *http.Request, data *entity.TOTPCode) error {
+	err := json.NewDecoder(req.Body).Decode(data)
+	if err != nil {
+		return err
+	}
+
+	data.Code = strings.TrimSpace(data.Code)
+	if len(data.Code) != 6 || strings.Trim(data.Code, "0123456789") != "" {
+		return errors.New("Code must be 6 digits")
+	}
+
+	return nil
+}
+
+// ReadUPIPinSetup decodes the request body into a UPIPinSetup object and validates the UPI ID and PIN.
+func ReadUPIPinSetup(req *http.Request, data *entity.UPIPinSetup) error {
+	err := json.NewDecoder(req.Body).Decode(data)
+	if err != nil {
+		return err
+	}
+
+	if err := validateUPIDetails(entity.UPIDetails{UpiId: data.UpiID}); err != nil {
+		return err
+	}
+	return validatePin(data.Pin)
+}
+
+// ReadUPIPinReset decodes the request body into a UPIPinReset object and validates the UPI ID,
+// password and new PIN.
+func ReadUPIPinReset(req *http.Request, data *entity.UPIPinReset) error {
+	err := json.NewDecoder(req.Body).Decode(data)
+	if err != nil {
+		return err
+	}
+
+	if err := validateUPIDetails(entity.UPIDetails{UpiId: data.UpiID}); err != nil {
+		return err
+	}
+	if data.Password == "" {
+		return errors.New("Password is required to reset the PIN")
+	}
+	return validatePin(data.Pin)
+}
+
+// validatePin checks a PIN only has digits; its length is checked against the configuration by the service.
+func validatePin(pin string) error {
+	if pin == "" || strings.Trim(pin, "0123456789") != "" {
+		return errors.New("PIN must contain only digits")
+	}
+	return nil
+}
+
+// ReadBeneficiaryRequest decodes the request body into a BeneficiaryRequest object
+// and validates the nickname, receiving method and payment details.
+func ReadBeneficiaryRequest(req *http.Request, data *entity.BeneficiaryRequest) error {
+	err := json.NewDecoder(req.Body).Decode(data)
+	if err != nil {
+		return err
+	}
+
+	if err := validateNickname(data.Nickname); err != nil {
+		return err
+	}
+
+	return validateReceivingDetails(&data.Method, data.Details)
+}
+
+// ReadBeneficiaryRename decodes the request body into a BeneficiaryRename object and validates the nickname.
+func ReadBeneficiaryRename(req *http.Request, data *entity.BeneficiaryRename) error {
+	err := json.NewDecoder(req.Body).Decode(data)
+	if err != nil {
+		return err
+	}
+
+	return validateNickname(data.Nickname)
+}
+
+// validateNickname checks a beneficiary nickname is present and at most 50 characters long.
+func validateNickname(nickname string) error {
+	nickname = strings.TrimSpace(nickname)
+	if nickname == "" {
+		return errors.New("Nickname is required")
+	}
+	if len([]rune(nickname)) > 50 {
+		return errors.New("Nickname must be at most 50 characters")
+	}
+	return nil
+}
+
+// ReadAccountVerifyRequest decodes the request body into an AccountVerifyRequest object
+// and validates the method and payment details.
+func ReadAccountVerifyRequest(req *http.Request, data *entity.AccountVerifyRequest) error {
+	err := json.NewDecoder(req.Body).Decode(data)
+	if err != nil {
+		return err
+	}
+
+	return validateReceivingDetails(&data.Method, data.Details)
+}
+
+// validateReceivingDetails upper-cases a receiving method, checks it is 'UPI' or 'BANK'
+// and validates the matching payment details.
+func validateReceivingDetails(method *string, details entity.PaymentDetails) error {
+	*method = strings.ToUpper(*method)
+	if *method != "UPI" && *method != "BANK" {
+		return errors.New("Method must be either 'UPI' or 'BANK'")
+	}
+	return validatePaymentDetails(*method, details)
+}
+
+// ReadAccountStatusChange decodes the request body into an AccountStatusChange object
+// and validates the status and that a reason is given.
+func ReadAccountStatusChange(req *http.Request, data *entity.AccountStatusChange) error {
+	err := json.NewDecoder(req.Body).Decode(data)
+	if err != nil {
+		return err
+	}
+
+	data.Status = strings.ToLower(strings.TrimSpace(data.Status))
+	switch data.Status {
+	case entity.AccountActive, entity.AccountDebitFrozen, entity.AccountFrozen, entity.AccountClosed, entity.AccountDormant:
+	default:
+		return errors.New("Status must be one of 'active', 'debit_frozen', 'frozen', 'closed' or 'dormant'")
+	}
+
+	if strings.TrimSpace(data.Reason) == "" {
+		return errors.New("Reason is required to change an account's status")
+	}
+	return nil
+}
+
+// ReadCreditLimitChange decodes the request body into a CreditLimitChange object
+// and validates the limit and the reason for the change.
+func ReadCreditLimitChange(req *http.Request, data *entity.CreditLimitChange) error {
+	err := json.NewDecoder(req.Body).Decode(data)
+	if err != nil {
+		return err
+	}
+
+	if data.CreditLimit < 0 {
+		return errors.New("Credit limit must not be negative")
+	}
+	if strings.TrimSpace(data.Reason) == "" {
+		return errors.New("Reason is required to change an account's credit limit")
+	}
+	return nil
+}
+
+// ReadFeeQuoteRequest decodes the request body into a FeeQuoteRequest object
+// and validates the amount and both payment methods.
+func ReadFeeQuoteRequest(req *http.Request, data *entity.FeeQuoteRequest) error {
+	err := json.NewDecoder(req.Body).Decode(data)
+	if err != nil {
+		return err
+	}
+
+	if data.Amount <= 0 {
+		return errors.New("Amount must be greater than 0")
+	}
+	data.PaymentMethod = strings.ToUpper(data.PaymentMethod)
+	data.RecievingMethod = strings.ToUpper(data.RecievingMethod)
+	if data.PaymentMethod == "" || data.RecievingMethod == "" {
+		return errors.New("Payment Method and Recieving Method are required")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"go-transaction/entity"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateRequestBodyAmount(t *testing.T) {
+	upi := entity.PaymentDetails{UPI: entity.UPIDetails{UpiId: "alice@bank"}}
+	tests := []struct {
+		name    string
+		amount  float64
+		wantErr bool
+	}{
+		{"positive amount", 250, false},
+		{"zero amount", 0, true},
+		{"negative amount", -250, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := entity.RequestBody{
+				SenderID:               "alice",
+				Amount:                 tt.amount,
+				PaymentMethod:          "UPI",
+				RecievingMethod:        "UPI",
+				SenderPaymentDetails:   upi,
+				ReceiverPaymentDetails: entity.PaymentDetails{UPI: entity.UPIDetails{UpiId: "bob@bank"}},
+			}
+			if err := ValidateRequestBody(&body); (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateRequestBody returned %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadWebhookSubscriptionRequestURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://93.184.216.34/hooks", true},
+		{"http://93.184.216.34/hooks", false},
+		{"ftp://93.184.216.34/hooks", false},
+		{"/hooks", false},
+		{"https://127.0.0.1/hooks", false},
+		{"https://localhost:8443/hooks", false},
+		{"https://169.254.169.254/latest/meta-data", false},
+		{"https://10.0.0.8/hooks", false},
+		{"https://[::1]/hooks", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/webhooks", strings.NewReader(`{"url":"`+tt.url+`"}`))
+		var data entity.WebhookSubscriptionRequest
+		if err := ReadWebhookSubscriptionRequest(req, &data); (err == nil) != tt.allowed {
+			t.Errorf("ReadWebhookSubscriptionRequest(%s) = %v, allowed %v", tt.url, err, tt.allowed)
+		}
+	}
+}
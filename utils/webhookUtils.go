@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// SignWebhook returns the signature sent in the X-Webhook-Signature header:
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the subscription secret.
+// Receivers recompute it to check the sender and reject old timestamps to stop replays.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook reports whether signature is the valid signature of body and timestamp for the secret.
+func VerifyWebhook(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhook(secret, timestamp, body)), []byte(signature))
+}
+
+// blockedWebhookNets are address ranges that are private or reserved but not covered by the net.IP predicates.
+var blockedWebhookNets = []*net.IPNet{
+	mustParseCIDR("100.64.0.0/10"), // carrier-grade NAT
+	mustParseCIDR("192.0.0.0/24"),  // IETF protocol assignments
+	mustParseCIDR("198.18.0.0/15"), // benchmarking
+	mustParseCIDR("64:ff9b::/96"),  // NAT64, which can reach IPv4 addresses
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return ipNet
+}
+
+// WebhookAddressAllowed reports whether webhooks may be delivered to ip. Loopback, link-local (which
+// includes cloud metadata endpoints such as 169.254.169.254), private, unspecified and multicast addresses
+// are refused, so subscriptions cannot make the dispatcher reach internal services.
+func WebhookAddressAllowed(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsPrivate() ||
+		ip.IsUnspecified() || ip.IsMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, ipNet := range blockedWebhookNets {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveWebhookHost resolves the host of a webhook URL and returns its addresses, or an error if it does
+// not resolve or any of its addresses is refused by WebhookAddressAllowed.
+func ResolveWebhookHost(ctx context.Context, host string) ([]net.IP, error) {
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve webhook host %s: %v", host, err)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("webhook host %s has no addresses", host)
+	}
+	for _, ip := range ips {
+		if !WebhookAddressAllowed(ip) {
+			return nil, fmt.Errorf("webhook host %s resolves to %s, which is not allowed", host, ip)
+		}
+	}
+	return ips, nil
+}
+
+// WebhookDialContext returns a DialContext for the webhook HTTP client that resolves the host itself and
+// only connects to addresses allowed by WebhookAddressAllowed. Checking at dial time, on the address
+// actually dialled, stops a host that passed the check at subscription time from being re-pointed inside.
+func WebhookDialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := ResolveWebhookHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var dialErr error
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			dialErr = err
+		}
+		return nil, dialErr
+	}
+}
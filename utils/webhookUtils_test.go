@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestWebhookAddressAllowed(t *testing.T) {
+	tests := []struct {
+		ip      string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"10.0.0.5", false},
+		{"172.16.3.4", false},
+		{"192.168.1.10", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"64:ff9b::a9fe:a9fe", false},
+	}
+
+	for _, tt := range tests {
+		if got := WebhookAddressAllowed(net.ParseIP(tt.ip)); got != tt.allowed {
+			t.Errorf("WebhookAddressAllowed(%s) = %v, want %v", tt.ip, got, tt.allowed)
+		}
+	}
+}
+
+func TestResolveWebhookHostRefusesInternalAddresses(t *testing.T) {
+	for _, host := range []string{"127.0.0.1", "169.254.169.254", "10.1.2.3", "::1", "localhost"} {
+		if _, err := ResolveWebhookHost(context.Background(), host); err == nil {
+			t.Errorf("ResolveWebhookHost(%s) allowed an internal address", host)
+		}
+	}
+	if _, err := ResolveWebhookHost(context.Background(), "93.184.216.34"); err != nil {
+		t.Errorf("ResolveWebhookHost refused a public address: %v", err)
+	}
+}
+
+func TestWebhookDialContextRefusesLoopback(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	dial := WebhookDialContext(&net.Dialer{})
+	if conn, err := dial(context.Background(), "tcp", listener.Addr().String()); err == nil {
+		conn.Close()
+		t.Fatal("WebhookDialContext connected to a loopback address")
+	}
+}